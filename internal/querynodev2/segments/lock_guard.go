@@ -0,0 +1,100 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build lockguard
+
+package segments
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// guardedRWMutex is the lock of the segment manager,
+// it panics if the goroutine holding it locks it again,
+// which would deadlock once a writer is waiting.
+// It's only enabled with the lockguard build tag, run the tests with -tags lockguard to catch
+// the observers, actions and filters calling back into the manager.
+// The lock must be released by the goroutine acquired it, as the manager always does.
+type guardedRWMutex struct {
+	sync.RWMutex
+
+	holdersMu sync.Mutex
+	holders   map[int64]int // goroutine ID -> lock count
+}
+
+func (m *guardedRWMutex) Lock() {
+	m.enter("Lock")
+	m.RWMutex.Lock()
+}
+
+func (m *guardedRWMutex) Unlock() {
+	m.leave()
+	m.RWMutex.Unlock()
+}
+
+func (m *guardedRWMutex) RLock() {
+	m.enter("RLock")
+	m.RWMutex.RLock()
+}
+
+func (m *guardedRWMutex) RUnlock() {
+	m.leave()
+	m.RWMutex.RUnlock()
+}
+
+func (m *guardedRWMutex) enter(op string) {
+	id := goroutineID()
+	m.holdersMu.Lock()
+	defer m.holdersMu.Unlock()
+	if m.holders[id] > 0 {
+		panic(fmt.Sprintf("segment manager re-entered: goroutine %d calls %s while holding the manager lock, "+
+			"observers, actions and filters must not call back into the manager", id, op))
+	}
+	if m.holders == nil {
+		m.holders = make(map[int64]int)
+	}
+	m.holders[id]++
+}
+
+func (m *guardedRWMutex) leave() {
+	id := goroutineID()
+	m.holdersMu.Lock()
+	defer m.holdersMu.Unlock()
+	if m.holders[id] <= 1 {
+		delete(m.holders, id)
+		return
+	}
+	m.holders[id]--
+}
+
+// goroutineID parses the ID of the current goroutine from its stack header "goroutine 123 [running]:".
+func goroutineID() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, err := strconv.ParseInt(string(buf), 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse goroutine ID: %v", err))
+	}
+	return id
+}
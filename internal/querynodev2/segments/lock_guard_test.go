@@ -0,0 +1,77 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build lockguard
+
+package segments
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+func TestLockGuardReentrantFilter(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+	mgr.Put(SegmentTypeSealed, newMockSealedSegment(t, 1))
+
+	reentrant := SegmentFilterFunc(func(segment Segment) bool {
+		return len(mgr.GetBy(WithID(segment.ID()))) > 0
+	})
+	defer func() {
+		r := recover()
+		assert.NotNil(t, r)
+		assert.Contains(t, r, "segment manager re-entered")
+		assert.Contains(t, r, "calls RLock while holding the manager lock")
+	}()
+	mgr.GetBy(reentrant)
+}
+
+func TestLockGuardReentrantAction(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+	mgr.Put(SegmentTypeSealed, newMockSealedSegment(t, 1))
+
+	assert.Panics(t, func() {
+		mgr.UpdateByExclusive(func(segment Segment) bool {
+			mgr.Put(SegmentTypeSealed, newMockSealedSegment(t, 2))
+			return true
+		})
+	})
+	// the outer lock is released by the deferred unlock
+	assert.Len(t, mgr.GetBy(), 1)
+}
+
+func TestLockGuardConcurrent(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			mgr.Put(SegmentTypeSealed, newMockSealedSegment(t, id))
+			assert.NotEmpty(t, mgr.GetBy(WithID(id)))
+		}(int64(i))
+	}
+	wg.Wait()
+	assert.Len(t, mgr.GetBy(), 8)
+}
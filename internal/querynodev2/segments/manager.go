@@ -25,20 +25,31 @@ package segments
 import "C"
 
 import (
+	"container/heap"
+	"container/list"
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 	"golang.org/x/sync/singleflight"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus/internal/proto/datapb"
 	"github.com/milvus-io/milvus/internal/proto/querypb"
+	"github.com/milvus-io/milvus/pkg/common"
 	"github.com/milvus-io/milvus/pkg/eventlog"
 	"github.com/milvus-io/milvus/pkg/log"
 	"github.com/milvus-io/milvus/pkg/metrics"
 	"github.com/milvus-io/milvus/pkg/util/cache"
+	"github.com/milvus-io/milvus/pkg/util/hardware"
 	"github.com/milvus-io/milvus/pkg/util/merr"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
@@ -51,6 +62,23 @@ type SegmentFilter interface {
 	SegmentIDs() ([]int64, bool)
 }
 
+// SignedSegmentFilter is the optional interface of SegmentFilter,
+// the segments matching the filters all signed are cached by GetBy if enabled.
+type SignedSegmentFilter interface {
+	SegmentFilter
+	// Signature identifies the filter for caching the matched segments,
+	// empty if the result may change without adding or removing segments
+	Signature() string
+}
+
+// signatureOf returns the signature of the filter, empty if it's not a SignedSegmentFilter.
+func signatureOf(filter SegmentFilter) string {
+	if signed, ok := filter.(SignedSegmentFilter); ok {
+		return signed.Signature()
+	}
+	return ""
+}
+
 // SegmentFilterFunc is a type wrapper for `func(Segment) bool` to SegmentFilter.
 type SegmentFilterFunc func(segment Segment) bool
 
@@ -66,6 +94,17 @@ func (s SegmentFilterFunc) SegmentIDs() ([]int64, bool) {
 	return nil, false
 }
 
+// signedFilter is the SegmentFilterFunc on the immutable segment attributes,
+// which is identified by the signature.
+type signedFilter struct {
+	SegmentFilterFunc
+	signature string
+}
+
+func (f signedFilter) Signature() string {
+	return f.signature
+}
+
 // SegmentIDFilter is the specific segment filter for SegmentID only.
 type SegmentIDFilter int64
 
@@ -81,6 +120,10 @@ func (f SegmentIDFilter) SegmentIDs() ([]int64, bool) {
 	return []int64{int64(f)}, true
 }
 
+func (f SegmentIDFilter) Signature() string {
+	return fmt.Sprintf("id=%d", int64(f))
+}
+
 type SegmentTypeFilter SegmentType
 
 func (f SegmentTypeFilter) Filter(segment Segment) bool {
@@ -95,22 +138,55 @@ func (f SegmentTypeFilter) SegmentIDs() ([]int64, bool) {
 	return nil, false
 }
 
+func (f SegmentTypeFilter) Signature() string {
+	return fmt.Sprintf("type=%s", SegmentType(f).String())
+}
+
 func WithSkipEmpty() SegmentFilter {
 	return SegmentFilterFunc(func(segment Segment) bool {
 		return segment.InsertCount() > 0
 	})
 }
 
+// collectionsFilter is the filter on the collections of the segments,
+// the manager looks up the segments of the collections by the collection index instead of scanning all.
+type collectionsFilter struct {
+	signedFilter
+	collections typeutil.Set[int64]
+}
+
+// WithCollections returns the filter matching segments belonging to any of the collections.
+func WithCollections(collectionIDs ...int64) SegmentFilter {
+	collections := typeutil.NewSet(collectionIDs...)
+	sorted := collections.Collect()
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return collectionsFilter{
+		signedFilter: signedFilter{
+			SegmentFilterFunc: func(segment Segment) bool {
+				return collections.Contain(segment.Collection())
+			},
+			signature: fmt.Sprintf("collections=%v", sorted),
+		},
+		collections: collections,
+	}
+}
+
 func WithPartition(partitionID typeutil.UniqueID) SegmentFilter {
-	return SegmentFilterFunc(func(segment Segment) bool {
-		return segment.Partition() == partitionID
-	})
+	return signedFilter{
+		SegmentFilterFunc: func(segment Segment) bool {
+			return segment.Partition() == partitionID
+		},
+		signature: fmt.Sprintf("partition=%d", partitionID),
+	}
 }
 
 func WithChannel(channel string) SegmentFilter {
-	return SegmentFilterFunc(func(segment Segment) bool {
-		return segment.Shard() == channel
-	})
+	return signedFilter{
+		SegmentFilterFunc: func(segment Segment) bool {
+			return segment.Shard() == channel
+		},
+		signature: fmt.Sprintf("channel=%s", channel),
+	}
 }
 
 func WithType(typ SegmentType) SegmentFilter {
@@ -122,9 +198,91 @@ func WithID(id int64) SegmentFilter {
 }
 
 func WithLevel(level datapb.SegmentLevel) SegmentFilter {
+	return signedFilter{
+		SegmentFilterFunc: func(segment Segment) bool {
+			return segment.Level() == level
+		},
+		signature: fmt.Sprintf("level=%s", level.String()),
+	}
+}
+
+// WithIndexStale returns the filter matching segments which have no index built with `currentIndexID`,
+// the index is either missing or built with an outdated index spec.
+func WithIndexStale(currentIndexID int64) SegmentFilter {
+	return SegmentFilterFunc(func(segment Segment) bool {
+		for _, index := range segment.Indexes() {
+			if index.IndexInfo.GetIndexID() == currentIndexID {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// WithRowsBelowIndexThreshold returns the filter matching segments with fewer inserted rows than `threshold`,
+// which are served by brute force search.
+func WithRowsBelowIndexThreshold(threshold int64) SegmentFilter {
+	return SegmentFilterFunc(func(segment Segment) bool {
+		return segment.InsertCount() < threshold
+	})
+}
+
+// WithRowsReachIndexThreshold returns the filter matching segments with no fewer inserted rows than `threshold`,
+// the complement of WithRowsBelowIndexThreshold.
+func WithRowsReachIndexThreshold(threshold int64) SegmentFilter {
+	return SegmentFilterFunc(func(segment Segment) bool {
+		return segment.InsertCount() >= threshold
+	})
+}
+
+// PinOption changes which of the segments are pinned by GetAndPinWithOptions.
+type PinOption func(*pinOptions)
+
+type pinOptions struct {
+	// preferred is the load status preferred among the duplicates of a segment ID, empty if no preference
+	preferred LoadStatus
+}
+
+// PreferLoadMode returns the pin option preferring the segment in the load status,
+// if a segment ID exists both growing and sealed, or differently loaded,
+// only the duplicate in the load status is pinned.
+func PreferLoadMode(status LoadStatus) PinOption {
+	return func(opts *pinOptions) {
+		opts.preferred = status
+	}
+}
+
+// WithResourceAbove returns the filter matching segments whose estimated resource usage of the dimension exceeds `bytes`,
+// which are the candidates for re-compaction.
+// The negative `bytes` is rejected, the returned filter matches no segment.
+func WithResourceAbove(dim ResourceDimension, bytes int64) SegmentFilter {
+	if bytes < 0 {
+		log.Warn("reject negative resource threshold", zap.Int64("bytes", bytes))
+		return SegmentFilterFunc(func(Segment) bool { return false })
+	}
+	return SegmentFilterFunc(func(segment Segment) bool {
+		return dim.of(segment.ResourceUsageEstimate()) > uint64(bytes)
+	})
+}
+
+// WithPendingDeletes returns the filter matching segments whose last applied delete is older than `afterTs`,
+// which may have deletes after it not applied yet.
+func WithPendingDeletes(afterTs typeutil.Timestamp) SegmentFilter {
 	return SegmentFilterFunc(func(segment Segment) bool {
-		return segment.Level() == level
+		return segment.LastDeltaTimestamp() < afterTs
+	})
+}
+
+// Not returns the filter matching segments which are NOT matched by `f`,
+// the fast path of `f` is dropped since it cannot be negated.
+func Not(f SegmentFilter) SegmentFilter {
+	not := SegmentFilterFunc(func(segment Segment) bool {
+		return !f.Filter(segment)
 	})
+	if signature := signatureOf(f); signature != "" {
+		return signedFilter{SegmentFilterFunc: not, signature: fmt.Sprintf("not(%s)", signature)}
+	}
+	return not
 }
 
 type SegmentAction func(segment Segment) bool
@@ -147,6 +305,371 @@ func IncreaseVersion(version int64) SegmentAction {
 	}
 }
 
+// isUnderMemoryPressure reports whether the memory usage of the node exceeds the overloaded threshold.
+var isUnderMemoryPressure = func() bool {
+	threshold := paramtable.Get().QueryNodeCfg.OverloadedMemoryThresholdPercentage.GetAsFloat()
+	return float64(hardware.GetUsedMemoryCount()) > float64(hardware.GetMemoryCount())*threshold
+}
+
+// rangeCtxCheckInterval is the number of segments visited between two context checks in RangeCtx.
+var rangeCtxCheckInterval = 64
+
+// diskShrinkCheckInterval is the interval to retry shrinking the disk cache in WaitForDiskBelow.
+var diskShrinkCheckInterval = 100 * time.Millisecond
+
+// recentReleasesCapacity is the number of the release records kept for RecentReleases,
+// non-positive value disables the recording.
+var recentReleasesCapacity = 128
+
+// thrashHistoryCapacity is the number of the latest eviction and reload cycles kept per segment for ThrashingSegments.
+var thrashHistoryCapacity = 64
+
+// ResourceDimension is the dimension of the segment resource usage.
+type ResourceDimension int32
+
+const (
+	ResourceDimensionMemory ResourceDimension = iota
+	ResourceDimensionDisk
+)
+
+// of returns the usage of the dimension.
+func (d ResourceDimension) of(usage ResourceUsage) uint64 {
+	if d == ResourceDimensionDisk {
+		return usage.DiskSize
+	}
+	return usage.MemorySize
+}
+
+// ScanStats is the cost of a filtered segment scan.
+type ScanStats struct {
+	// Scanned is the number of segments checked by the filters
+	Scanned int
+	// Matched is the number of segments passed the filters
+	Matched int
+	// Elapsed is the time spent on the scan, including waiting for the lock
+	Elapsed time.Duration
+}
+
+// PinTimeoutPolicy decides how GetAndPinWithDeadline handles a segment not pinned within the deadline.
+type PinTimeoutPolicy int32
+
+const (
+	// PinTimeoutSkip skips the segment and reports it as skipped
+	PinTimeoutSkip PinTimeoutPolicy = iota
+	// PinTimeoutError fails the whole call
+	PinTimeoutError
+)
+
+// ReleaseReason is the cause of a segment release.
+type ReleaseReason string
+
+const (
+	// ReleaseReasonReplaced means the segment is replaced by a newer version
+	ReleaseReasonReplaced ReleaseReason = "replaced"
+	// ReleaseReasonRemoved means the segment is removed explicitly
+	ReleaseReasonRemoved ReleaseReason = "removed"
+	// ReleaseReasonEvicted means the segment data is evicted from the disk cache
+	ReleaseReasonEvicted ReleaseReason = "evicted"
+	// ReleaseReasonCleared means the segment is released by clearing the manager
+	ReleaseReasonCleared ReleaseReason = "cleared"
+)
+
+// LoadFailureCategory is the cause of a segment load failure.
+type LoadFailureCategory string
+
+const (
+	// LoadFailureNetwork means the binlogs failed to be read from the storage
+	LoadFailureNetwork LoadFailureCategory = "network"
+	// LoadFailureDecode means the binlogs read are broken
+	LoadFailureDecode LoadFailureCategory = "decode"
+	// LoadFailureCapacity means there is no room for the segment
+	LoadFailureCapacity LoadFailureCategory = "capacity"
+	// LoadFailureCollectionMissing means the collection of the segment is not loaded
+	LoadFailureCollectionMissing LoadFailureCategory = "collection_missing"
+	// LoadFailureOther means the failures not classified
+	LoadFailureOther LoadFailureCategory = "other"
+)
+
+// the segcore error codes of the load failures, see internal/core/src/common/EasyAssert.h
+const (
+	segcoreDataTypeInvalid  int32 = 2007
+	segcoreBucketInvalid    int32 = 2016
+	segcoreObjectNotExist   int32 = 2017
+	segcoreS3Error          int32 = 2018
+	segcoreDataIsEmpty      int32 = 2023
+	segcoreDataFormatBroken int32 = 2024
+)
+
+// loadCachedSegmentFields loads the fields of the sealed segments cached in the disk cache,
+// replaced in the tests to inject the failures.
+var loadCachedSegmentFields = loadSealedSegmentFields
+
+// classifyLoadFailure returns the category of the segment load failure.
+func classifyLoadFailure(err error) LoadFailureCategory {
+	switch {
+	case errors.Is(err, merr.ErrCollectionNotLoaded), errors.Is(err, merr.ErrCollectionNotFound):
+		return LoadFailureCollectionMissing
+	case errors.Is(err, merr.ErrServiceMemoryLimitExceeded), errors.Is(err, merr.ErrServiceDiskLimitExceeded):
+		return LoadFailureCapacity
+	case errors.Is(err, merr.ErrIoUnexpectEOF):
+		return LoadFailureDecode
+	case errors.Is(err, merr.ErrIoFailed), errors.Is(err, merr.ErrIoKeyNotFound), errors.Is(err, context.DeadlineExceeded):
+		return LoadFailureNetwork
+	}
+
+	switch merr.Code(err) {
+	case segcoreBucketInvalid, segcoreObjectNotExist, segcoreS3Error:
+		return LoadFailureNetwork
+	case segcoreDataTypeInvalid, segcoreDataIsEmpty, segcoreDataFormatBroken:
+		return LoadFailureDecode
+	default:
+		return LoadFailureOther
+	}
+}
+
+// ReleaseRecord records a segment release.
+type ReleaseRecord struct {
+	SegmentID    int64
+	CollectionID int64
+	Reason       ReleaseReason
+	Time         time.Time
+}
+
+// AuditOp is the kind of the segment manager operation in the audit trail.
+type AuditOp string
+
+const (
+	AuditOpPut     AuditOp = "put"
+	AuditOpRemove  AuditOp = "remove"
+	AuditOpClear   AuditOp = "clear"
+	AuditOpUpdate  AuditOp = "update"
+	AuditOpHandoff AuditOp = "handoff"
+)
+
+// AuditEntry records a segment manager operation and the segments affected.
+type AuditEntry struct {
+	Op         AuditOp
+	SegmentIDs []int64
+	Time       time.Time
+}
+
+// auditTrail is the ring buffer of the latest operations, the nil trail records nothing.
+type auditTrail struct {
+	mu      sync.Mutex // guards entries and next
+	entries []AuditEntry
+	next    int
+	size    int
+}
+
+func newAuditTrail(size int) *auditTrail {
+	if size <= 0 {
+		return nil
+	}
+	return &auditTrail{
+		entries: make([]AuditEntry, 0, size),
+		size:    size,
+	}
+}
+
+func (trail *auditTrail) record(op AuditOp, segmentIDs []int64) {
+	if trail == nil {
+		return
+	}
+	entry := AuditEntry{
+		Op:         op,
+		SegmentIDs: segmentIDs,
+		Time:       time.Now(),
+	}
+
+	trail.mu.Lock()
+	defer trail.mu.Unlock()
+	if len(trail.entries) < trail.size {
+		trail.entries = append(trail.entries, entry)
+	} else {
+		trail.entries[trail.next] = entry
+	}
+	trail.next = (trail.next + 1) % trail.size
+}
+
+// dump returns the entries from the oldest to the latest.
+func (trail *auditTrail) dump() []AuditEntry {
+	if trail == nil {
+		return nil
+	}
+
+	trail.mu.Lock()
+	defer trail.mu.Unlock()
+	ret := make([]AuditEntry, 0, len(trail.entries))
+	if len(trail.entries) == trail.size {
+		ret = append(ret, trail.entries[trail.next:]...)
+		ret = append(ret, trail.entries[:trail.next]...)
+	} else {
+		ret = append(ret, trail.entries...)
+	}
+	return ret
+}
+
+// filterResultCache is the LRU cache of the segments matching the signed filters, keyed by the joined signatures.
+type filterResultCache struct {
+	mu       sync.Mutex
+	capacity int
+	// order is the entries from the most recently used to the least
+	order   *list.List
+	entries map[string]*list.Element
+	// generation is increased by every clear, see putIfCurrent
+	generation uint64
+}
+
+type filterResultEntry struct {
+	signature string
+	segments  []Segment
+}
+
+func newFilterResultCache(capacity int) *filterResultCache {
+	return &filterResultCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns the copy of the cached segments of the signature.
+func (c *filterResultCache) get(signature string) ([]Segment, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[signature]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return append([]Segment(nil), elem.Value.(*filterResultEntry).segments...), true
+}
+
+// put caches the copy of the segments, the least recently used entry is evicted if full.
+func (c *filterResultCache) put(signature string, segments []Segment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.putLocked(signature, segments)
+}
+
+// current returns the generation to put the segments found from now on with.
+func (c *filterResultCache) current() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// putIfCurrent works like put, but drops the segments if the cache is cleared since the generation,
+// as they may miss the segments put meanwhile.
+func (c *filterResultCache) putIfCurrent(generation uint64, signature string, segments []Segment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation == c.generation {
+		c.putLocked(signature, segments)
+	}
+}
+
+func (c *filterResultCache) putLocked(signature string, segments []Segment) {
+	segments = append([]Segment(nil), segments...)
+	if elem, ok := c.entries[signature]; ok {
+		elem.Value.(*filterResultEntry).segments = segments
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[signature] = c.order.PushFront(&filterResultEntry{signature: signature, segments: segments})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*filterResultEntry).signature)
+	}
+}
+
+func (c *filterResultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if c.order.Len() > 0 {
+		c.order.Init()
+		c.entries = make(map[string]*list.Element)
+	}
+}
+
+func (c *filterResultCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// SegmentInfo is the summary of a segment, detached from the segment itself.
+type SegmentInfo struct {
+	SegmentID    int64
+	CollectionID int64
+	PartitionID  int64
+	Shard        string
+	Type         SegmentType
+	Version      int64
+	NumOfRows    int64
+}
+
+func segmentInfoOf(segment Segment) SegmentInfo {
+	return SegmentInfo{
+		SegmentID:    segment.ID(),
+		CollectionID: segment.Collection(),
+		PartitionID:  segment.Partition(),
+		Shard:        segment.Shard(),
+		Type:         segment.Type(),
+		Version:      segment.Version(),
+		NumOfRows:    segment.InsertCount(),
+	}
+}
+
+// CollectionSummary is the summary of the loaded segments of a collection.
+type CollectionSummary struct {
+	CollectionID int64
+	NumSegments  int
+	NumSealed    int
+	NumGrowing   int
+	// NumRows is the number of the inserted rows, not effected by deletion
+	NumRows int64
+	// IndexedRows is the number of the rows of the segments with index loaded
+	IndexedRows int64
+	// IndexCoverage is the percentage of the rows covered by index, 0 if no row
+	IndexCoverage float64
+}
+
+// PinnedSegment is a pinned segment with the snapshot of its info captured at pin time,
+// the info doesn't change even if the segment is reloaded later.
+type PinnedSegment struct {
+	Segment Segment
+	Info    SegmentInfo
+}
+
+type segmentUsage struct {
+	segment Segment
+	usage   uint64
+}
+
+// segmentUsageHeap is a min heap of segments by resource usage.
+type segmentUsageHeap []segmentUsage
+
+func (h segmentUsageHeap) Len() int           { return len(h) }
+func (h segmentUsageHeap) Less(i, j int) bool { return h[i].usage < h[j].usage }
+func (h segmentUsageHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *segmentUsageHeap) Push(x any) {
+	*h = append(*h, x.(segmentUsage))
+}
+
+func (h *segmentUsageHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
 type actionType int32
 
 const (
@@ -160,8 +683,28 @@ type Manager struct {
 	DiskCache  cache.Cache[int64, Segment]
 }
 
-func NewManager() *Manager {
+// ManagerOption configures the Manager.
+type ManagerOption func(*managerOptions)
+
+type managerOptions struct {
+	evictionPolicy cache.EvictionPolicy[int64]
+}
+
+// WithDiskCacheEvictionPolicy sets the policy picking the segments to evict from the disk cache,
+// the least recently used ones are evicted first by default.
+func WithDiskCacheEvictionPolicy(policy cache.EvictionPolicy[int64]) ManagerOption {
+	return func(opts *managerOptions) {
+		opts.evictionPolicy = policy
+	}
+}
+
+func NewManager(opts ...ManagerOption) *Manager {
+	options := &managerOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
 	diskCap := paramtable.Get().QueryNodeCfg.DiskCapacityLimit.GetAsInt64()
+	segmentNumLimit := paramtable.Get().QueryNodeCfg.DiskCacheSegmentNumLimit.GetAsInt64()
 
 	segMgr := NewSegmentManager()
 	sf := singleflight.Group{}
@@ -171,210 +714,1324 @@ func NewManager() *Manager {
 	}
 
 	manager.DiskCache = cache.NewCacheBuilder[int64, Segment]().WithLazyScavenger(func(key int64) int64 {
-		return int64(segMgr.sealedSegments[key].ResourceUsageEstimate().DiskSize)
-	}, diskCap).WithLoader(func(key int64) (Segment, bool) {
-		log.Debug("cache missed segment", zap.Int64("segmentID", key))
-		segMgr.mu.RLock()
-		defer segMgr.mu.RUnlock()
-
-		segment, ok := segMgr.sealedSegments[key]
-		if !ok {
-			// the segment has been released, just ignore it
-			return nil, false
-		}
-
-		info := segment.LoadInfo()
-		_, err, _ := sf.Do(fmt.Sprint(segment.ID()), func() (interface{}, error) {
-			collection := manager.Collection.Get(segment.Collection())
-			if collection == nil {
-				return nil, merr.WrapErrCollectionNotLoaded(segment.Collection(), "failed to load segment fields")
-			}
-			err := loadSealedSegmentFields(context.Background(), collection, segment.(*LocalSegment), info.BinlogPaths, info.GetNumOfRows(), WithLoadStatus(LoadStatusMapped))
-			return nil, err
-		})
-		if err != nil {
-			log.Warn("cache sealed segment failed", zap.Error(err))
-			return nil, false
-		}
-		return segment, true
+		return int64(segMgr.GetSealed(key).ResourceUsageEstimate().DiskSize)
+	}, diskCap).WithCountLimit(segmentNumLimit).WithEvictionPolicy(options.evictionPolicy).WithLoader(func(ctx context.Context, key int64) (Segment, bool) {
+		return loadCachedSegment(ctx, segMgr, manager.Collection, &sf, key)
+	}).WithEvictable(func(key int64, segment Segment) bool {
+		// the segment pinned by running queries shall not be released
+		return !segMgr.isPinned(segment)
 	}).WithFinalizer(func(key int64, segment Segment) error {
 		log.Debug("evict segment from cache", zap.Int64("segmentID", key))
 		segment.Release(WithReleaseScope(ReleaseScopeData))
+		segMgr.recordRelease(segment, ReleaseReasonEvicted)
+		segMgr.recordEviction(key)
+		eventlog.Record(eventlog.NewRawEvt(eventlog.Level_Info, fmt.Sprintf("Segment %d[%d] evicted, disk size %d", segment.ID(), segment.Collection(), segment.ResourceUsageEstimate().DiskSize)))
 		return nil
 	}).Build()
+	segMgr.materialize = func(ctx context.Context, segment Segment) error {
+		return manager.DiskCache.Do(ctx, segment.ID(), func(Segment) error { return nil })
+	}
 	return manager
 }
 
+// RegisterLazy puts the placeholders of the sealed segments without loading their data,
+// so that the collection appears loaded quickly, the data is loaded through the disk cache on the first GetAndPin.
+// The placeholders are of version 0, so the segments loaded later replace them, the already loaded segments are skipped.
+func (mgr *Manager) RegisterLazy(ctx context.Context, infos []*querypb.SegmentLoadInfo) error {
+	placeholders := make([]Segment, 0, len(infos))
+	releasePlaceholders := func() {
+		for _, segment := range placeholders {
+			segment.Release()
+		}
+	}
+	for _, info := range infos {
+		// the L0 segments have no data to load lazily
+		if info.GetLevel() == datapb.SegmentLevel_L0 || mgr.Segment.GetSealed(info.GetSegmentID()) != nil {
+			continue
+		}
+		collection := mgr.Collection.Get(info.GetCollectionID())
+		if collection == nil {
+			releasePlaceholders()
+			return merr.WrapErrCollectionNotLoaded(info.GetCollectionID(), "failed to register lazy segment")
+		}
+		segment, err := NewSegment(ctx, collection, SegmentTypeSealed, 0, info)
+		if err != nil {
+			releasePlaceholders()
+			return err
+		}
+		placeholders = append(placeholders, segment)
+	}
+
+	mgr.Segment.PutLazy(placeholders...)
+	log.Ctx(ctx).Info("register lazy segments", zap.Int64s("segmentIDs", lo.Map(placeholders, func(segment Segment, _ int) int64 {
+		return segment.ID()
+	})))
+	return nil
+}
+
+// loadCachedSegment loads the fields of the sealed segment missed by the disk cache,
+// the concurrent loads of the same segment are deduplicated by sf.
+func loadCachedSegment(ctx context.Context, segMgr *segmentManager, collections CollectionManager, sf *singleflight.Group, key int64) (Segment, bool) {
+	log.Debug("cache missed segment", zap.Int64("segmentID", key))
+	shard, ok := segMgr.segmentShards.Get(key)
+	if !ok {
+		// the segment has been released, just ignore it
+		return nil, false
+	}
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	segment, ok := shard.sealedSegments[key]
+	if !ok {
+		// the segment has been released, just ignore it
+		return nil, false
+	}
+
+	info := segment.LoadInfo()
+	leader := false
+	_, err, shared := sf.Do(fmt.Sprint(segment.ID()), func() (interface{}, error) {
+		leader = true
+		collection := collections.Get(segment.Collection())
+		if collection == nil {
+			return nil, merr.WrapErrCollectionNotLoaded(segment.Collection(), "failed to load segment fields")
+		}
+		err := loadCachedSegmentFields(ctx, collection, segment.(*LocalSegment), info.BinlogPaths, info.GetNumOfRows(), WithLoadStatus(LoadStatusMapped))
+		return nil, err
+	})
+	// shared is true for the leader as well once any call is deduplicated, tell the leader by whether it ran the load
+	role := metrics.SingleflightLeaderLabel
+	if shared && !leader {
+		role = metrics.SingleflightSharedLabel
+	}
+	metrics.QueryNodeSegmentCacheLoadSingleflightCount.WithLabelValues(fmt.Sprint(paramtable.GetNodeID()), role).Inc()
+	if err != nil {
+		category := classifyLoadFailure(err)
+		log.Warn("cache sealed segment failed", zap.String("category", string(category)), zap.Error(err))
+		eventlog.Record(eventlog.NewRawEvt(eventlog.Level_Warn, fmt.Sprintf("Segment %d[%d] failed to cache, %s failure: %s", segment.ID(), segment.Collection(), category, err.Error())))
+		metrics.QueryNodeSegmentCacheLoadFailedCount.WithLabelValues(fmt.Sprint(paramtable.GetNodeID()), string(category)).Inc()
+		return nil, false
+	}
+	eventlog.Record(eventlog.NewRawEvt(eventlog.Level_Info, fmt.Sprintf("Segment %d[%d] cached, disk size %d", segment.ID(), segment.Collection(), segment.ResourceUsageEstimate().DiskSize)))
+	segMgr.recordReload(key)
+	return segment, true
+}
+
+// PauseEviction stops the disk cache evicting segments, e.g. during a batch load,
+// the eviction resumes automatically after timeout or if the cache grows beyond the overcommit limit.
+func (mgr *Manager) PauseEviction() {
+	params := &paramtable.Get().QueryNodeCfg
+	timeout := params.DiskCacheEvictionPauseTimeout.GetAsDuration(time.Second)
+	hardLimit := int64(float64(params.DiskCapacityLimit.GetAsInt64()) * params.DiskCacheOvercommitRatio.GetAsFloat())
+	log.Info("pause disk cache eviction", zap.Duration("timeout", timeout), zap.Int64("hardLimit", hardLimit))
+	mgr.DiskCache.PauseEviction(timeout, hardLimit)
+}
+
+// Touch marks the cached sealed segment as recently used to keep it from being evicted,
+// it neither pins the segment nor loads it if not cached.
+func (mgr *Manager) Touch(segmentID int64) {
+	mgr.DiskCache.Touch(segmentID)
+}
+
+// WaitForDiskBelow evicts the cached sealed segments until the disk usage of the cache is below bytes,
+// the segments pinned by the running queries are evicted once unpinned, it waits until then or ctx done.
+func (mgr *Manager) WaitForDiskBelow(ctx context.Context, bytes int64) error {
+	ticker := time.NewTicker(diskShrinkCheckInterval)
+	defer ticker.Stop()
+
+	for !mgr.DiskCache.Shrink(bytes) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// VerifyCacheConsistency checks the disk cache against the sealed segments,
+// every cached segment must be still loaded, and every resident segment of the lazy load collections must be cached,
+// returns the divergences found. The check is not atomic, it may report the segments being loaded or released.
+func (mgr *Manager) VerifyCacheConsistency() error {
+	cached := typeutil.NewSet(mgr.DiskCache.Keys()...)
+
+	var stale, untracked []int64
+	for id := range cached {
+		if mgr.Segment.GetSealed(id) == nil {
+			stale = append(stale, id)
+		}
+	}
+	for _, segment := range mgr.Segment.GetBy(WithType(SegmentTypeSealed)) {
+		if segment.LoadStatus() != LoadStatusMapped || cached.Contain(segment.ID()) {
+			continue
+		}
+		collection := mgr.Collection.Get(segment.Collection())
+		if collection != nil && common.IsCollectionLazyLoadEnabled(collection.Schema().GetProperties()...) {
+			untracked = append(untracked, segment.ID())
+		}
+	}
+	if len(stale) == 0 && len(untracked) == 0 {
+		return nil
+	}
+
+	sort.Slice(stale, func(i, j int) bool { return stale[i] < stale[j] })
+	sort.Slice(untracked, func(i, j int) bool { return untracked[i] < untracked[j] })
+	return merr.WrapErrServiceInternal(fmt.Sprintf("disk cache diverges from the sealed segments, stale cached segments %v, untracked resident segments %v", stale, untracked))
+}
+
+// ResumeEviction resumes the disk cache eviction paused by PauseEviction.
+func (mgr *Manager) ResumeEviction() {
+	log.Info("resume disk cache eviction")
+	mgr.DiskCache.ResumeEviction()
+}
+
 type SegmentManager interface {
 	// Put puts the given segments in,
 	// and increases the ref count of the corresponding collection,
 	// dup segments will not increase the ref count
 	Put(segmentType SegmentType, segments ...Segment)
+	// PutCtx works like Put, the log lines and events of the operation carry the trace info in ctx
+	PutCtx(ctx context.Context, segmentType SegmentType, segments ...Segment)
+	// PutWithReport works like Put,
+	// and reports which segments are loaded and which are skipped due to stale version
+	PutWithReport(segmentType SegmentType, segments ...Segment) (loaded []int64, skipped []int64)
+	// TryPut works like PutCtx, but puts nothing and returns ErrServiceRateLimit
+	// if the new growing segments would exceed the max growing segment number of their channel,
+	// so that the caller could slow down the ingestion
+	TryPut(ctx context.Context, segmentType SegmentType, segments ...Segment) error
+	// PutLazy puts the sealed placeholder segments without data in,
+	// the data of a placeholder is loaded through the disk cache on its first GetAndPin
+	PutLazy(segments ...Segment)
+	// UpdateBy applies the action to the segments matching the filters, returns the number of the updated ones,
+	// the action runs under the read lock, so it may only mutate the segment state atomically, e.g. by CAS,
+	// concurrent UpdateBy calls could apply actions to the same segment at the same time
 	UpdateBy(action SegmentAction, filters ...SegmentFilter) int
+	// UpdateByExclusive works like UpdateBy, but runs the actions under the write lock,
+	// for the actions that must not interleave with any other manager operations.
+	// The action may only call back the lock-free lookups: Get, GetMany, GetWithType, GetSealed, GetGrowing, Exists,
+	// HasCollection, Empty, SealedCount and GrowingCount, calling any other manager method deadlocks
+	UpdateByExclusive(action SegmentAction, filters ...SegmentFilter) int
+	// SetVersions advances the versions of the segments matching the filters to target,
+	// returns the IDs of the advanced segments and the ones at or beyond target already or read-only
+	SetVersions(target int64, filters ...SegmentFilter) (applied []int64, conflicted []int64)
+	// VersionLag returns how far the versions of the loaded segments lag behind the latest versions keyed by segment ID,
+	// the segments absent in latest are skipped, the max lag is reported as a gauge
+	VersionLag(latest map[int64]int64) map[int64]int64
+	// Reconcile converges the segments of the type to the desired segment versions keyed by segment ID,
+	// it removes the segments not desired, advances the versions of the existing ones except the read-only ones,
+	// and loads the missing ones by the loader, the loading failures are combined in the returned error
+	Reconcile(desired map[int64]int64, typ SegmentType, loader func(int64) (Segment, error)) (added, removed, updated []int64, err error)
+	// Orphans returns the IDs of the loaded segments absent from the target, sorted
+	Orphans(target typeutil.Set[int64]) []int64
 	Get(segmentID typeutil.UniqueID) Segment
+	// Exists returns whether the segment of the ID exists, either growing or sealed
+	Exists(segmentID typeutil.UniqueID) bool
+	// GetMany returns the found segments keyed by ID, missing IDs are absent in the result
+	GetMany(segmentIDs []int64) map[int64]Segment
 	GetWithType(segmentID typeutil.UniqueID, typ SegmentType) Segment
+	// WaitForSegment blocks until the segment of the ID and type is put in, or ctx done
+	WaitForSegment(ctx context.Context, segmentID int64, typ SegmentType) (Segment, error)
 	GetBy(filters ...SegmentFilter) []Segment
-	// Get segments and acquire the read locks
+	// GetByChecked works like GetBy, but returns an error if the filters contradict each other,
+	// e.g. filtering by different segment types, for which GetBy returns nothing
+	GetByChecked(filters ...SegmentFilter) ([]Segment, error)
+	// GetByWithStats works like GetBy, and reports the cost of the scan
+	GetByWithStats(filters ...SegmentFilter) ([]Segment, ScanStats)
+	// GetByPaged returns the page [offset, offset+limit) of the segments matching the filters ordered by segment ID,
+	// and the total number of the matched segments
+	GetByPaged(offset, limit int, filters ...SegmentFilter) (segments []Segment, total int)
+	// Hierarchy returns the infos of the segments matching the filters,
+	// grouped by collection and then partition, sorted by segment ID.
+	Hierarchy(filters ...SegmentFilter) map[int64]map[int64][]SegmentInfo
+	// RangeCtx iterates the segments matching the filters until fn returns false,
+	// returns the context error if ctx is done before the range finished
+	RangeCtx(ctx context.Context, fn func(Segment) bool, filters ...SegmentFilter) error
+	// Get segments and acquire the read locks, the result is nil if nothing matches
 	GetAndPinBy(filters ...SegmentFilter) ([]Segment, error)
+	// GetAndPinByRequired works like GetAndPinBy, but fails with ErrSegmentNotLoaded if nothing matches
+	GetAndPinByRequired(filters ...SegmentFilter) ([]Segment, error)
+	// GetAndPinByInfo works like GetAndPinBy, and bundles the segments with the snapshots of their info captured at pin time
+	GetAndPinByInfo(filters ...SegmentFilter) ([]PinnedSegment, error)
+	// GetAndPin pins the segments of the IDs matching the filters,
+	// both the growing and the sealed ones are pinned if an ID exists in both
 	GetAndPin(segments []int64, filters ...SegmentFilter) ([]Segment, error)
+	// GetAndPinWithOptions works like GetAndPin, the options choose among the duplicates of an ID, see PreferLoadMode
+	GetAndPinWithOptions(segments []int64, opts []PinOption, filters ...SegmentFilter) ([]Segment, error)
+	// GetAndPinWithDeadline works like GetAndPin, but gives up pinning a segment if it can't be pinned within the deadline,
+	// the segment is skipped and reported in the skipped IDs, or fails the whole call, according to the policy
+	GetAndPinWithDeadline(segments []int64, deadline time.Duration, policy PinTimeoutPolicy, filters ...SegmentFilter) (pinned []Segment, skipped []int64, err error)
+	// Unpin unpins the segments pinned by the GetAndPin calls, the nil entries are skipped
 	Unpin(segments []Segment)
 
 	GetSealed(segmentID typeutil.UniqueID) Segment
 	GetGrowing(segmentID typeutil.UniqueID) Segment
+	// GetByIndexBuildID returns the sealed segment having the index of the build ID loaded, false if not found
+	GetByIndexBuildID(buildID int64) (Segment, bool)
+	// RefreshIndexes re-indexes the sealed segments by the index build IDs,
+	// must be called after loading indexes into the segments already put
+	RefreshIndexes()
 	Empty() bool
+	SealedCount() int
+	GrowingCount() int
+	// LoadedCollections returns the IDs of the collections having any segment loaded, without duplicates
+	LoadedCollections() []int64
+	// HasCollection returns whether any segment of the collection is loaded
+	HasCollection(collectionID int64) bool
+	// ChannelCheckpoint returns the min safe timestamp across the growing segments of the given channel,
+	// false if there is no growing segment on the channel
+	ChannelCheckpoint(channel string) (typeutil.Timestamp, bool)
+	// GrowingRowRange summarizes the rows inserted into the growing segment of the ID,
+	// the min and max timestamps are 0 if nothing inserted, false if the growing segment not found
+	GrowingRowRange(segmentID int64) (minTs, maxTs typeutil.Timestamp, rows int64, ok bool)
+	// MaxTimestamp returns the max timestamp of the inserted rows across the segments matching the filters,
+	// 0 if nothing inserted
+	MaxTimestamp(filters ...SegmentFilter) typeutil.Timestamp
+	// CollectionFreshness returns the max timestamp of the inserts and deletes applied to the segments of the collection,
+	// the data written after it is not visible yet, 0 if nothing applied
+	CollectionFreshness(collectionID int64) (maxAppliedTs typeutil.Timestamp)
+	// TopByResource returns at most n segments with the largest estimated resource usage of the given dimension,
+	// ordered from the largest to the smallest
+	TopByResource(n int, by ResourceDimension) []Segment
+	// CollectionSummary summarizes the segments of the collection in one pass under the lock,
+	// the index coverage is the rows of the segments with index loaded divided by all the rows
+	CollectionSummary(collectionID int64) CollectionSummary
 
 	// Remove removes the given segment,
 	// and decreases the ref count of the corresponding collection,
 	// will not decrease the ref count if the given segment not exists
 	Remove(segmentID typeutil.UniqueID, scope querypb.DataScope) (int, int)
 	RemoveBy(filters ...SegmentFilter) (int, int)
+	// RemoveCtx and RemoveByCtx work like Remove and RemoveBy,
+	// the log lines and events of the operation carry the trace info in ctx
+	RemoveCtx(ctx context.Context, segmentID typeutil.UniqueID, scope querypb.DataScope) (int, int)
+	RemoveByCtx(ctx context.Context, filters ...SegmentFilter) (int, int)
+	// RemoveByAsync works like RemoveBy, the segments are unqueryable once it returns,
+	// but released in the release pool later, so the caller isn't blocked by the slow releases
+	RemoveByAsync(filters ...SegmentFilter) (int, int)
+	// RemoveByWaitPins works like RemoveBy, but waits for the pins of the segments matching the filters to be released until timeout,
+	// the segments still pinned on timeout are kept and their IDs returned
+	RemoveByWaitPins(timeout time.Duration, filters ...SegmentFilter) (int, int, []int64)
+	// Handoff puts the sealed segment in and removes the growing one atomically,
+	// returns the removed growing segment, which shall be released by the caller
+	Handoff(growingID int64, sealed Segment) (Segment, error)
+	// Relabel changes the channel of the loaded segments of the ID without reloading them, e.g. after the vchannel migration,
+	// returns false if no such segment
+	Relabel(segmentID int64, newChannel string) bool
+	// Clear removes and releases all segments, the pinned ones are removed once unpinned
 	Clear()
+	// ClearWaitPins works like Clear, but waits for the pinned segments to be unpinned until ctx done only,
+	// the still pinned segments are kept and reported in the returned error
+	ClearWaitPins(ctx context.Context) error
+	// RecentReleases returns at most n latest release records, ordered from the newest to the oldest
+	RecentReleases(n int) []ReleaseRecord
+	// RecordQueryError counts a failed query on the segment, ignored if the segment not loaded,
+	// the count is dropped once the segment released
+	RecordQueryError(segmentID int64)
+	// UnhealthySegments returns the IDs of the segments with more query errors than threshold, in ascending order
+	UnhealthySegments(threshold int) []int64
+	// Quarantine excludes the loaded segment of the ID from GetAndPin and GetAndPinBy without removing it,
+	// until Unquarantine called or the segment released
+	Quarantine(segmentID int64, reason string)
+	Unquarantine(segmentID int64)
+	// QuarantinedSegments returns the reasons of the quarantined segments keyed by segment ID
+	QuarantinedSegments() map[int64]string
+	// SetReadOnly marks the loaded segment of the ID read-only or not,
+	// the read-only segment still serves reads, but UpdateBy, UpdateByExclusive and SetVersions skip it,
+	// and Reconcile doesn't advance its version, until unmarked or the segment released
+	SetReadOnly(segmentID int64, ro bool)
+	// ReadOnlySegments returns the IDs of the read-only segments in ascending order
+	ReadOnlySegments() []int64
+	// ThrashingSegments returns the IDs of the segments evicted from the disk cache and reloaded
+	// at least minCycles times within the latest window, in ascending order,
+	// the cycles are dropped once the segment released
+	ThrashingSegments(minCycles int, window time.Duration) []int64
+
+	// DumpAudit returns the latest put, remove, clear, update and handoff operations from the oldest,
+	// nothing if the audit trail is disabled
+	DumpAudit() []AuditEntry
 }
 
 var _ SegmentManager = (*segmentManager)(nil)
 
-// Manager manages all collections and segments
-type segmentManager struct {
-	mu sync.RWMutex // guards all
+// segmentSnapshot is an immutable copy of the segment maps.
+type segmentSnapshot struct {
+	growing map[typeutil.UniqueID]Segment
+	sealed  map[typeutil.UniqueID]Segment
+}
+
+// collectionShard is the segments of a collection,
+// the operations on the segments of different collections don't contend.
+type collectionShard struct {
+	mu guardedRWMutex // guards all
+
+	collection int64
+	// dropped marks the shard removed from the manager once empty, the puts locking it retry with a new one
+	dropped bool
 
 	growingSegments map[typeutil.UniqueID]Segment
 	sealedSegments  map[typeutil.UniqueID]Segment
+	// snapshot is the copy of the segment maps published on every mutation,
+	// the point lookups read it without locking
+	snapshot atomic.Pointer[segmentSnapshot]
+	// dirtyGrowing and dirtySealed mark the maps changed since the snapshot published
+	dirtyGrowing, dirtySealed bool
+
+	// segmentShards and indexBuilds are shared by all the shards, see segmentManager
+	segmentShards *typeutil.ConcurrentMap[int64, *collectionShard]
+	indexBuilds   *typeutil.ConcurrentMap[int64, int64]
+	// segmentBuilds is the build IDs indexed for the sealed segments keyed by segment ID
+	segmentBuilds map[int64][]int64
 }
 
-func NewSegmentManager() *segmentManager {
-	mgr := &segmentManager{
+func newCollectionShard(collection int64, segmentShards *typeutil.ConcurrentMap[int64, *collectionShard], indexBuilds *typeutil.ConcurrentMap[int64, int64]) *collectionShard {
+	shard := &collectionShard{
+		collection:      collection,
 		growingSegments: make(map[int64]Segment),
 		sealedSegments:  make(map[int64]Segment),
+		segmentShards:   segmentShards,
+		indexBuilds:     indexBuilds,
+		segmentBuilds:   make(map[int64][]int64),
 	}
-	return mgr
+	shard.snapshot.Store(&segmentSnapshot{
+		growing: make(map[int64]Segment),
+		sealed:  make(map[int64]Segment),
+	})
+	return shard
 }
 
-func (mgr *segmentManager) Put(segmentType SegmentType, segments ...Segment) {
-	var replacedSegment []Segment
-	mgr.mu.Lock()
-	defer mgr.mu.Unlock()
-	var targetMap map[int64]Segment
-	switch segmentType {
-	case SegmentTypeGrowing:
-		targetMap = mgr.growingSegments
-	case SegmentTypeSealed:
-		targetMap = mgr.sealedSegments
-	default:
-		panic("unexpected segment type")
+// Manager manages all collections and segments
+type segmentManager struct {
+	shardsMu sync.RWMutex // guards shards, never held while locking a shard
+	// shards is the segments keyed by collection ID, a shard is created by the first put of its collection
+	// and dropped once empty. The operations across the shards lock them in ascending collection ID.
+	shards map[int64]*collectionShard
+	// segmentShards is the shards of the segments keyed by segment ID, updated under the shard locks,
+	// the point lookups locate the segments by it without locking.
+	// The segment IDs are allocated globally, so a segment ID belongs to one collection only.
+	segmentShards *typeutil.ConcurrentMap[int64, *collectionShard]
+	// indexBuilds is the IDs of the sealed segments keyed by the build IDs of their loaded indexes,
+	// updated under the shard locks along with the sealed segments, and read without locking
+	indexBuilds *typeutil.ConcurrentMap[int64, int64]
+
+	pinMu  sync.Mutex // guards pinned and unpinCh
+	pinned map[Segment]int
+	// unpinCh is closed and replaced whenever a segment is fully unpinned to wake up the waiters
+	unpinCh chan struct{}
+
+	releaseMu   sync.Mutex // guards releases and releaseNext
+	releases    []ReleaseRecord
+	releaseNext int
+
+	healthMu    sync.Mutex // guards queryErrors, quarantined, readOnly, evictedAt and thrashCycles
+	queryErrors map[int64]int
+	quarantined map[int64]string
+	readOnly    typeutil.Set[int64]
+	// evictedAt is the time the segments are evicted from the disk cache and not reloaded yet
+	evictedAt map[int64]time.Time
+	// thrashCycles is the reload time of the latest eviction and reload cycles of the segments
+	thrashCycles map[int64][]time.Time
+
+	// filterCache is the segments matching the signed filters, nil if disabled
+	filterCache *filterResultCache
+
+	putMu sync.Mutex // guards putCh
+	// putCh is closed and replaced on every put to wake up the waiters
+	putCh chan struct{}
+
+	// audit is the trail of the latest operations, nil if disabled
+	audit *auditTrail
+
+	metricsMu sync.Mutex // guards metricChannels
+	// metricsPending marks the segment metrics to update, see updateMetric
+	metricsPending atomic.Bool
+	// metricChannels is the channels emitted in the segment number gauge
+	metricChannels typeutil.Set[string]
+
+	metricsDisabled bool
+
+	lazyMu sync.Mutex // guards lazy
+	// lazy is the placeholders put by PutLazy whose data is not loaded yet
+	lazy typeutil.Set[Segment]
+	// materialize loads the data of the placeholder, set by the Manager owning the disk cache,
+	// the placeholders are left to be loaded by the queries if nil
+	materialize func(ctx context.Context, segment Segment) error
+}
+
+// segmentManagerOption configures the segment manager,
+// the options are unexported so that only the tests of this package could change the defaults.
+type segmentManagerOption func(*segmentManager)
+
+// withMetricsDisabled stops the segment manager emitting metrics,
+// which saves the setup time of the large test fixtures and keeps the metrics clean.
+func withMetricsDisabled() segmentManagerOption {
+	return func(mgr *segmentManager) {
+		mgr.metricsDisabled = true
 	}
+}
 
-	for _, segment := range segments {
-		oldSegment, ok := targetMap[segment.ID()]
+func NewSegmentManager(opts ...segmentManagerOption) *segmentManager {
+	mgr := &segmentManager{
+		shards:         make(map[int64]*collectionShard),
+		segmentShards:  typeutil.NewConcurrentMap[int64, *collectionShard](),
+		indexBuilds:    typeutil.NewConcurrentMap[int64, int64](),
+		pinned:         make(map[Segment]int),
+		unpinCh:        make(chan struct{}),
+		lazy:           typeutil.NewSet[Segment](),
+		queryErrors:    make(map[int64]int),
+		quarantined:    make(map[int64]string),
+		readOnly:       typeutil.NewSet[int64](),
+		evictedAt:      make(map[int64]time.Time),
+		thrashCycles:   make(map[int64][]time.Time),
+		putCh:          make(chan struct{}),
+		audit:          newAuditTrail(paramtable.Get().QueryNodeCfg.SegmentAuditTrailSize.GetAsInt()),
+		metricChannels: typeutil.NewSet[string](),
+	}
+	if params := &paramtable.Get().QueryNodeCfg; params.EnableSegmentFilterCache.GetAsBool() {
+		if capacity := params.SegmentFilterCacheSize.GetAsInt(); capacity > 0 {
+			mgr.filterCache = newFilterResultCache(capacity)
+		}
+	}
+	for _, opt := range opts {
+		opt(mgr)
+	}
+	return mgr
+}
 
-		if ok {
-			if oldSegment.Version() >= segment.Version() {
-				log.Warn("Invalid segment distribution changed, skip it",
+// acquireShards returns the shards of the collections in the locking order, the missing ones are created.
+func (mgr *segmentManager) acquireShards(collections ...int64) []*collectionShard {
+	shards := make([]*collectionShard, 0, len(collections))
+	var missing []int64
+	mgr.shardsMu.RLock()
+	for _, collection := range collections {
+		if shard, ok := mgr.shards[collection]; ok {
+			shards = append(shards, shard)
+		} else {
+			missing = append(missing, collection)
+		}
+	}
+	mgr.shardsMu.RUnlock()
+
+	if len(missing) > 0 {
+		mgr.shardsMu.Lock()
+		for _, collection := range missing {
+			shard, ok := mgr.shards[collection]
+			if !ok {
+				shard = newCollectionShard(collection, mgr.segmentShards, mgr.indexBuilds)
+				mgr.shards[collection] = shard
+			}
+			shards = append(shards, shard)
+		}
+		mgr.shardsMu.Unlock()
+	}
+	return sortShards(shards)
+}
+
+// shardsOf returns the existing shards of the collections in the locking order.
+func (mgr *segmentManager) shardsOf(collections typeutil.Set[int64]) []*collectionShard {
+	mgr.shardsMu.RLock()
+	defer mgr.shardsMu.RUnlock()
+
+	shards := make([]*collectionShard, 0, collections.Len())
+	for collection := range collections {
+		if shard, ok := mgr.shards[collection]; ok {
+			shards = append(shards, shard)
+		}
+	}
+	return sortShards(shards)
+}
+
+// allShards returns all the shards in the locking order.
+func (mgr *segmentManager) allShards() []*collectionShard {
+	mgr.shardsMu.RLock()
+	defer mgr.shardsMu.RUnlock()
+	return sortShards(lo.Values(mgr.shards))
+}
+
+// shardsOfSegments returns the distinct shards of the segments in the locking order, the unknown IDs are skipped.
+func (mgr *segmentManager) shardsOfSegments(segmentIDs ...int64) []*collectionShard {
+	shards := make([]*collectionShard, 0, 1)
+	for _, id := range segmentIDs {
+		if shard, ok := mgr.segmentShards.Get(id); ok && !lo.Contains(shards, shard) {
+			shards = append(shards, shard)
+		}
+	}
+	return sortShards(shards)
+}
+
+// shardsFor returns the shards the segments matching the filters could be in, in the locking order,
+// which are the shards of the filtered collections, or of the filtered segment IDs, or all the shards otherwise.
+func (mgr *segmentManager) shardsFor(filters ...SegmentFilter) []*collectionShard {
+	split := splitFilters(filters...)
+	switch {
+	case split.conflict != nil:
+		return nil
+	case split.hasCollections:
+		return mgr.shardsOf(split.collections)
+	case split.hasSegIDs:
+		return mgr.shardsOfSegments(split.segmentIDs.Collect()...)
+	default:
+		return mgr.allShards()
+	}
+}
+
+// sortShards sorts the shards by collection ID, which is the locking order.
+func sortShards(shards []*collectionShard) []*collectionShard {
+	sort.Slice(shards, func(i, j int) bool { return shards[i].collection < shards[j].collection })
+	return shards
+}
+
+// shardsByCollection keys the shards by collection ID.
+func shardsByCollection(shards []*collectionShard) map[int64]*collectionShard {
+	return lo.KeyBy(shards, func(shard *collectionShard) int64 { return shard.collection })
+}
+
+// lockedShardOf returns the shard of the segment if it's one of the locked shards keyed by collection ID, nil otherwise.
+func (mgr *segmentManager) lockedShardOf(locked map[int64]*collectionShard, segmentID int64) *collectionShard {
+	if shard, ok := mgr.segmentShards.Get(segmentID); ok && locked[shard.collection] == shard {
+		return shard
+	}
+	return nil
+}
+
+// lockShards write-locks the shards, which must be in the locking order.
+func lockShards(shards []*collectionShard) {
+	for _, shard := range shards {
+		shard.mu.Lock()
+	}
+}
+
+func unlockShards(shards []*collectionShard) {
+	for i := len(shards) - 1; i >= 0; i-- {
+		shards[i].mu.Unlock()
+	}
+}
+
+// rlockShards read-locks the shards, which must be in the locking order.
+func rlockShards(shards []*collectionShard) {
+	for _, shard := range shards {
+		shard.mu.RLock()
+	}
+}
+
+func runlockShards(shards []*collectionShard) {
+	for i := len(shards) - 1; i >= 0; i-- {
+		shards[i].mu.RUnlock()
+	}
+}
+
+// publishShards publishes the snapshots of the shards changed, and drops the ones left empty,
+// must be called with their write locks held.
+func (mgr *segmentManager) publishShards(shards []*collectionShard) {
+	for _, shard := range shards {
+		shard.publishSnapshot()
+		if !shard.dropped && shard.empty() {
+			mgr.dropShard(shard)
+		}
+	}
+}
+
+// dropShard removes the empty shard from the manager, must be called with its write lock held,
+// the shards lock is taken after the shard lock here, as no one holds it while locking a shard.
+func (mgr *segmentManager) dropShard(shard *collectionShard) {
+	mgr.shardsMu.Lock()
+	if mgr.shards[shard.collection] == shard {
+		delete(mgr.shards, shard.collection)
+	}
+	mgr.shardsMu.Unlock()
+	shard.dropped = true
+}
+
+// CloneForTest copies the segment maps and the health records into a new manager,
+// so that the tests could branch from a fixture without affecting it.
+// The Segment objects are shared rather than copied, the pins and the cached filter results are not carried over.
+func (mgr *segmentManager) CloneForTest() *segmentManager {
+	shards := mgr.allShards()
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	clone := &segmentManager{
+		shards:          make(map[int64]*collectionShard),
+		segmentShards:   typeutil.NewConcurrentMap[int64, *collectionShard](),
+		indexBuilds:     typeutil.NewConcurrentMap[int64, int64](),
+		pinned:          make(map[Segment]int),
+		unpinCh:         make(chan struct{}),
+		lazy:            typeutil.NewSet[Segment](),
+		putCh:           make(chan struct{}),
+		metricChannels:  typeutil.NewSet[string](),
+		metricsDisabled: mgr.metricsDisabled,
+	}
+	if mgr.audit != nil {
+		clone.audit = newAuditTrail(mgr.audit.size)
+	}
+	if mgr.filterCache != nil {
+		clone.filterCache = newFilterResultCache(mgr.filterCache.capacity)
+	}
+	// the clone is not shared yet, no need to lock its shards
+	mgr.rangeWithFilter(shards, func(id int64, segType SegmentType, segment Segment) bool {
+		clone.acquireShards(segment.Collection())[0].addSegmentWithType(segType, segment)
+		return true
+	})
+	clone.publishShards(clone.allShards())
+
+	mgr.healthMu.Lock()
+	clone.queryErrors = lo.Assign(mgr.queryErrors)
+	clone.quarantined = lo.Assign(mgr.quarantined)
+	clone.readOnly = typeutil.NewSet(mgr.readOnly.Collect()...)
+	clone.evictedAt = lo.Assign(mgr.evictedAt)
+	clone.thrashCycles = lo.MapValues(mgr.thrashCycles, func(cycles []time.Time, _ int64) []time.Time {
+		return append([]time.Time(nil), cycles...)
+	})
+	mgr.healthMu.Unlock()
+
+	mgr.releaseMu.Lock()
+	clone.releases = append([]ReleaseRecord(nil), mgr.releases...)
+	clone.releaseNext = mgr.releaseNext
+	mgr.releaseMu.Unlock()
+
+	return clone
+}
+
+func (mgr *segmentManager) Put(segmentType SegmentType, segments ...Segment) {
+	mgr.put(context.Background(), segmentType, false, segments...)
+}
+
+func (mgr *segmentManager) PutCtx(ctx context.Context, segmentType SegmentType, segments ...Segment) {
+	mgr.put(ctx, segmentType, false, segments...)
+}
+
+func (mgr *segmentManager) PutWithReport(segmentType SegmentType, segments ...Segment) (loaded []int64, skipped []int64) {
+	loaded, skipped, _ = mgr.put(context.Background(), segmentType, false, segments...)
+	return loaded, skipped
+}
+
+func (mgr *segmentManager) PutLazy(segments ...Segment) {
+	// the placeholders skipped due to stale version are released by put
+	loaded, _ := mgr.PutWithReport(SegmentTypeSealed, segments...)
+	loadedIDs := typeutil.NewSet(loaded...)
+
+	mgr.lazyMu.Lock()
+	defer mgr.lazyMu.Unlock()
+	for _, segment := range segments {
+		if loadedIDs.Contain(segment.ID()) {
+			mgr.lazy.Insert(segment)
+		}
+	}
+}
+
+func (mgr *segmentManager) TryPut(ctx context.Context, segmentType SegmentType, segments ...Segment) error {
+	_, _, err := mgr.put(ctx, segmentType, true, segments...)
+	return err
+}
+
+// put puts the given segments in, the growing segments are limited per channel if limited is true,
+// returns the IDs of the loaded segments and the ones skipped due to stale version
+func (mgr *segmentManager) put(ctx context.Context, segmentType SegmentType, limited bool, segments ...Segment) (loaded []int64, skipped []int64, err error) {
+	if segmentType != SegmentTypeGrowing && segmentType != SegmentTypeSealed {
+		panic("unexpected segment type")
+	}
+
+	// only the map mutation is done under the locks of the shards of the segments,
+	// releasing, event logging and metrics are done after unlocking
+	var replacedSegment, loadedSegment, skippedSegment []Segment
+	collections := lo.Uniq(lo.Map(segments, func(segment Segment, _ int) int64 { return segment.Collection() }))
+	var shards []*collectionShard
+	for {
+		shards = mgr.acquireShards(collections...)
+		lockShards(shards)
+		if !lo.ContainsBy(shards, func(shard *collectionShard) bool { return shard.dropped }) {
+			break
+		}
+		// dropped once empty since acquired, retry with the new ones
+		unlockShards(shards)
+	}
+	if limited && segmentType == SegmentTypeGrowing {
+		// a channel belongs to one collection, the shards of the segments hold all the growing segments of their channels
+		if err := mgr.checkGrowingLimit(shards, segments); err != nil {
+			// drop the shards created for nothing
+			mgr.publishShards(shards)
+			unlockShards(shards)
+			return nil, nil, err
+		}
+	}
+	byCollection := shardsByCollection(shards)
+	for _, segment := range segments {
+		shard := byCollection[segment.Collection()]
+		oldSegment := shard.getWithTypeLocked(segment.ID(), segmentType)
+
+		if oldSegment != nil {
+			if oldSegment.Version() >= segment.Version() {
+				log.Ctx(ctx).Warn("Invalid segment distribution changed, skip it",
 					zap.Int64("segmentID", segment.ID()),
 					zap.Int64("oldVersion", oldSegment.Version()),
 					zap.Int64("newVersion", segment.Version()),
 				)
-				// delete redundant segment
-				segment.Release()
+				skippedSegment = append(skippedSegment, segment)
+				skipped = append(skipped, segment.ID())
 				continue
 			}
 			replacedSegment = append(replacedSegment, oldSegment)
 		}
-		targetMap[segment.ID()] = segment
-
-		eventlog.Record(eventlog.NewRawEvt(eventlog.Level_Info, fmt.Sprintf("Segment %d[%d] loaded", segment.ID(), segment.Collection())))
-		metrics.QueryNodeNumSegments.WithLabelValues(
-			fmt.Sprint(paramtable.GetNodeID()),
-			fmt.Sprint(segment.Collection()),
-			fmt.Sprint(segment.Partition()),
-			segment.Type().String(),
-			fmt.Sprint(len(segment.Indexes())),
-			segment.Level().String(),
-		).Inc()
+		shard.addSegmentWithType(segmentType, segment)
+		loadedSegment = append(loadedSegment, segment)
+		loaded = append(loaded, segment.ID())
 	}
+	mgr.invalidateFilterCache()
+	mgr.publishShards(shards)
+	unlockShards(shards)
+	mgr.notifyPut()
 	mgr.updateMetric()
 
+	if len(loaded) > 0 {
+		mgr.audit.record(AuditOpPut, loaded)
+	}
+
+	// delete redundant segment
+	for _, segment := range skippedSegment {
+		segment.Release()
+	}
+
+	for _, segment := range loadedSegment {
+		eventlog.Record(eventlog.NewRawEvt(eventlog.Level_Info, withTraceID(ctx, fmt.Sprintf("Segment %d[%d] loaded", segment.ID(), segment.Collection()))))
+		mgr.addSegmentNum(segment, 1)
+	}
+
 	// release replaced segment
 	if len(replacedSegment) > 0 {
 		go func() {
 			for _, segment := range replacedSegment {
-				remove(segment)
+				mgr.release(ctx, segment, ReleaseReasonReplaced)
 			}
 		}()
 	}
+	return loaded, skipped, nil
+}
+
+// checkGrowingLimit checks whether the new growing segments exceed the max growing segment number of their channel,
+// must be called with the write locks of the shards of the segments held.
+func (mgr *segmentManager) checkGrowingLimit(shards []*collectionShard, segments []Segment) error {
+	limit := paramtable.Get().QueryNodeCfg.MaxGrowingSegmentNumPerChannel.GetAsInt()
+	if limit <= 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	mgr.rangeWithFilter(shards, func(_ int64, _ SegmentType, segment Segment) bool {
+		counts[segment.Shard()]++
+		return true
+	}, WithType(SegmentTypeGrowing))
+	for _, segment := range segments {
+		if lo.ContainsBy(shards, func(shard *collectionShard) bool { return shard.growingSegments[segment.ID()] != nil }) {
+			// replacing the existing one doesn't grow the number
+			continue
+		}
+		counts[segment.Shard()]++
+		if counts[segment.Shard()] > limit {
+			return errors.Wrapf(merr.ErrServiceRateLimit, "too many growing segments on channel %s, limit %d", segment.Shard(), limit)
+		}
+	}
+	return nil
+}
+
+// notifyPut wakes up the waiters of the segments, must be called after publishing the put segments.
+func (mgr *segmentManager) notifyPut() {
+	mgr.putMu.Lock()
+	defer mgr.putMu.Unlock()
+	close(mgr.putCh)
+	mgr.putCh = make(chan struct{})
+}
+
+func (mgr *segmentManager) WaitForSegment(ctx context.Context, segmentID int64, typ SegmentType) (Segment, error) {
+	for {
+		// take the channel before the lookup, so that the segment put in between closes it
+		mgr.putMu.Lock()
+		putCh := mgr.putCh
+		mgr.putMu.Unlock()
+		segment := mgr.GetWithType(segmentID, typ)
+		if segment != nil {
+			return segment, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-putCh:
+		}
+	}
+}
+
+func (mgr *segmentManager) VersionLag(latest map[int64]int64) map[int64]int64 {
+	shards := mgr.allShards()
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	lags := make(map[int64]int64)
+	var maxLag int64
+	mgr.rangeWithFilter(shards, func(id int64, _ SegmentType, segment Segment) bool {
+		version, ok := latest[id]
+		if !ok {
+			return true
+		}
+		lag := version - segment.Version()
+		if lag < 0 {
+			lag = 0
+		}
+		// keep the larger one if both growing and sealed segments of the ID loaded
+		if old, ok := lags[id]; !ok || lag > old {
+			lags[id] = lag
+		}
+		if lag > maxLag {
+			maxLag = lag
+		}
+		return true
+	})
+	if !mgr.metricsDisabled {
+		metrics.QueryNodeSegmentMaxVersionLag.WithLabelValues(fmt.Sprint(paramtable.GetNodeID())).Set(float64(maxLag))
+	}
+	return lags
 }
 
 func (mgr *segmentManager) UpdateBy(action SegmentAction, filters ...SegmentFilter) int {
-	mgr.mu.RLock()
-	defer mgr.mu.RUnlock()
+	shards := mgr.shardsFor(filters...)
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	readOnly := mgr.readOnlySegments()
+	var updated, skipped []int64
+	mgr.rangeWithFilter(shards, func(id int64, _ SegmentType, segment Segment) bool {
+		if readOnly.Contain(id) {
+			skipped = append(skipped, id)
+			return true
+		}
+		if action(segment) {
+			updated = append(updated, segment.ID())
+		}
+		return true
+	}, filters...)
+	warnSkippedReadOnly(skipped)
+	if len(updated) > 0 {
+		mgr.audit.record(AuditOpUpdate, updated)
+	}
+	return len(updated)
+}
+
+func (mgr *segmentManager) UpdateByExclusive(action SegmentAction, filters ...SegmentFilter) int {
+	shards := mgr.shardsFor(filters...)
+	lockShards(shards)
+	defer unlockShards(shards)
 
-	updated := 0
-	mgr.rangeWithFilter(func(_ int64, _ SegmentType, segment Segment) bool {
+	readOnly := mgr.readOnlySegments()
+	var updated, skipped []int64
+	mgr.rangeWithFilter(shards, func(id int64, _ SegmentType, segment Segment) bool {
+		if readOnly.Contain(id) {
+			skipped = append(skipped, id)
+			return true
+		}
 		if action(segment) {
-			updated++
+			updated = append(updated, segment.ID())
 		}
 		return true
 	}, filters...)
-	return updated
+	warnSkippedReadOnly(skipped)
+	if len(updated) > 0 {
+		// the cached results may rely on what the actions changed
+		mgr.invalidateFilterCache()
+		mgr.audit.record(AuditOpUpdate, updated)
+	}
+	return len(updated)
 }
 
-func (mgr *segmentManager) Get(segmentID typeutil.UniqueID) Segment {
-	mgr.mu.RLock()
-	defer mgr.mu.RUnlock()
+// publishSnapshot publishes the copy of the segment maps changed since the previous snapshot,
+// the unchanged ones are shared with the previous snapshot.
+// Must be called after adding or removing segments, with the write lock held.
+func (shard *collectionShard) publishSnapshot() {
+	if !shard.dirtyGrowing && !shard.dirtySealed {
+		return
+	}
+	snapshot := *shard.snapshot.Load()
+	if shard.dirtyGrowing {
+		snapshot.growing = lo.Assign(shard.growingSegments)
+	}
+	if shard.dirtySealed {
+		snapshot.sealed = lo.Assign(shard.sealedSegments)
+	}
+	shard.dirtyGrowing, shard.dirtySealed = false, false
+	shard.snapshot.Store(&snapshot)
+}
+
+func (mgr *segmentManager) SetVersions(target int64, filters ...SegmentFilter) ([]int64, []int64) {
+	shards := mgr.shardsFor(filters...)
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	readOnly := mgr.readOnlySegments()
+	var applied, conflicted []int64
+	mgr.rangeWithFilter(shards, func(id int64, _ SegmentType, segment Segment) bool {
+		if readOnly.Contain(id) {
+			conflicted = append(conflicted, id)
+			return true
+		}
+		for oldVersion := segment.Version(); ; oldVersion = segment.Version() {
+			if oldVersion >= target {
+				conflicted = append(conflicted, id)
+				break
+			}
+			if segment.CASVersion(oldVersion, target) {
+				applied = append(applied, id)
+				break
+			}
+		}
+		return true
+	}, filters...)
+	return applied, conflicted
+}
+
+func (mgr *segmentManager) Reconcile(desired map[int64]int64, typ SegmentType, loader func(int64) (Segment, error)) ([]int64, []int64, []int64, error) {
+	var added, removed, updated, missing []int64
+
+	shards := mgr.allShards()
+	lockShards(shards)
+	byCollection := shardsByCollection(shards)
+	var removedSegments []Segment
+	mgr.rangeWithFilter(shards, func(id int64, segType SegmentType, segment Segment) bool {
+		if _, ok := desired[id]; !ok {
+			byCollection[segment.Collection()].removeSegmentWithType(segType, id)
+			removedSegments = append(removedSegments, segment)
+			removed = append(removed, id)
+		}
+		return true
+	}, WithType(typ))
+	if len(removedSegments) > 0 {
+		mgr.invalidateFilterCache()
+		mgr.publishShards(shards)
+	}
+
+	readOnly := mgr.readOnlySegments()
+	var skipped []int64
+	for id, version := range desired {
+		var segment Segment
+		if shard := mgr.lockedShardOf(byCollection, id); shard != nil {
+			segment = shard.getWithTypeLocked(id, typ)
+		}
+		if segment == nil {
+			missing = append(missing, id)
+			continue
+		}
+		if readOnly.Contain(id) {
+			skipped = append(skipped, id)
+			continue
+		}
+		for oldVersion := segment.Version(); oldVersion < version; oldVersion = segment.Version() {
+			if segment.CASVersion(oldVersion, version) {
+				updated = append(updated, id)
+				break
+			}
+		}
+	}
+	unlockShards(shards)
+	warnSkippedReadOnly(skipped)
 
-	if segment, ok := mgr.growingSegments[segmentID]; ok {
+	if len(removedSegments) > 0 {
+		mgr.updateMetric()
+	}
+	for _, segment := range removedSegments {
+		mgr.release(context.Background(), segment, ReleaseReasonRemoved)
+	}
+
+	// loading is slow, do it without holding the lock
+	var errs []error
+	for _, id := range missing {
+		segment, err := loader(id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		loaded, _, _ := mgr.put(context.Background(), typ, false, segment)
+		added = append(added, loaded...)
+	}
+	return added, removed, updated, merr.Combine(errs...)
+}
+
+// Orphans collects the IDs of both the growing and sealed segments not in the target,
+// a segment loaded as both types is reported once.
+func (mgr *segmentManager) Orphans(target typeutil.Set[int64]) []int64 {
+	shards := mgr.allShards()
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	orphans := typeutil.NewSet[int64]()
+	mgr.rangeWithFilter(shards, func(id int64, _ SegmentType, _ Segment) bool {
+		if !target.Contain(id) {
+			orphans.Insert(id)
+		}
+		return true
+	})
+	ret := orphans.Collect()
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+// empty returns whether the shard has no segment, the caller must hold the lock.
+func (shard *collectionShard) empty() bool {
+	return len(shard.growingSegments) == 0 && len(shard.sealedSegments) == 0
+}
+
+// getWithTypeLocked returns the segment of the ID and type, the caller must hold the lock.
+func (shard *collectionShard) getWithTypeLocked(segmentID typeutil.UniqueID, typ SegmentType) Segment {
+	return shard.segmentsWithType(typ)[segmentID]
+}
+
+// segmentsWithType returns the map of the segments of the type, nil for the unknown type,
+// the caller must hold the lock.
+func (shard *collectionShard) segmentsWithType(typ SegmentType) map[typeutil.UniqueID]Segment {
+	switch typ {
+	case SegmentTypeSealed:
+		return shard.sealedSegments
+	case SegmentTypeGrowing:
+		return shard.growingSegments
+	default:
+		return nil
+	}
+}
+
+// snapshotOf returns the published snapshot of the shard of the segment, nil if the segment is unknown.
+func (mgr *segmentManager) snapshotOf(segmentID typeutil.UniqueID) *segmentSnapshot {
+	shard, ok := mgr.segmentShards.Get(segmentID)
+	if !ok {
+		return nil
+	}
+	return shard.snapshot.Load()
+}
+
+func (mgr *segmentManager) Get(segmentID typeutil.UniqueID) Segment {
+	snapshot := mgr.snapshotOf(segmentID)
+	if snapshot == nil {
+		return nil
+	}
+	if segment, ok := snapshot.growing[segmentID]; ok {
 		return segment
-	} else if segment, ok = mgr.sealedSegments[segmentID]; ok {
+	} else if segment, ok = snapshot.sealed[segmentID]; ok {
 		return segment
 	}
 
 	return nil
 }
 
-func (mgr *segmentManager) GetWithType(segmentID typeutil.UniqueID, typ SegmentType) Segment {
-	mgr.mu.RLock()
-	defer mgr.mu.RUnlock()
+func (mgr *segmentManager) Exists(segmentID typeutil.UniqueID) bool {
+	return mgr.Get(segmentID) != nil
+}
+
+func (mgr *segmentManager) GetMany(segmentIDs []int64) map[int64]Segment {
+	ret := make(map[int64]Segment, len(segmentIDs))
+	for _, segmentID := range segmentIDs {
+		snapshot := mgr.snapshotOf(segmentID)
+		if snapshot == nil {
+			continue
+		}
+		if segment, ok := snapshot.growing[segmentID]; ok {
+			ret[segmentID] = segment
+		} else if segment, ok = snapshot.sealed[segmentID]; ok {
+			ret[segmentID] = segment
+		}
+	}
+	return ret
+}
 
+func (mgr *segmentManager) GetWithType(segmentID typeutil.UniqueID, typ SegmentType) Segment {
+	snapshot := mgr.snapshotOf(segmentID)
+	if snapshot == nil {
+		return nil
+	}
 	switch typ {
 	case SegmentTypeSealed:
-		return mgr.sealedSegments[segmentID]
+		return snapshot.sealed[segmentID]
 	case SegmentTypeGrowing:
-		return mgr.growingSegments[segmentID]
+		return snapshot.growing[segmentID]
 	default:
 		return nil
 	}
 }
 
 func (mgr *segmentManager) GetBy(filters ...SegmentFilter) []Segment {
-	mgr.mu.RLock()
-	defer mgr.mu.RUnlock()
+	signature, cacheable := filtersSignature(filters...)
+	cacheable = cacheable && mgr.filterCache != nil
+	var generation uint64
+	if cacheable {
+		if cached, ok := mgr.filterCache.get(signature); ok {
+			return cached
+		}
+		generation = mgr.filterCache.current()
+	}
+
+	shards := mgr.shardsFor(filters...)
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	var ret []Segment
+	mgr.rangeWithFilter(shards, func(id int64, _ SegmentType, segment Segment) bool {
+		ret = append(ret, segment)
+		return true
+	}, filters...)
+
+	// the segments can't change while holding the read locks, but the ones put into the shards not picked are missed,
+	// such puts clear the cache, so the result is cached only if not cleared since the generation taken
+	if cacheable {
+		mgr.filterCache.putIfCurrent(generation, signature, ret)
+	}
+	return ret
+}
+
+func (mgr *segmentManager) GetByChecked(filters ...SegmentFilter) ([]Segment, error) {
+	if err := splitFilters(filters...).conflict; err != nil {
+		return nil, err
+	}
+	return mgr.GetBy(filters...), nil
+}
 
+// filtersSignature joins the signatures of the filters,
+// false if any of them has no signature.
+func filtersSignature(filters ...SegmentFilter) (string, bool) {
+	signatures := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		signature := signatureOf(filter)
+		if signature == "" {
+			return "", false
+		}
+		signatures = append(signatures, signature)
+	}
+	return strings.Join(signatures, "&"), true
+}
+
+// invalidateFilterCache drops the cached GetBy results,
+// must be called after adding or removing segments, with the write locks of the changed shards held.
+func (mgr *segmentManager) invalidateFilterCache() {
+	if mgr.filterCache != nil {
+		mgr.filterCache.clear()
+	}
+}
+
+func (mgr *segmentManager) GetByWithStats(filters ...SegmentFilter) ([]Segment, ScanStats) {
+	var stats ScanStats
+	start := time.Now()
+
+	shards := mgr.shardsFor(filters...)
+	rlockShards(shards)
 	var ret []Segment
-	mgr.rangeWithFilter(func(id int64, _ SegmentType, segment Segment) bool {
+	mgr.rangeWithFilterStats(&stats, shards, func(id int64, _ SegmentType, segment Segment) bool {
 		ret = append(ret, segment)
 		return true
 	}, filters...)
+	runlockShards(shards)
+
+	stats.Elapsed = time.Since(start)
+	return ret, stats
+}
+
+func (mgr *segmentManager) GetByPaged(offset, limit int, filters ...SegmentFilter) ([]Segment, int) {
+	matched := mgr.GetBy(filters...)
+	// the map order is nondeterministic, sort them to make the pages stable
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].ID() < matched[j].ID()
+	})
+
+	total := len(matched)
+	if offset < 0 || limit <= 0 || offset >= total {
+		return nil, total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total
+}
+
+func (mgr *segmentManager) Hierarchy(filters ...SegmentFilter) map[int64]map[int64][]SegmentInfo {
+	shards := mgr.shardsFor(filters...)
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	ret := make(map[int64]map[int64][]SegmentInfo)
+	mgr.rangeWithFilter(shards, func(id int64, _ SegmentType, segment Segment) bool {
+		partitions, ok := ret[segment.Collection()]
+		if !ok {
+			partitions = make(map[int64][]SegmentInfo)
+			ret[segment.Collection()] = partitions
+		}
+		partitions[segment.Partition()] = append(partitions[segment.Partition()], segmentInfoOf(segment))
+		return true
+	}, filters...)
+
+	for _, partitions := range ret {
+		for _, infos := range partitions {
+			sort.Slice(infos, func(i, j int) bool {
+				return infos[i].SegmentID < infos[j].SegmentID
+			})
+		}
+	}
 	return ret
 }
 
+func (mgr *segmentManager) RangeCtx(ctx context.Context, fn func(Segment) bool, filters ...SegmentFilter) error {
+	shards := mgr.shardsFor(filters...)
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	var err error
+	visited := 0
+	mgr.rangeWithFilter(shards, func(_ int64, _ SegmentType, segment Segment) bool {
+		// checking the context is not free, do it every rangeCtxCheckInterval segments
+		if visited%rangeCtxCheckInterval == 0 {
+			if err = ctx.Err(); err != nil {
+				return false
+			}
+		}
+		visited++
+		return fn(segment)
+	}, filters...)
+	return err
+}
+
 func (mgr *segmentManager) GetAndPinBy(filters ...SegmentFilter) ([]Segment, error) {
-	mgr.mu.RLock()
-	defer mgr.mu.RUnlock()
+	shards := mgr.shardsFor(filters...)
+	rlockShards(shards)
+	defer runlockShards(shards)
+	return mgr.getAndPinByLocked(shards, filters...)
+}
+
+func (mgr *segmentManager) GetAndPinByInfo(filters ...SegmentFilter) ([]PinnedSegment, error) {
+	shards := mgr.shardsFor(filters...)
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	segments, err := mgr.getAndPinByLocked(shards, filters...)
+	if err != nil {
+		return nil, err
+	}
+	// the info is captured under the same lock, so no reload could happen in between
+	ret := make([]PinnedSegment, 0, len(segments))
+	for _, segment := range segments {
+		ret = append(ret, PinnedSegment{
+			Segment: segment,
+			Info:    segmentInfoOf(segment),
+		})
+	}
+	return ret, nil
+}
 
+// getAndPinByLocked pins the segments matching the filters in the shards, must be called with their locks held.
+func (mgr *segmentManager) getAndPinByLocked(shards []*collectionShard, filters ...SegmentFilter) ([]Segment, error) {
 	var ret []Segment
 	var err error
 	defer func() {
 		if err != nil {
-			for _, segment := range ret {
-				segment.RUnlock()
-			}
+			mgr.Unpin(ret)
 		}
 	}()
 
-	mgr.rangeWithFilter(func(id int64, _ SegmentType, segment Segment) bool {
-		if segment.Level() == datapb.SegmentLevel_L0 {
+	quarantined := mgr.quarantinedSegments()
+	mgr.rangeWithFilter(shards, func(id int64, _ SegmentType, segment Segment) bool {
+		if segment.Level() == datapb.SegmentLevel_L0 || quarantined.Contain(id) {
 			return true
 		}
-		err = segment.RLock()
+		err = mgr.pin(segment)
 		if err != nil {
 			return false
 		}
@@ -382,244 +2039,958 @@ func (mgr *segmentManager) GetAndPinBy(filters ...SegmentFilter) ([]Segment, err
 		return true
 	}, filters...)
 
+	if err != nil {
+		return nil, err
+	}
 	return ret, nil
 }
 
+func (mgr *segmentManager) GetAndPinByRequired(filters ...SegmentFilter) ([]Segment, error) {
+	segments, err := mgr.GetAndPinBy(filters...)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		signature, _ := filtersSignature(filters...)
+		return nil, errors.Wrapf(merr.ErrSegmentNotLoaded, "no segment matches the filters %s", signature)
+	}
+	return segments, nil
+}
+
 func (mgr *segmentManager) GetAndPin(segments []int64, filters ...SegmentFilter) ([]Segment, error) {
-	mgr.mu.RLock()
-	defer mgr.mu.RUnlock()
+	return mgr.GetAndPinWithOptions(segments, nil, filters...)
+}
+
+func (mgr *segmentManager) GetAndPinWithOptions(segments []int64, opts []PinOption, filters ...SegmentFilter) ([]Segment, error) {
+	options := pinOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	lockedSegments, _, err := mgr.getAndPin(segments, 0, PinTimeoutError, options, filters...)
+	if err != nil {
+		return nil, err
+	}
+	// load the placeholders outside the lock, the disk cache loader takes it
+	if err := mgr.materializeLazy(lockedSegments); err != nil {
+		mgr.Unpin(lockedSegments)
+		return nil, err
+	}
+	return lockedSegments, nil
+}
+
+// materializeLazy loads the data of the pinned placeholders put by PutLazy on their first access.
+func (mgr *segmentManager) materializeLazy(segments []Segment) error {
+	if mgr.materialize == nil {
+		return nil
+	}
+
+	mgr.lazyMu.Lock()
+	placeholders := lo.Filter(segments, func(segment Segment, _ int) bool {
+		return mgr.lazy.Contain(segment)
+	})
+	mgr.lazyMu.Unlock()
+
+	for _, segment := range placeholders {
+		if err := mgr.materialize(context.Background(), segment); err != nil {
+			log.Warn("failed to load placeholder segment", zap.Int64("segmentID", segment.ID()), zap.Error(err))
+			return err
+		}
+		mgr.lazyMu.Lock()
+		mgr.lazy.Remove(segment)
+		mgr.lazyMu.Unlock()
+		log.Info("placeholder segment loaded on first access", zap.Int64("segmentID", segment.ID()))
+	}
+	return nil
+}
+
+func (mgr *segmentManager) GetAndPinWithDeadline(segments []int64, deadline time.Duration, policy PinTimeoutPolicy, filters ...SegmentFilter) ([]Segment, []int64, error) {
+	return mgr.getAndPin(segments, deadline, policy, pinOptions{}, filters...)
+}
+
+// getAndPin gets and pins the given segments,
+// a non-positive deadline means waiting until the segment pinned.
+func (mgr *segmentManager) getAndPin(segments []int64, deadline time.Duration, policy PinTimeoutPolicy, opts pinOptions, filters ...SegmentFilter) ([]Segment, []int64, error) {
+	shards := mgr.shardsOfSegments(segments...)
+	rlockShards(shards)
+	defer runlockShards(shards)
+	byCollection := shardsByCollection(shards)
 
 	lockedSegments := make([]Segment, 0, len(segments))
+	var skipped []int64
 	var err error
+	// check memory pressure lazily, at most once per call
+	pressureChecked, underPressure := false, false
 	defer func() {
 		if err != nil {
-			for _, segment := range lockedSegments {
-				segment.RUnlock()
-			}
+			mgr.Unpin(lockedSegments)
 		}
 	}()
 
+	quarantined := mgr.quarantinedSegments()
 	for _, id := range segments {
-		growing, growingExist := mgr.growingSegments[id]
-		sealed, sealedExist := mgr.sealedSegments[id]
+		var growing, sealed Segment
+		var growingExist, sealedExist bool
+		// the segment put or moved to another shard since the shards locked is not found
+		if shard := mgr.lockedShardOf(byCollection, id); shard != nil {
+			growing, growingExist = shard.growingSegments[id]
+			sealed, sealedExist = shard.sealedSegments[id]
+		}
 
 		// L0 Segment should not be queryable.
 		if sealedExist && sealed.Level() == datapb.SegmentLevel_L0 {
 			continue
 		}
+		// the quarantined segment is excluded from query like L0
+		if quarantined.Contain(id) {
+			continue
+		}
 
 		growingExist = growingExist && filter(growing, filters...)
 		sealedExist = sealedExist && filter(sealed, filters...)
-
-		if growingExist {
-			err = growing.RLock()
-			if err != nil {
-				return nil, err
+		if growingExist && sealedExist && opts.preferred != "" {
+			// pin only the duplicate in the preferred load mode if any
+			growingPreferred, sealedPreferred := growing.LoadStatus() == opts.preferred, sealed.LoadStatus() == opts.preferred
+			if growingPreferred != sealedPreferred {
+				growingExist, sealedExist = growingPreferred, sealedPreferred
 			}
-			lockedSegments = append(lockedSegments, growing)
 		}
-		if sealedExist {
-			err = sealed.RLock()
-			if err != nil {
-				return nil, err
+
+		// pinning a sealed segment not resident may trigger loading,
+		// reject it to avoid OOM if the node is under memory pressure
+		if sealedExist && sealed.LoadStatus() == LoadStatusMeta {
+			if !pressureChecked {
+				pressureChecked, underPressure = true, isUnderMemoryPressure()
+			}
+			if underPressure {
+				err = merr.WrapErrServiceUnavailable("memory pressure too high", fmt.Sprintf("reject to pin non-resident segment %d", id))
+				return nil, nil, err
 			}
-			lockedSegments = append(lockedSegments, sealed)
 		}
 
 		if !growingExist && !sealedExist {
 			err = merr.WrapErrSegmentNotLoaded(id, "segment not found")
-			return nil, err
+			return nil, nil, err
+		}
+
+		candidates := make([]Segment, 0, 2)
+		if growingExist {
+			candidates = append(candidates, growing)
+		}
+		if sealedExist {
+			candidates = append(candidates, sealed)
+		}
+		for _, segment := range candidates {
+			var ok bool
+			ok, err = mgr.pinWithDeadline(segment, deadline)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok {
+				lockedSegments = append(lockedSegments, segment)
+				continue
+			}
+			if policy == PinTimeoutError {
+				err = merr.WrapErrServiceUnavailable("pin segment timeout", fmt.Sprintf("segment %d not pinned within %s", id, deadline))
+				return nil, nil, err
+			}
+			if len(skipped) == 0 || skipped[len(skipped)-1] != id {
+				skipped = append(skipped, id)
+			}
 		}
 	}
 
-	return lockedSegments, nil
+	return lockedSegments, skipped, nil
 }
 
+// Unpin decreases the pin count of the segments, the nil entries are skipped,
+// the read lock of a segment is released only if its pin count reaches zero,
+// or right away if it's not pinned through the manager.
 func (mgr *segmentManager) Unpin(segments []Segment) {
+	mgr.pinMu.Lock()
+	defer mgr.pinMu.Unlock()
+
+	unpinned := false
+	defer func() {
+		if unpinned {
+			close(mgr.unpinCh)
+			mgr.unpinCh = make(chan struct{})
+		}
+	}()
 	for _, segment := range segments {
+		if segment == nil {
+			continue
+		}
+		count, ok := mgr.pinned[segment]
+		if !ok {
+			// locked without pinning through the manager, release the read lock as before
+			segment.RUnlock()
+			continue
+		}
+		if count > 1 {
+			mgr.pinned[segment]--
+			continue
+		}
+		delete(mgr.pinned, segment)
+		segment.RUnlock()
+		unpinned = true
+	}
+}
+
+// pin increases the pin count of the segment,
+// the read lock of the segment is acquired only by the first pin,
+// so nested pins of the same segment never acquire the read lock reentrantly.
+func (mgr *segmentManager) pin(segment Segment) error {
+	if mgr.repin(segment) {
+		return nil
+	}
+
+	// acquire the read lock outside pinMu, it may block on a releasing segment
+	start := time.Now()
+	err := segment.RLock()
+	mgr.observePinWait(segment, start)
+	if err != nil {
+		mgr.recordPinFailure(segment)
+		return err
+	}
+	mgr.markPinned(segment)
+	return nil
+}
+
+// pinWithDeadline works like pin, but gives up if the read lock is not acquired within the deadline,
+// returns false if given up. A non-positive deadline means no deadline.
+func (mgr *segmentManager) pinWithDeadline(segment Segment, deadline time.Duration) (bool, error) {
+	if deadline <= 0 {
+		return true, mgr.pin(segment)
+	}
+	if mgr.repin(segment) {
+		return true, nil
+	}
+
+	start := time.Now()
+	locked := make(chan error, 1)
+	go func() {
+		locked <- segment.RLock()
+	}()
+
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
+	select {
+	case err := <-locked:
+		mgr.observePinWait(segment, start)
+		if err != nil {
+			mgr.recordPinFailure(segment)
+			return false, err
+		}
+		mgr.markPinned(segment)
+		return true, nil
+	case <-timer.C:
+		mgr.observePinWait(segment, start)
+		mgr.recordPinFailure(segment)
+		// release the read lock once the abandoned acquisition succeeds
+		go func() {
+			if err := <-locked; err == nil {
+				segment.RUnlock()
+			}
+		}()
+		return false, nil
+	}
+}
+
+// observePinWait records the time spent on waiting for the read lock of the segment since start.
+func (mgr *segmentManager) observePinWait(segment Segment, start time.Time) {
+	if mgr.metricsDisabled {
+		return
+	}
+	metrics.QueryNodeSegmentPinWaitLatency.WithLabelValues(
+		fmt.Sprint(paramtable.GetNodeID()),
+		segment.Type().String(),
+	).Observe(float64(time.Since(start).Milliseconds()))
+}
+
+// recordPinFailure counts the segment failed to pin.
+func (mgr *segmentManager) recordPinFailure(segment Segment) {
+	if mgr.metricsDisabled {
+		return
+	}
+	metrics.QueryNodeSegmentPinFailedCount.WithLabelValues(
+		fmt.Sprint(paramtable.GetNodeID()),
+		segment.Type().String(),
+	).Inc()
+}
+
+// repin increases the pin count if the segment is pinned already,
+// returns false if not pinned.
+func (mgr *segmentManager) repin(segment Segment) bool {
+	mgr.pinMu.Lock()
+	defer mgr.pinMu.Unlock()
+	if mgr.pinned[segment] > 0 {
+		mgr.pinned[segment]++
+		return true
+	}
+	return false
+}
+
+// markPinned increases the pin count of the segment whose read lock is just acquired.
+func (mgr *segmentManager) markPinned(segment Segment) {
+	mgr.pinMu.Lock()
+	defer mgr.pinMu.Unlock()
+	mgr.pinned[segment]++
+	if mgr.pinned[segment] > 1 {
+		// pinned by others concurrently, which holds the read lock already
 		segment.RUnlock()
 	}
 }
 
-func (mgr *segmentManager) rangeWithFilter(process func(id int64, segType SegmentType, segment Segment) bool, filters ...SegmentFilter) {
-	var segType SegmentType
-	var hasSegType, hasSegIDs bool
-	segmentIDs := typeutil.NewSet[int64]()
+// isPinned returns whether the segment is pinned.
+func (mgr *segmentManager) isPinned(segment Segment) bool {
+	mgr.pinMu.Lock()
+	defer mgr.pinMu.Unlock()
+
+	return mgr.pinned[segment] > 0
+}
+
+// pinnedSegments returns the segments still pinned.
+func (mgr *segmentManager) pinnedSegments() typeutil.Set[Segment] {
+	pinned, _ := mgr.pinnedOf(nil)
+	return pinned
+}
+
+// pinnedOf returns the given segments still pinned, all the pinned segments if nil,
+// and the channel closed once any segment is unpinned afterwards.
+func (mgr *segmentManager) pinnedOf(segments typeutil.Set[Segment]) (typeutil.Set[Segment], <-chan struct{}) {
+	mgr.pinMu.Lock()
+	defer mgr.pinMu.Unlock()
+
+	ret := typeutil.NewSet[Segment]()
+	for segment := range mgr.pinned {
+		if segments == nil || segments.Contain(segment) {
+			ret.Insert(segment)
+		}
+	}
+	return ret, mgr.unpinCh
+}
+
+// rangeWithFilter calls process on the segments matching the filters in the shards, which are usually picked by shardsFor,
+// must be called with their locks held.
+func (mgr *segmentManager) rangeWithFilter(shards []*collectionShard, process func(id int64, segType SegmentType, segment Segment) bool, filters ...SegmentFilter) {
+	mgr.rangeWithFilterStats(nil, shards, process, filters...)
+}
 
-	otherFilters := make([]SegmentFilter, 0, len(filters))
+// fastPathFilters is the filters split into the fast path ones and the others.
+type fastPathFilters struct {
+	segType    SegmentType
+	hasSegType bool
+	segmentIDs typeutil.Set[int64]
+	hasSegIDs  bool
+	// collections is the intersection of the collection filters, which are kept in others as well
+	collections    typeutil.Set[int64]
+	hasCollections bool
+	others         []SegmentFilter
+	// conflict describes the contradictory fast path filters, nil if none
+	conflict error
+}
+
+// splitFilters splits the fast path filters out, the segment type filters and the segment ID filters are ANDed,
+// they conflict if no segment could match all of them.
+func splitFilters(filters ...SegmentFilter) fastPathFilters {
+	split := fastPathFilters{others: make([]SegmentFilter, 0, len(filters))}
 	for _, filter := range filters {
 		if sType, ok := filter.SegmentType(); ok {
-			segType = sType
-			hasSegType = true
+			if split.hasSegType && sType != split.segType && split.conflict == nil {
+				split.conflict = merr.WrapErrParameterInvalidMsg("conflicting segment type filters %s and %s", split.segType.String(), sType.String())
+			}
+			split.segType, split.hasSegType = sType, true
 			continue
 		}
 		if segIDs, ok := filter.SegmentIDs(); ok {
-			hasSegIDs = true
-			segmentIDs.Insert(segIDs...)
+			ids := typeutil.NewSet(segIDs...)
+			if split.hasSegIDs {
+				ids = split.segmentIDs.Intersection(ids)
+				if ids.Len() == 0 && split.conflict == nil {
+					split.conflict = merr.WrapErrParameterInvalidMsg("conflicting segment ID filters %v and %v", split.segmentIDs.Collect(), segIDs)
+				}
+			}
+			split.segmentIDs, split.hasSegIDs = ids, true
 			continue
 		}
-		otherFilters = append(otherFilters, filter)
+		if filter, ok := filter.(collectionsFilter); ok {
+			collections := filter.collections
+			if split.hasCollections {
+				collections = split.collections.Intersection(collections)
+			}
+			split.collections, split.hasCollections = collections, true
+		}
+		split.others = append(split.others, filter)
+	}
+	return split
+}
+
+// rangeWithFilterStats works like rangeWithFilter,
+// and accumulates the scanned and matched counts into stats if not nil.
+func (mgr *segmentManager) rangeWithFilterStats(stats *ScanStats, shards []*collectionShard, process func(id int64, segType SegmentType, segment Segment) bool, filters ...SegmentFilter) {
+	split := splitFilters(filters...)
+	if split.conflict != nil {
+		// nothing matches the contradictory filters
+		return
+	}
+	segType, hasSegType := split.segType, split.hasSegType
+	segmentIDs, hasSegIDs := split.segmentIDs, split.hasSegIDs
+	otherFilters := split.others
+	if split.hasCollections {
+		// only the shards of the collections are candidates
+		shards = lo.Filter(shards, func(shard *collectionShard, _ int) bool { return split.collections.Contain(shard.collection) })
 	}
 
 	mergedFilter := func(info Segment) bool {
+		if stats != nil {
+			stats.Scanned++
+		}
 		for _, filter := range otherFilters {
 			if !filter.Filter(info) {
 				return false
 			}
 		}
+		if stats != nil {
+			stats.Matched++
+		}
 		return true
 	}
 
-	var candidates map[SegmentType]map[int64]Segment
-	switch segType {
-	case SegmentTypeSealed:
-		candidates = map[SegmentType]map[int64]Segment{SegmentTypeSealed: mgr.sealedSegments}
-	case SegmentTypeGrowing:
-		candidates = map[SegmentType]map[int64]Segment{SegmentTypeGrowing: mgr.growingSegments}
-	default:
-		if !hasSegType {
-			candidates = map[SegmentType]map[int64]Segment{
-				SegmentTypeSealed:  mgr.sealedSegments,
-				SegmentTypeGrowing: mgr.growingSegments,
-			}
-		}
+	var types []SegmentType
+	switch {
+	case !hasSegType:
+		types = []SegmentType{SegmentTypeSealed, SegmentTypeGrowing}
+	case segType == SegmentTypeSealed || segType == SegmentTypeGrowing:
+		types = []SegmentType{segType}
+	}
+
+	if hasSegIDs {
+		// look up the shards of the IDs only
+		byCollection := shardsByCollection(shards)
+		for id := range segmentIDs {
+			shard := mgr.lockedShardOf(byCollection, id)
+			if shard == nil {
+				continue
+			}
+			for _, segType := range types {
+				segment, has := shard.segmentsWithType(segType)[id]
+				if has && mergedFilter(segment) {
+					if !process(id, segType, segment) {
+						return
+					}
+				}
+			}
+		}
+		return
+	}
+	for _, shard := range shards {
+		for _, segType := range types {
+			for id, segment := range shard.segmentsWithType(segType) {
+				if mergedFilter(segment) {
+					if !process(id, segType, segment) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+func filter(segment Segment, filters ...SegmentFilter) bool {
+	for _, filter := range filters {
+		if !filter.Filter(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+func (mgr *segmentManager) GetSealed(segmentID typeutil.UniqueID) Segment {
+	if snapshot := mgr.snapshotOf(segmentID); snapshot != nil {
+		if segment, ok := snapshot.sealed[segmentID]; ok {
+			return segment
+		}
+	}
+
+	return nil
+}
+
+func (mgr *segmentManager) GetByIndexBuildID(buildID int64) (Segment, bool) {
+	segmentID, ok := mgr.indexBuilds.Get(buildID)
+	if !ok {
+		return nil, false
+	}
+	segment := mgr.GetSealed(segmentID)
+	return segment, segment != nil
+}
+
+func (mgr *segmentManager) RefreshIndexes() {
+	for _, shard := range mgr.allShards() {
+		shard.mu.Lock()
+		for id, segment := range shard.sealedSegments {
+			shard.unindexBuilds(id)
+			shard.indexBuildsOf(segment)
+		}
+		shard.mu.Unlock()
+	}
+}
+
+func (mgr *segmentManager) GetGrowing(segmentID typeutil.UniqueID) Segment {
+	if snapshot := mgr.snapshotOf(segmentID); snapshot != nil {
+		if segment, ok := snapshot.growing[segmentID]; ok {
+			return segment
+		}
+	}
+
+	return nil
+}
+
+func (mgr *segmentManager) Empty() bool {
+	return mgr.GrowingCount()+mgr.SealedCount() == 0
+}
+
+func (mgr *segmentManager) SealedCount() int {
+	count := 0
+	for _, shard := range mgr.allShards() {
+		count += len(shard.snapshot.Load().sealed)
+	}
+	return count
+}
+
+func (mgr *segmentManager) GrowingCount() int {
+	count := 0
+	for _, shard := range mgr.allShards() {
+		count += len(shard.snapshot.Load().growing)
 	}
+	return count
+}
 
-	for segType, candidate := range candidates {
-		if hasSegIDs {
-			for id := range segmentIDs {
-				segment, has := candidate[id]
-				if has && mergedFilter(segment) {
-					if !process(id, segType, segment) {
-						break
-					}
-				}
-			}
-		} else {
-			for id, segment := range candidate {
-				if mergedFilter(segment) {
-					if !process(id, segType, segment) {
-						break
-					}
-				}
-			}
-		}
+func (mgr *segmentManager) HasCollection(collectionID int64) bool {
+	shards := mgr.shardsOf(typeutil.NewSet(collectionID))
+	if len(shards) == 0 {
+		return false
 	}
+	// read the snapshot rather than locking the shard, so that it could be called back by UpdateByExclusive,
+	// the shard just created by a put may be empty yet
+	snapshot := shards[0].snapshot.Load()
+	return len(snapshot.growing)+len(snapshot.sealed) > 0
 }
 
-func filter(segment Segment, filters ...SegmentFilter) bool {
-	for _, filter := range filters {
-		if !filter.Filter(segment) {
-			return false
+func (mgr *segmentManager) LoadedCollections() []int64 {
+	var collections []int64
+	for _, shard := range mgr.allShards() {
+		// the shard just created by a put may be empty yet
+		shard.mu.RLock()
+		if !shard.empty() {
+			collections = append(collections, shard.collection)
 		}
+		shard.mu.RUnlock()
 	}
-	return true
+	return collections
 }
 
-func (mgr *segmentManager) GetSealed(segmentID typeutil.UniqueID) Segment {
-	mgr.mu.RLock()
-	defer mgr.mu.RUnlock()
+func (mgr *segmentManager) ChannelCheckpoint(channel string) (typeutil.Timestamp, bool) {
+	shards := mgr.allShards()
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	var (
+		checkpoint typeutil.Timestamp
+		found      bool
+	)
+	mgr.rangeWithFilter(shards, func(_ int64, _ SegmentType, segment Segment) bool {
+		if segment.Shard() != channel {
+			return true
+		}
+		ts := segment.LastInsertTimestamp()
+		if ts == 0 {
+			// nothing consumed yet, fallback to the start position
+			ts = segment.StartPosition().GetTimestamp()
+		}
+		if !found || ts < checkpoint {
+			checkpoint, found = ts, true
+		}
+		return true
+	}, WithType(SegmentTypeGrowing))
+	return checkpoint, found
+}
 
-	if segment, ok := mgr.sealedSegments[segmentID]; ok {
-		return segment
+func (mgr *segmentManager) GrowingRowRange(segmentID int64) (minTs, maxTs typeutil.Timestamp, rows int64, ok bool) {
+	segment := mgr.GetGrowing(segmentID)
+	if segment == nil {
+		return 0, 0, 0, false
 	}
+	return segment.FirstInsertTimestamp(), segment.LastInsertTimestamp(), segment.InsertCount(), true
+}
 
-	return nil
+func (mgr *segmentManager) MaxTimestamp(filters ...SegmentFilter) typeutil.Timestamp {
+	shards := mgr.shardsFor(filters...)
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	var maxTs typeutil.Timestamp
+	mgr.rangeWithFilter(shards, func(_ int64, _ SegmentType, segment Segment) bool {
+		if ts := segment.LastInsertTimestamp(); ts > maxTs {
+			maxTs = ts
+		}
+		return true
+	}, filters...)
+	return maxTs
 }
 
-func (mgr *segmentManager) GetGrowing(segmentID typeutil.UniqueID) Segment {
-	mgr.mu.RLock()
-	defer mgr.mu.RUnlock()
+func (mgr *segmentManager) CollectionFreshness(collectionID int64) typeutil.Timestamp {
+	shards := mgr.shardsFor(WithCollections(collectionID))
+	rlockShards(shards)
+	defer runlockShards(shards)
 
-	if segment, ok := mgr.growingSegments[segmentID]; ok {
-		return segment
-	}
+	var maxAppliedTs typeutil.Timestamp
+	mgr.rangeWithFilter(shards, func(_ int64, _ SegmentType, segment Segment) bool {
+		if ts := segment.MaxAppliedTimestamp(); ts > maxAppliedTs {
+			maxAppliedTs = ts
+		}
+		return true
+	}, WithCollections(collectionID))
+	return maxAppliedTs
+}
 
-	return nil
+func (mgr *segmentManager) CollectionSummary(collectionID int64) CollectionSummary {
+	shards := mgr.shardsFor(WithCollections(collectionID))
+	rlockShards(shards)
+	defer runlockShards(shards)
+
+	summary := CollectionSummary{CollectionID: collectionID}
+	mgr.rangeWithFilter(shards, func(_ int64, segType SegmentType, segment Segment) bool {
+		summary.NumSegments++
+		switch segType {
+		case SegmentTypeGrowing:
+			summary.NumGrowing++
+		case SegmentTypeSealed:
+			summary.NumSealed++
+		}
+		rows := segment.InsertCount()
+		summary.NumRows += rows
+		if len(segment.Indexes()) > 0 {
+			summary.IndexedRows += rows
+		}
+		return true
+	}, WithCollections(collectionID))
+	if summary.NumRows > 0 {
+		summary.IndexCoverage = float64(summary.IndexedRows) * 100 / float64(summary.NumRows)
+	}
+	return summary
 }
 
-func (mgr *segmentManager) Empty() bool {
-	mgr.mu.RLock()
-	defer mgr.mu.RUnlock()
+func (mgr *segmentManager) TopByResource(n int, by ResourceDimension) []Segment {
+	if n <= 0 {
+		return nil
+	}
+
+	shards := mgr.allShards()
+	rlockShards(shards)
+	defer runlockShards(shards)
 
-	return len(mgr.growingSegments)+len(mgr.sealedSegments) == 0
+	// keep the n largest segments in a min heap
+	h := make(segmentUsageHeap, 0, n+1)
+	mgr.rangeWithFilter(shards, func(_ int64, _ SegmentType, segment Segment) bool {
+		heap.Push(&h, segmentUsage{segment: segment, usage: by.of(segment.ResourceUsageEstimate())})
+		if h.Len() > n {
+			heap.Pop(&h)
+		}
+		return true
+	})
+
+	ret := make([]Segment, h.Len())
+	for i := len(ret) - 1; i >= 0; i-- {
+		ret[i] = heap.Pop(&h).(segmentUsage).segment
+	}
+	return ret
 }
 
 // returns true if the segment exists,
 // false otherwise
 func (mgr *segmentManager) Remove(segmentID typeutil.UniqueID, scope querypb.DataScope) (int, int) {
-	mgr.mu.Lock()
+	return mgr.RemoveCtx(context.Background(), segmentID, scope)
+}
+
+func (mgr *segmentManager) RemoveCtx(ctx context.Context, segmentID typeutil.UniqueID, scope querypb.DataScope) (int, int) {
+	shards := mgr.shardsOfSegments(segmentID)
+	lockShards(shards)
+	// the segment is in one of the shards at most
+	removeWithType := func(typ SegmentType) Segment {
+		for _, shard := range shards {
+			if segment := shard.removeSegmentWithType(typ, segmentID); segment != nil {
+				return segment
+			}
+		}
+		return nil
+	}
 
 	var removeGrowing, removeSealed int
 	var growing, sealed Segment
 	switch scope {
 	case querypb.DataScope_Streaming:
-		growing = mgr.removeSegmentWithType(SegmentTypeGrowing, segmentID)
+		growing = removeWithType(SegmentTypeGrowing)
 		if growing != nil {
 			removeGrowing = 1
 		}
 
 	case querypb.DataScope_Historical:
-		sealed = mgr.removeSegmentWithType(SegmentTypeSealed, segmentID)
+		sealed = removeWithType(SegmentTypeSealed)
 		if sealed != nil {
 			removeSealed = 1
 		}
 
 	case querypb.DataScope_All:
-		growing = mgr.removeSegmentWithType(SegmentTypeGrowing, segmentID)
+		growing = removeWithType(SegmentTypeGrowing)
 		if growing != nil {
 			removeGrowing = 1
 		}
 
-		sealed = mgr.removeSegmentWithType(SegmentTypeSealed, segmentID)
+		sealed = removeWithType(SegmentTypeSealed)
 		if sealed != nil {
 			removeSealed = 1
 		}
 	}
+	mgr.invalidateFilterCache()
+	mgr.publishShards(shards)
+	unlockShards(shards)
 	mgr.updateMetric()
-	mgr.mu.Unlock()
+
+	if growing != nil || sealed != nil {
+		mgr.audit.record(AuditOpRemove, []int64{segmentID})
+	}
 
 	if growing != nil {
-		remove(growing)
+		mgr.release(ctx, growing, ReleaseReasonRemoved)
 	}
 
 	if sealed != nil {
-		remove(sealed)
+		mgr.release(ctx, sealed, ReleaseReasonRemoved)
 	}
 
 	return removeGrowing, removeSealed
 }
 
-func (mgr *segmentManager) removeSegmentWithType(typ SegmentType, segmentID typeutil.UniqueID) Segment {
-	switch typ {
-	case SegmentTypeGrowing:
-		s, ok := mgr.growingSegments[segmentID]
-		if ok {
-			delete(mgr.growingSegments, segmentID)
-			return s
+func (mgr *segmentManager) Handoff(growingID int64, sealed Segment) (Segment, error) {
+	if sealed.Type() != SegmentTypeSealed {
+		return nil, merr.WrapErrParameterInvalid(SegmentTypeSealed.String(), sealed.Type().String(), "handoff target must be sealed segment")
+	}
+
+	// the sealed segment must be of the collection of the growing one, which is in the same shard
+	shards := mgr.shardsOfSegments(growingID)
+	lockShards(shards)
+	var growing Segment
+	if len(shards) > 0 {
+		growing = shards[0].growingSegments[growingID]
+	}
+	if growing == nil {
+		unlockShards(shards)
+		return nil, merr.WrapErrSegmentNotFound(growingID, "growing segment not found")
+	}
+	if growing.Collection() != sealed.Collection() || growing.Partition() != sealed.Partition() {
+		unlockShards(shards)
+		return nil, merr.WrapErrParameterInvalidMsg("sealed segment %d[collection=%d, partition=%d] mismatches growing segment %d[collection=%d, partition=%d]",
+			sealed.ID(), sealed.Collection(), sealed.Partition(), growing.ID(), growing.Collection(), growing.Partition())
+	}
+	shard := shards[0]
+	replaced, ok := shard.sealedSegments[sealed.ID()]
+	if ok && replaced.Version() >= sealed.Version() {
+		unlockShards(shards)
+		return nil, merr.WrapErrSegmentReduplicate(sealed.ID(), "sealed segment with newer version exists")
+	}
+	shard.addSegmentWithType(SegmentTypeSealed, sealed)
+	shard.removeSegmentWithType(SegmentTypeGrowing, growingID)
+	mgr.invalidateFilterCache()
+	mgr.publishShards(shards)
+	unlockShards(shards)
+	mgr.notifyPut()
+	mgr.updateMetric()
+
+	mgr.audit.record(AuditOpHandoff, []int64{growingID, sealed.ID()})
+
+	if replaced != nil {
+		go mgr.release(context.Background(), replaced, ReleaseReasonReplaced)
+	}
+
+	eventlog.Record(eventlog.NewRawEvt(eventlog.Level_Info, fmt.Sprintf("Segment %d[%d] loaded", sealed.ID(), sealed.Collection())))
+	mgr.addSegmentNum(sealed, 1)
+	mgr.addSegmentNum(growing, -1)
+
+	return growing, nil
+}
+
+func (mgr *segmentManager) Relabel(segmentID int64, newChannel string) bool {
+	shards := mgr.shardsOfSegments(segmentID)
+	lockShards(shards)
+	var relabeled []Segment
+	for _, shard := range shards {
+		for _, segment := range []Segment{shard.growingSegments[segmentID], shard.sealedSegments[segmentID]} {
+			if segment != nil {
+				segment.SetShard(newChannel)
+				relabeled = append(relabeled, segment)
+			}
 		}
+	}
+	if len(relabeled) > 0 {
+		// drop the cached results of the channel filters
+		mgr.invalidateFilterCache()
+	}
+	unlockShards(shards)
+
+	if len(relabeled) == 0 {
+		return false
+	}
+	// refresh the per channel metrics
+	mgr.updateMetric()
+	mgr.audit.record(AuditOpUpdate, []int64{segmentID})
+	log.Info("relabel segment", zap.Int64("segmentID", segmentID), zap.String("channel", newChannel))
+	return true
+}
 
+// addSegmentWithType puts the segment into the map of the type, and adds it into the segment and index lookups,
+// the replaced segment of the same ID and type is dropped from the lookups. Must be called with the write lock held.
+func (shard *collectionShard) addSegmentWithType(typ SegmentType, segment Segment) {
+	id := segment.ID()
+	shard.removeSegmentWithType(typ, id)
+	switch typ {
+	case SegmentTypeGrowing:
+		shard.growingSegments[id] = segment
+		shard.dirtyGrowing = true
 	case SegmentTypeSealed:
-		s, ok := mgr.sealedSegments[segmentID]
-		if ok {
-			delete(mgr.sealedSegments, segmentID)
-			return s
-		}
+		shard.sealedSegments[id] = segment
+		shard.dirtySealed = true
+		shard.indexBuildsOf(segment)
 	default:
+		return
+	}
+	shard.segmentShards.Insert(id, shard)
+}
+
+// removeSegmentWithType removes the segment of the ID from the map of the type and the lookups,
+// returns nil if not found. Must be called with the write lock held.
+func (shard *collectionShard) removeSegmentWithType(typ SegmentType, segmentID typeutil.UniqueID) Segment {
+	var s Segment
+	switch typ {
+	case SegmentTypeGrowing:
+		s = shard.growingSegments[segmentID]
+		delete(shard.growingSegments, segmentID)
+		shard.dirtyGrowing = shard.dirtyGrowing || s != nil
+	case SegmentTypeSealed:
+		s = shard.sealedSegments[segmentID]
+		delete(shard.sealedSegments, segmentID)
+		shard.dirtySealed = shard.dirtySealed || s != nil
+		shard.unindexBuilds(segmentID)
+	}
+	if s == nil {
 		return nil
 	}
 
-	return nil
+	// the segment of the other type with the same ID keeps the ID in the lookup
+	if shard.growingSegments[segmentID] == nil && shard.sealedSegments[segmentID] == nil {
+		if owner, ok := shard.segmentShards.Get(segmentID); ok && owner == shard {
+			shard.segmentShards.Remove(segmentID)
+		}
+	}
+	return s
+}
+
+// indexBuildsOf adds the build IDs of the loaded indexes of the sealed segment into the index lookup,
+// must be called with the write lock held.
+func (shard *collectionShard) indexBuildsOf(segment Segment) {
+	var buildIDs []int64
+	for _, index := range segment.Indexes() {
+		if buildID := index.IndexInfo.GetBuildID(); buildID != 0 {
+			shard.indexBuilds.Insert(buildID, segment.ID())
+			buildIDs = append(buildIDs, buildID)
+		}
+	}
+	if len(buildIDs) > 0 {
+		shard.segmentBuilds[segment.ID()] = buildIDs
+	}
+}
+
+// unindexBuilds drops the build IDs of the sealed segment of the ID from the index lookup,
+// must be called with the write lock held.
+func (shard *collectionShard) unindexBuilds(segmentID int64) {
+	for _, buildID := range shard.segmentBuilds[segmentID] {
+		if id, ok := shard.indexBuilds.Get(buildID); ok && id == segmentID {
+			shard.indexBuilds.Remove(buildID)
+		}
+	}
+	delete(shard.segmentBuilds, segmentID)
 }
 
 func (mgr *segmentManager) RemoveBy(filters ...SegmentFilter) (int, int) {
-	mgr.mu.Lock()
+	return mgr.RemoveByCtx(context.Background(), filters...)
+}
+
+func (mgr *segmentManager) RemoveByCtx(ctx context.Context, filters ...SegmentFilter) (int, int) {
+	removeSegments, removeGrowing, removeSealed := mgr.removeBy(filters...)
+	for _, s := range removeSegments {
+		mgr.release(ctx, s, ReleaseReasonRemoved)
+	}
+	return removeGrowing, removeSealed
+}
+
+func (mgr *segmentManager) RemoveByAsync(filters ...SegmentFilter) (int, int) {
+	removeSegments, removeGrowing, removeSealed := mgr.removeBy(filters...)
+	if len(removeSegments) > 0 {
+		// the pool may be busy, submit in background to return immediately
+		go func() {
+			pool := GetReleasePool()
+			for _, s := range removeSegments {
+				s := s
+				pool.Submit(func() (any, error) {
+					mgr.release(context.Background(), s, ReleaseReasonRemoved)
+					return nil, nil
+				})
+			}
+		}()
+	}
+	return removeGrowing, removeSealed
+}
+
+func (mgr *segmentManager) RemoveByWaitPins(timeout time.Duration, filters ...SegmentFilter) (int, int, []int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	mgr.waitUnpinned(ctx, typeutil.NewSet(mgr.GetBy(filters...)...))
+
+	// the segments may be pinned again since the wait, keep the pinned ones,
+	// no more pins could happen while removing under the write lock
+	var pinnedIDs []int64
+	unpinned := SegmentFilterFunc(func(segment Segment) bool {
+		if mgr.isPinned(segment) {
+			pinnedIDs = append(pinnedIDs, segment.ID())
+			return false
+		}
+		return true
+	})
+	removeSegments, removeGrowing, removeSealed := mgr.removeBy(append(filters[:len(filters):len(filters)], unpinned)...)
+	for _, s := range removeSegments {
+		mgr.release(context.Background(), s, ReleaseReasonRemoved)
+	}
+
+	if len(pinnedIDs) > 0 {
+		// the growing and sealed segments of the same ID may be both pinned
+		pinnedIDs = lo.Uniq(pinnedIDs)
+		sort.Slice(pinnedIDs, func(i, j int) bool { return pinnedIDs[i] < pinnedIDs[j] })
+		log.Warn("keep segments still pinned after waiting", zap.Duration("timeout", timeout), zap.Int64s("segmentIDs", pinnedIDs))
+	}
+	return removeGrowing, removeSealed, pinnedIDs
+}
+
+// removeBy removes the segments matching the filters from the manager without releasing them,
+// returns the removed segments and the number of the growing and sealed ones.
+func (mgr *segmentManager) removeBy(filters ...SegmentFilter) ([]Segment, int, int) {
+	shards := mgr.shardsFor(filters...)
+	lockShards(shards)
+	byCollection := shardsByCollection(shards)
 
 	var removeSegments []Segment
 	var removeGrowing, removeSealed int
 
-	mgr.rangeWithFilter(func(id int64, segType SegmentType, segment Segment) bool {
-		s := mgr.removeSegmentWithType(segType, id)
+	mgr.rangeWithFilter(shards, func(id int64, segType SegmentType, segment Segment) bool {
+		s := byCollection[segment.Collection()].removeSegmentWithType(segType, id)
 		if s != nil {
 			removeSegments = append(removeSegments, s)
 			switch segType {
@@ -631,50 +3002,387 @@ func (mgr *segmentManager) RemoveBy(filters ...SegmentFilter) (int, int) {
 		}
 		return true
 	}, filters...)
+	mgr.invalidateFilterCache()
+	mgr.publishShards(shards)
+	unlockShards(shards)
 	mgr.updateMetric()
-	mgr.mu.Unlock()
 
-	for _, s := range removeSegments {
-		remove(s)
+	if len(removeSegments) > 0 {
+		removedIDs := make([]int64, 0, len(removeSegments))
+		for _, s := range removeSegments {
+			removedIDs = append(removedIDs, s.ID())
+		}
+		mgr.audit.record(AuditOpRemove, removedIDs)
 	}
-
-	return removeGrowing, removeSealed
+	return removeSegments, removeGrowing, removeSealed
 }
 
 func (mgr *segmentManager) Clear() {
-	mgr.mu.Lock()
-	defer mgr.mu.Unlock()
+	// the segments pinned again since the wait are kept by ClearWaitPins, retry until all cleared
+	for {
+		if err := mgr.ClearWaitPins(context.Background()); err == nil {
+			return
+		}
+	}
+}
 
-	for id, segment := range mgr.growingSegments {
-		delete(mgr.growingSegments, id)
-		remove(segment)
+func (mgr *segmentManager) ClearWaitPins(ctx context.Context) error {
+	// wait without holding the lock, so that the queries and loads are not blocked meanwhile,
+	// the segments pinned again since the wait are kept by removeAll
+	mgr.waitUnpinned(ctx, nil)
+
+	removed, pinnedIDs := mgr.removeAll()
+	for _, segment := range removed {
+		mgr.release(ctx, segment, ReleaseReasonCleared)
 	}
 
-	for id, segment := range mgr.sealedSegments {
-		delete(mgr.sealedSegments, id)
-		remove(segment)
+	if len(pinnedIDs) > 0 {
+		return merr.WrapErrServiceInternal("failed to clear segments", fmt.Sprintf("segments %v still pinned", pinnedIDs))
 	}
+	return nil
+}
+
+// removeAll removes all the segments but the pinned ones from the manager without releasing them,
+// returns the removed segments and the sorted IDs of the kept ones.
+func (mgr *segmentManager) removeAll() ([]Segment, []int64) {
+	shards := mgr.allShards()
+	lockShards(shards)
+	byCollection := shardsByCollection(shards)
+	// no more segments could be pinned while holding the write locks
+	pinned := mgr.pinnedSegments()
+
+	var removed []Segment
+	var clearedIDs, pinnedIDs []int64
+	mgr.rangeWithFilter(shards, func(id int64, segType SegmentType, segment Segment) bool {
+		if pinned.Contain(segment) {
+			pinnedIDs = append(pinnedIDs, id)
+			return true
+		}
+		byCollection[segment.Collection()].removeSegmentWithType(segType, id)
+		removed = append(removed, segment)
+		clearedIDs = append(clearedIDs, id)
+		return true
+	})
+	mgr.invalidateFilterCache()
+	mgr.publishShards(shards)
+	unlockShards(shards)
 	mgr.updateMetric()
+
+	mgr.audit.record(AuditOpClear, clearedIDs)
+	sort.Slice(pinnedIDs, func(i, j int) bool { return pinnedIDs[i] < pinnedIDs[j] })
+	return removed, pinnedIDs
+}
+
+// waitUnpinned waits until none of the given segments is pinned or ctx done, all the segments if nil,
+// returns the segments still pinned. It's woken up by Unpin rather than polling.
+func (mgr *segmentManager) waitUnpinned(ctx context.Context, segments typeutil.Set[Segment]) typeutil.Set[Segment] {
+	for {
+		pinned, unpinCh := mgr.pinnedOf(segments)
+		if pinned.Len() == 0 {
+			return pinned
+		}
+		select {
+		case <-ctx.Done():
+			return pinned
+		case <-unpinCh:
+		}
+	}
 }
 
+// updateMetric updates the segment metrics from the published snapshots, called after the segments changed.
+// The concurrent calls are coalesced, the caller finding another one updating leaves the update to it,
+// which updates again before returning.
 func (mgr *segmentManager) updateMetric() {
+	if mgr.metricsDisabled {
+		return
+	}
+	mgr.metricsPending.Store(true)
+	for mgr.metricsPending.Load() && mgr.metricsMu.TryLock() {
+		mgr.metricsPending.Store(false)
+		mgr.updateMetricLocked()
+		mgr.metricsMu.Unlock()
+	}
+}
+
+func (mgr *segmentManager) updateMetricLocked() {
 	// update collection and partiation metric
 	collections, partiations := make(typeutil.Set[int64]), make(typeutil.Set[int64])
-	for _, seg := range mgr.growingSegments {
-		collections.Insert(seg.Collection())
-		partiations.Insert(seg.Partition())
+	channels := make(map[string]int)
+	for _, shard := range mgr.allShards() {
+		snapshot := shard.snapshot.Load()
+		for _, segments := range []map[typeutil.UniqueID]Segment{snapshot.growing, snapshot.sealed} {
+			for _, seg := range segments {
+				collections.Insert(seg.Collection())
+				partiations.Insert(seg.Partition())
+				channels[seg.Shard()]++
+			}
+		}
+	}
+	nodeID := fmt.Sprint(paramtable.GetNodeID())
+	metrics.QueryNodeNumCollections.WithLabelValues(nodeID).Set(float64(collections.Len()))
+	metrics.QueryNodeNumPartitions.WithLabelValues(nodeID).Set(float64(partiations.Len()))
+
+	// the channels without segments any more drop to zero
+	for channel := range mgr.metricChannels {
+		if _, ok := channels[channel]; !ok {
+			metrics.QueryNodeChannelSegmentNum.WithLabelValues(nodeID, channel).Set(0)
+			mgr.metricChannels.Remove(channel)
+		}
+	}
+	for channel, num := range channels {
+		metrics.QueryNodeChannelSegmentNum.WithLabelValues(nodeID, channel).Set(float64(num))
+		mgr.metricChannels.Insert(channel)
+	}
+}
+
+// release works like remove, and logs the release and its reason with the correlation info carried by ctx.
+func (mgr *segmentManager) release(ctx context.Context, segment Segment, reason ReleaseReason) bool {
+	log.Ctx(ctx).Info("remove segment",
+		zap.Int64("segmentID", segment.ID()),
+		zap.Int64("collectionID", segment.Collection()),
+		zap.String("type", segment.Type().String()),
+		zap.String("reason", string(reason)),
+	)
+	eventlog.Record(eventlog.NewRawEvt(eventlog.Level_Info, withTraceID(ctx, fmt.Sprintf("Segment %d[%d] %s", segment.ID(), segment.Collection(), reason))))
+	mgr.recordRelease(segment, reason)
+	mgr.healthMu.Lock()
+	delete(mgr.queryErrors, segment.ID())
+	delete(mgr.quarantined, segment.ID())
+	mgr.readOnly.Remove(segment.ID())
+	delete(mgr.evictedAt, segment.ID())
+	delete(mgr.thrashCycles, segment.ID())
+	mgr.healthMu.Unlock()
+	mgr.lazyMu.Lock()
+	mgr.lazy.Remove(segment)
+	mgr.lazyMu.Unlock()
+	return mgr.remove(segment)
+}
+
+// recordRelease appends the release to the ring buffer of the recent releases.
+func (mgr *segmentManager) recordRelease(segment Segment, reason ReleaseReason) {
+	if recentReleasesCapacity <= 0 {
+		return
+	}
+	record := ReleaseRecord{
+		SegmentID:    segment.ID(),
+		CollectionID: segment.Collection(),
+		Reason:       reason,
+		Time:         time.Now(),
+	}
+
+	mgr.releaseMu.Lock()
+	defer mgr.releaseMu.Unlock()
+	if len(mgr.releases) < recentReleasesCapacity {
+		mgr.releases = append(mgr.releases, record)
+	} else {
+		mgr.releases[mgr.releaseNext] = record
+	}
+	mgr.releaseNext = (mgr.releaseNext + 1) % recentReleasesCapacity
+}
+
+func (mgr *segmentManager) RecordQueryError(segmentID int64) {
+	if !mgr.Exists(segmentID) {
+		return
+	}
+	mgr.healthMu.Lock()
+	defer mgr.healthMu.Unlock()
+	mgr.queryErrors[segmentID]++
+}
+
+func (mgr *segmentManager) UnhealthySegments(threshold int) []int64 {
+	mgr.healthMu.Lock()
+	defer mgr.healthMu.Unlock()
+
+	var ret []int64
+	for segmentID, count := range mgr.queryErrors {
+		if count > threshold {
+			ret = append(ret, segmentID)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+func (mgr *segmentManager) Quarantine(segmentID int64, reason string) {
+	segment := mgr.Get(segmentID)
+	if segment == nil {
+		log.Warn("skip quarantining segment not loaded", zap.Int64("segmentID", segmentID), zap.String("reason", reason))
+		return
+	}
+
+	mgr.healthMu.Lock()
+	mgr.quarantined[segmentID] = reason
+	mgr.healthMu.Unlock()
+
+	log.Warn("quarantine segment", zap.Int64("segmentID", segmentID), zap.String("reason", reason))
+	eventlog.Record(eventlog.NewRawEvt(eventlog.Level_Warn, fmt.Sprintf("Segment %d[%d] quarantined, %s", segmentID, segment.Collection(), reason)))
+}
+
+func (mgr *segmentManager) Unquarantine(segmentID int64) {
+	mgr.healthMu.Lock()
+	_, ok := mgr.quarantined[segmentID]
+	delete(mgr.quarantined, segmentID)
+	mgr.healthMu.Unlock()
+
+	if ok {
+		log.Info("unquarantine segment", zap.Int64("segmentID", segmentID))
+	}
+}
+
+func (mgr *segmentManager) QuarantinedSegments() map[int64]string {
+	mgr.healthMu.Lock()
+	defer mgr.healthMu.Unlock()
+
+	ret := make(map[int64]string, len(mgr.quarantined))
+	for segmentID, reason := range mgr.quarantined {
+		ret[segmentID] = reason
+	}
+	return ret
+}
+
+func (mgr *segmentManager) SetReadOnly(segmentID int64, ro bool) {
+	if ro && mgr.Get(segmentID) == nil {
+		log.Warn("skip marking segment not loaded read-only", zap.Int64("segmentID", segmentID))
+		return
+	}
+
+	mgr.healthMu.Lock()
+	changed := mgr.readOnly.Contain(segmentID) != ro
+	if ro {
+		mgr.readOnly.Insert(segmentID)
+	} else {
+		mgr.readOnly.Remove(segmentID)
+	}
+	mgr.healthMu.Unlock()
+
+	if changed {
+		log.Info("set segment read-only", zap.Int64("segmentID", segmentID), zap.Bool("readOnly", ro))
+	}
+}
+
+func (mgr *segmentManager) ReadOnlySegments() []int64 {
+	ret := mgr.readOnlySegments().Collect()
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+// recordEviction marks the segment evicted from the disk cache,
+// the following reload completes an eviction and reload cycle.
+func (mgr *segmentManager) recordEviction(segmentID int64) {
+	mgr.healthMu.Lock()
+	defer mgr.healthMu.Unlock()
+	mgr.evictedAt[segmentID] = time.Now()
+}
+
+// recordReload completes the eviction and reload cycle of the segment reloaded into the disk cache,
+// ignored if the segment is cached for the first time.
+func (mgr *segmentManager) recordReload(segmentID int64) {
+	mgr.healthMu.Lock()
+	defer mgr.healthMu.Unlock()
+
+	if _, ok := mgr.evictedAt[segmentID]; !ok {
+		return
+	}
+	delete(mgr.evictedAt, segmentID)
+	if thrashHistoryCapacity <= 0 {
+		return
+	}
+	cycles := append(mgr.thrashCycles[segmentID], time.Now())
+	if len(cycles) > thrashHistoryCapacity {
+		cycles = cycles[len(cycles)-thrashHistoryCapacity:]
+	}
+	mgr.thrashCycles[segmentID] = cycles
+}
+
+func (mgr *segmentManager) ThrashingSegments(minCycles int, window time.Duration) []int64 {
+	since := time.Now().Add(-window)
+
+	mgr.healthMu.Lock()
+	defer mgr.healthMu.Unlock()
+
+	var ret []int64
+	for segmentID, cycles := range mgr.thrashCycles {
+		// the cycles are in time order, count the ones within the window from the newest
+		n := len(cycles) - sort.Search(len(cycles), func(i int) bool { return !cycles[i].Before(since) })
+		if n > 0 && n >= minCycles {
+			ret = append(ret, segmentID)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+// readOnlySegments returns the copy of the IDs of the read-only segments.
+func (mgr *segmentManager) readOnlySegments() typeutil.Set[int64] {
+	mgr.healthMu.Lock()
+	defer mgr.healthMu.Unlock()
+	return typeutil.NewSet(mgr.readOnly.Collect()...)
+}
+
+// warnSkippedReadOnly logs the read-only segments skipped by the updates.
+func warnSkippedReadOnly(skipped []int64) {
+	if len(skipped) > 0 {
+		log.Warn("skip updating read-only segments", zap.Int64s("segmentIDs", skipped))
+	}
+}
+
+// quarantinedSegments returns the copy of the IDs of the quarantined segments.
+func (mgr *segmentManager) quarantinedSegments() typeutil.Set[int64] {
+	mgr.healthMu.Lock()
+	defer mgr.healthMu.Unlock()
+	return typeutil.NewSet(lo.Keys(mgr.quarantined)...)
+}
+
+func (mgr *segmentManager) RecentReleases(n int) []ReleaseRecord {
+	mgr.releaseMu.Lock()
+	defer mgr.releaseMu.Unlock()
+
+	if n <= 0 || len(mgr.releases) == 0 {
+		return nil
+	}
+	if n > len(mgr.releases) {
+		n = len(mgr.releases)
 	}
-	for _, seg := range mgr.sealedSegments {
-		collections.Insert(seg.Collection())
-		partiations.Insert(seg.Partition())
+	ret := make([]ReleaseRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		idx := (mgr.releaseNext - i + len(mgr.releases)) % len(mgr.releases)
+		ret = append(ret, mgr.releases[idx])
 	}
-	metrics.QueryNodeNumCollections.WithLabelValues(fmt.Sprint(paramtable.GetNodeID())).Set(float64(collections.Len()))
-	metrics.QueryNodeNumPartitions.WithLabelValues(fmt.Sprint(paramtable.GetNodeID())).Set(float64(partiations.Len()))
+	return ret
+}
+
+func (mgr *segmentManager) DumpAudit() []AuditEntry {
+	return mgr.audit.dump()
+}
+
+// withTraceID appends the trace ID carried by ctx to the event message, if any.
+func withTraceID(ctx context.Context, msg string) string {
+	if traceID := trace.SpanContextFromContext(ctx).TraceID(); traceID.IsValid() {
+		return fmt.Sprintf("%s, traceID %s", msg, traceID)
+	}
+	return msg
 }
 
-func remove(segment Segment) bool {
+func (mgr *segmentManager) remove(segment Segment) bool {
+	start := time.Now()
 	segment.Release()
+	if !mgr.metricsDisabled {
+		metrics.QueryNodeSegmentReleaseLatency.WithLabelValues(
+			fmt.Sprint(paramtable.GetNodeID()),
+			segment.Type().String(),
+			segment.Level().String(),
+		).Observe(float64(time.Since(start).Milliseconds()))
+	}
+	mgr.addSegmentNum(segment, -1)
+
+	return true
+}
 
+// addSegmentNum adds delta to the number of the segments of the same kind as the given one.
+func (mgr *segmentManager) addSegmentNum(segment Segment, delta float64) {
+	if mgr.metricsDisabled {
+		return
+	}
 	metrics.QueryNodeNumSegments.WithLabelValues(
 		fmt.Sprint(paramtable.GetNodeID()),
 		fmt.Sprint(segment.Collection()),
@@ -682,7 +3390,5 @@ func remove(segment Segment) bool {
 		segment.Type().String(),
 		fmt.Sprint(len(segment.Indexes())),
 		segment.Level().String(),
-	).Dec()
-
-	return true
+	).Add(delta)
 }
@@ -1,17 +1,39 @@
 package segments
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/atomic"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/milvus-io/milvus/internal/proto/datapb"
 	"github.com/milvus-io/milvus/internal/proto/querypb"
+	"github.com/milvus-io/milvus/pkg/common"
+	"github.com/milvus-io/milvus/pkg/eventlog"
+	"github.com/milvus-io/milvus/pkg/log"
+	"github.com/milvus-io/milvus/pkg/metrics"
+	"github.com/milvus-io/milvus/pkg/util/cache"
+	"github.com/milvus-io/milvus/pkg/util/merr"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
 type ManagerSuite struct {
@@ -43,29 +65,95 @@ func (s *ManagerSuite) SetupTest() {
 	s.mgr = NewSegmentManager()
 
 	for i, id := range s.segmentIDs {
-		schema := GenTestCollectionSchema("manager-suite", schemapb.DataType_Int64, true)
-		segment, err := NewSegment(
-			context.Background(),
-			NewCollection(s.collectionIDs[i], schema, GenTestIndexMeta(s.collectionIDs[i], schema), &querypb.LoadMetaInfo{
-				LoadType: querypb.LoadType_LoadCollection,
-			}),
-			s.types[i],
-			0,
-			&querypb.SegmentLoadInfo{
-				SegmentID:     id,
-				PartitionID:   s.partitionIDs[i],
-				CollectionID:  s.collectionIDs[i],
-				InsertChannel: s.channels[i],
-				Level:         s.levels[i],
-			},
-		)
-		s.Require().NoError(err)
+		segment := s.newSegment(id, i, 0)
 		s.segments = append(s.segments, segment)
 
 		s.mgr.Put(s.types[i], segment)
 	}
 }
 
+func (s *ManagerSuite) newSegment(id int64, idx int, version int64) Segment {
+	schema := GenTestCollectionSchema("manager-suite", schemapb.DataType_Int64, true)
+	segment, err := NewSegment(
+		context.Background(),
+		NewCollection(s.collectionIDs[idx], schema, GenTestIndexMeta(s.collectionIDs[idx], schema), &querypb.LoadMetaInfo{
+			LoadType: querypb.LoadType_LoadCollection,
+		}),
+		s.types[idx],
+		version,
+		&querypb.SegmentLoadInfo{
+			SegmentID:     id,
+			PartitionID:   s.partitionIDs[idx],
+			CollectionID:  s.collectionIDs[idx],
+			InsertChannel: s.channels[idx],
+			Level:         s.levels[idx],
+		},
+	)
+	s.Require().NoError(err)
+	return segment
+}
+
+func (s *ManagerSuite) TestPutWithReport() {
+	stale := s.newSegment(s.segmentIDs[0], 0, 0)
+	newer := s.newSegment(s.segmentIDs[2], 2, 1)
+	fresh := s.newSegment(5, 0, 0)
+
+	loaded, skipped := s.mgr.PutWithReport(SegmentTypeSealed, stale, newer, fresh)
+	s.ElementsMatch([]int64{s.segmentIDs[2], 5}, loaded)
+	s.ElementsMatch([]int64{s.segmentIDs[0]}, skipped)
+
+	s.NotSame(stale, s.mgr.Get(s.segmentIDs[0]))
+	s.Same(newer, s.mgr.Get(s.segmentIDs[2]))
+	s.Same(fresh, s.mgr.Get(5))
+}
+
+func (s *ManagerSuite) TestWaitForSegment() {
+	// loaded already
+	segment, err := s.mgr.WaitForSegment(context.Background(), 1, SegmentTypeSealed)
+	s.NoError(err)
+	s.Equal(s.segments[0], segment)
+
+	// put after the wait begins, the puts of the other segments don't end the wait
+	waiting := s.newSegment(5, 0, 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		segment, err := s.mgr.WaitForSegment(context.Background(), 5, SegmentTypeSealed)
+		s.NoError(err)
+		s.Equal(waiting, segment)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	s.mgr.Put(SegmentTypeSealed, s.newSegment(6, 0, 0))
+	s.mgr.Put(SegmentTypeGrowing, s.newSegment(5, 1, 0))
+	select {
+	case <-done:
+		s.FailNow("wait returned before the segment put")
+	case <-time.After(50 * time.Millisecond):
+	}
+	s.mgr.Put(SegmentTypeSealed, waiting)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		s.FailNow("wait not returned after the segment put")
+	}
+
+	// context expires
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = s.mgr.WaitForSegment(ctx, 100, SegmentTypeSealed)
+	s.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func (s *ManagerSuite) TestGetMany() {
+	segments := s.mgr.GetMany([]int64{s.segmentIDs[0], s.segmentIDs[1], 1000})
+	s.Len(segments, 2)
+	s.Same(s.mgr.Get(s.segmentIDs[0]), segments[s.segmentIDs[0]])
+	s.Same(s.mgr.Get(s.segmentIDs[1]), segments[s.segmentIDs[1]])
+	s.NotContains(segments, int64(1000))
+
+	s.Empty(s.mgr.GetMany(nil))
+}
+
 func (s *ManagerSuite) TestGetBy() {
 	for i, partitionID := range s.partitionIDs {
 		segments := s.mgr.GetBy(WithPartition(partitionID))
@@ -82,7 +170,7 @@ func (s *ManagerSuite) TestGetBy() {
 		segments := s.mgr.GetBy(WithType(typ))
 		s.Contains(lo.Map(segments, func(segment Segment, _ int) int64 { return segment.ID() }), s.segmentIDs[i])
 	}
-	s.mgr.Clear()
+	s.NoError(s.mgr.ClearWaitPins(context.Background()))
 
 	for _, typ := range s.types {
 		segments := s.mgr.GetBy(WithType(typ))
@@ -90,6 +178,234 @@ func (s *ManagerSuite) TestGetBy() {
 	}
 }
 
+func (s *ManagerSuite) TestGetByConflictingFilters() {
+	// conflicting type filters match nothing
+	s.Empty(s.mgr.GetBy(WithType(SegmentTypeSealed), WithType(SegmentTypeGrowing)))
+	s.Empty(s.mgr.GetBy(WithType(SegmentTypeGrowing), WithType(SegmentTypeSealed)))
+	_, err := s.mgr.GetByChecked(WithType(SegmentTypeSealed), WithType(SegmentTypeGrowing))
+	s.ErrorIs(err, merr.ErrParameterInvalid)
+
+	// the same type twice is fine
+	segments, err := s.mgr.GetByChecked(WithType(SegmentTypeGrowing), WithType(SegmentTypeGrowing))
+	s.NoError(err)
+	s.Len(segments, 1)
+	s.EqualValues(2, segments[0].ID())
+
+	// duplicate ID filters are ANDed
+	segments, err = s.mgr.GetByChecked(WithID(1), WithID(1))
+	s.NoError(err)
+	s.Len(segments, 1)
+	s.Empty(s.mgr.GetBy(WithID(1), WithID(3)))
+	_, err = s.mgr.GetByChecked(WithID(1), WithID(3))
+	s.ErrorIs(err, merr.ErrParameterInvalid)
+
+	// the filters of the ID and the type are not conflicting even if nothing matches
+	segments, err = s.mgr.GetByChecked(WithID(1), WithType(SegmentTypeGrowing))
+	s.NoError(err)
+	s.Empty(segments)
+}
+
+func (s *ManagerSuite) TestGetByIDAndCollection() {
+	// the ID fast path still applies the collection filter
+	segments, stats := s.mgr.GetByWithStats(WithID(1), WithCollections(s.collectionIDs[0]))
+	s.Len(segments, 1)
+	s.EqualValues(1, segments[0].ID())
+	s.EqualValues(1, stats.Scanned)
+
+	// the segments of the other collections are excluded
+	for _, id := range s.segmentIDs[1:] {
+		s.Empty(s.mgr.GetBy(WithID(id), WithCollections(s.collectionIDs[0])))
+	}
+	segments = s.mgr.GetBy(WithID(3), WithCollections(s.collectionIDs[0], s.collectionIDs[2]), WithType(SegmentTypeSealed))
+	s.Len(segments, 1)
+	s.EqualValues(3, segments[0].ID())
+	s.Empty(s.mgr.GetBy(WithID(2), WithCollections(s.collectionIDs[1]), WithType(SegmentTypeSealed)))
+}
+
+func (s *ManagerSuite) TestWithCollections() {
+	ids := func(segments []Segment) []int64 {
+		return lo.Map(segments, func(segment Segment, _ int) int64 { return segment.ID() })
+	}
+
+	s.ElementsMatch([]int64{1, 3}, ids(s.mgr.GetBy(WithCollections(s.collectionIDs[0], s.collectionIDs[2]))))
+	s.ElementsMatch(s.segmentIDs, ids(s.mgr.GetBy(WithCollections(s.collectionIDs...))))
+	s.ElementsMatch([]int64{2}, ids(s.mgr.GetBy(WithCollections(s.collectionIDs[1], 1000))))
+	s.Empty(s.mgr.GetBy(WithCollections()))
+	s.ElementsMatch([]int64{3}, ids(s.mgr.GetBy(WithCollections(s.collectionIDs[1], s.collectionIDs[2]), WithType(SegmentTypeSealed))))
+
+	// the signature is independent of the order and duplication
+	s.Equal(signatureOf(WithCollections(100, 200)), signatureOf(WithCollections(200, 100, 200)))
+	s.NotEqual(signatureOf(WithCollections(100)), signatureOf(WithCollections(100, 200)))
+}
+
+func (s *ManagerSuite) TestGetByWithStats() {
+	segments, stats := s.mgr.GetByWithStats()
+	s.Len(segments, len(s.segmentIDs))
+	s.Equal(len(s.segmentIDs), stats.Scanned)
+	s.Equal(len(s.segmentIDs), stats.Matched)
+
+	segments, stats = s.mgr.GetByWithStats(WithPartition(s.partitionIDs[0]))
+	s.Len(segments, 1)
+	s.Equal(len(s.segmentIDs), stats.Scanned)
+	s.Equal(1, stats.Matched)
+
+	// type and ID filters narrow the candidates before scanning
+	segments, stats = s.mgr.GetByWithStats(WithType(SegmentTypeSealed), WithPartition(s.partitionIDs[0]))
+	s.Len(segments, 1)
+	s.Equal(3, stats.Scanned)
+	s.Equal(1, stats.Matched)
+
+	segments, stats = s.mgr.GetByWithStats(WithID(s.segmentIDs[0]), WithPartition(s.partitionIDs[1]))
+	s.Empty(segments)
+	s.Equal(1, stats.Scanned)
+	s.Equal(0, stats.Matched)
+	s.GreaterOrEqual(stats.Scanned, stats.Matched)
+
+	// only the segments of the collections are scanned
+	segments, stats = s.mgr.GetByWithStats(WithCollections(s.collectionIDs[0], s.collectionIDs[1]))
+	s.Len(segments, 2)
+	s.Equal(2, stats.Scanned)
+	s.Equal(2, stats.Matched)
+
+	segments, stats = s.mgr.GetByWithStats(WithCollections(s.collectionIDs[0]), WithCollections(s.collectionIDs[1]))
+	s.Empty(segments)
+	s.Equal(0, stats.Scanned)
+
+	// the collection index follows the removals
+	s.mgr.Remove(s.segmentIDs[0], querypb.DataScope_All)
+	segments, stats = s.mgr.GetByWithStats(WithCollections(s.collectionIDs[0], s.collectionIDs[1]))
+	s.Len(segments, 1)
+	s.Equal(1, stats.Scanned)
+}
+
+func (s *ManagerSuite) TestClearWithPinned() {
+	pinnedID := s.segmentIDs[1]
+	segments, err := s.mgr.GetAndPin([]int64{pinnedID})
+	s.Require().NoError(err)
+
+	// pinned segment is kept when timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = s.mgr.ClearWaitPins(ctx)
+	s.Error(err)
+	s.Contains(err.Error(), fmt.Sprint(pinnedID))
+	s.NotNil(s.mgr.Get(pinnedID))
+	s.Len(s.mgr.GetBy(), 1)
+
+	// clear waits for the segment to be unpinned
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.mgr.Unpin(segments)
+	}()
+	s.NoError(s.mgr.ClearWaitPins(context.Background()))
+	s.True(s.mgr.Empty())
+}
+
+func (s *ManagerSuite) TestClearWaitPinsNotBlocking() {
+	pinnedID := s.segmentIDs[1]
+	segments, err := s.mgr.GetAndPin([]int64{pinnedID})
+	s.Require().NoError(err)
+
+	done := make(chan error)
+	go func() {
+		done <- s.mgr.ClearWaitPins(context.Background())
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// the queries and loads go on while waiting for the pins
+	s.NotEmpty(s.mgr.GetBy())
+	s.mgr.Put(SegmentTypeSealed, s.newSegment(5, 0, 0))
+	s.NotNil(s.mgr.GetSealed(5))
+	more, err := s.mgr.GetAndPin([]int64{pinnedID})
+	s.Require().NoError(err)
+	select {
+	case <-done:
+		s.Fail("clear returned with segments pinned")
+	default:
+	}
+
+	s.mgr.Unpin(more)
+	s.mgr.Unpin(segments)
+	s.NoError(<-done)
+	s.True(s.mgr.Empty())
+}
+
+func (s *ManagerSuite) TestClear() {
+	segments, err := s.mgr.GetAndPin(s.segmentIDs[:1])
+	s.Require().NoError(err)
+	s.mgr.Unpin(segments)
+
+	s.mgr.Clear()
+	s.True(s.mgr.Empty())
+	s.Empty(s.mgr.GetBy())
+}
+
+func (s *ManagerSuite) TestClearWaitsPins() {
+	pinnedID := s.segmentIDs[1]
+	segments, err := s.mgr.GetAndPin([]int64{pinnedID})
+	s.Require().NoError(err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.mgr.Clear()
+	}()
+	select {
+	case <-done:
+		s.Fail("clear returned with segments pinned")
+	case <-time.After(50 * time.Millisecond):
+	}
+	// the pinned segment is still there for the queries holding it
+	s.NotNil(s.mgr.Get(pinnedID))
+
+	s.mgr.Unpin(segments)
+	<-done
+	s.True(s.mgr.Empty())
+}
+
+func (s *ManagerSuite) TestRangeCtx() {
+	interval := rangeCtxCheckInterval
+	rangeCtxCheckInterval = 1
+	defer func() { rangeCtxCheckInterval = interval }()
+
+	var visited []int64
+	err := s.mgr.RangeCtx(context.Background(), func(segment Segment) bool {
+		visited = append(visited, segment.ID())
+		return true
+	})
+	s.NoError(err)
+	s.ElementsMatch(s.segmentIDs, visited)
+
+	// stop by fn
+	visited = visited[:0]
+	err = s.mgr.RangeCtx(context.Background(), func(segment Segment) bool {
+		visited = append(visited, segment.ID())
+		return false
+	})
+	s.NoError(err)
+	s.Len(visited, 1)
+
+	// cancel in the middle of range
+	ctx, cancel := context.WithCancel(context.Background())
+	visited = visited[:0]
+	err = s.mgr.RangeCtx(ctx, func(segment Segment) bool {
+		visited = append(visited, segment.ID())
+		cancel()
+		return true
+	})
+	s.ErrorIs(err, context.Canceled)
+	s.Len(visited, 1)
+
+	// already cancelled
+	visited = visited[:0]
+	err = s.mgr.RangeCtx(ctx, func(segment Segment) bool {
+		visited = append(visited, segment.ID())
+		return true
+	})
+	s.ErrorIs(err, context.Canceled)
+	s.Len(visited, 0)
+}
+
 func (s *ManagerSuite) TestGetAndPin() {
 	// get and pin will ignore L0 segment
 	segments, err := s.mgr.GetAndPin(lo.Filter(s.segmentIDs, func(_ int64, id int) bool { return s.levels[id] == datapb.SegmentLevel_L0 }))
@@ -97,6 +413,204 @@ func (s *ManagerSuite) TestGetAndPin() {
 	s.Equal(len(segments), 0)
 }
 
+func (s *ManagerSuite) TestGetAndPinByRequired() {
+	segments, err := s.mgr.GetAndPinBy(WithID(1000))
+	s.NoError(err)
+	s.Nil(segments)
+
+	segments, err = s.mgr.GetAndPinByRequired(WithID(1000))
+	s.ErrorIs(err, merr.ErrSegmentNotLoaded)
+	s.Nil(segments)
+
+	// the L0 segment is never pinned
+	_, err = s.mgr.GetAndPinByRequired(WithLevel(datapb.SegmentLevel_L0))
+	s.ErrorIs(err, merr.ErrSegmentNotLoaded)
+
+	segments, err = s.mgr.GetAndPinByRequired(WithType(SegmentTypeSealed), WithChannel(s.channels[0]))
+	s.NoError(err)
+	s.Len(segments, 1)
+	s.Equal(s.segmentIDs[0], segments[0].ID())
+	s.mgr.Unpin(segments)
+}
+
+func (s *ManagerSuite) TestGetAndPinByInfo() {
+	pinned, err := s.mgr.GetAndPinByInfo(WithType(SegmentTypeSealed), WithChannel(s.channels[0]))
+	s.NoError(err)
+	s.Len(pinned, 1)
+	s.Equal(s.segments[0], pinned[0].Segment)
+	s.Equal(SegmentInfo{
+		SegmentID:    s.segmentIDs[0],
+		CollectionID: s.collectionIDs[0],
+		PartitionID:  s.partitionIDs[0],
+		Shard:        s.channels[0],
+		Type:         SegmentTypeSealed,
+		Version:      0,
+		NumOfRows:    0,
+	}, pinned[0].Info)
+
+	// the snapshot is kept after the segment reloaded
+	s.mgr.Put(SegmentTypeSealed, s.newSegment(s.segmentIDs[0], 0, 1))
+	s.EqualValues(1, s.mgr.GetSealed(s.segmentIDs[0]).Version())
+	s.EqualValues(0, pinned[0].Info.Version)
+	s.mgr.Unpin([]Segment{pinned[0].Segment})
+
+	pinned, err = s.mgr.GetAndPinByInfo(WithID(1000))
+	s.NoError(err)
+	s.Empty(pinned)
+}
+
+func (s *ManagerSuite) TestQuarantine() {
+	ids := func(segments []Segment) []int64 {
+		return lo.Map(segments, func(segment Segment, _ int) int64 { return segment.ID() })
+	}
+
+	s.mgr.Quarantine(1, "query failed repeatedly")
+	// not loaded
+	s.mgr.Quarantine(1000, "not loaded")
+	s.Equal(map[int64]string{1: "query failed repeatedly"}, s.mgr.QuarantinedSegments())
+
+	// unqueryable but still present
+	segments, err := s.mgr.GetAndPin([]int64{1})
+	s.NoError(err)
+	s.Empty(segments)
+	segments, err = s.mgr.GetAndPinBy(WithType(SegmentTypeSealed))
+	s.NoError(err)
+	s.NotContains(ids(segments), int64(1))
+	s.mgr.Unpin(segments)
+	s.True(s.mgr.Exists(1))
+	s.Len(s.mgr.GetBy(WithID(1)), 1)
+
+	s.mgr.Unquarantine(1)
+	s.Empty(s.mgr.QuarantinedSegments())
+	segments, err = s.mgr.GetAndPin([]int64{1})
+	s.NoError(err)
+	s.Equal([]int64{1}, ids(segments))
+	s.mgr.Unpin(segments)
+
+	// dropped with the released segment
+	s.mgr.Quarantine(3, "corrupted")
+	s.mgr.Remove(3, querypb.DataScope_All)
+	s.Empty(s.mgr.QuarantinedSegments())
+}
+
+func (s *ManagerSuite) TestGetAndPinUnderMemoryPressure() {
+	checker := isUnderMemoryPressure
+	isUnderMemoryPressure = func() bool { return true }
+	defer func() { isUnderMemoryPressure = checker }()
+
+	sealedID, growingID := s.segmentIDs[0], s.segmentIDs[1]
+
+	// non-resident sealed segment shall be rejected
+	_, err := s.mgr.GetAndPin([]int64{sealedID, growingID})
+	s.Error(err)
+	s.True(merr.IsRetryableErr(err))
+
+	// growing segment is always resident
+	segments, err := s.mgr.GetAndPin([]int64{growingID})
+	s.NoError(err)
+	s.Len(segments, 1)
+	s.mgr.Unpin(segments)
+
+	// resident sealed segment pins normally
+	s.mgr.Get(sealedID).(*LocalSegment).loadStatus.Store(string(LoadStatusInMemory))
+	segments, err = s.mgr.GetAndPin([]int64{sealedID, growingID})
+	s.NoError(err)
+	s.Len(segments, 2)
+	s.mgr.Unpin(segments)
+}
+
+func (s *ManagerSuite) TestHandoff() {
+	mgr := NewSegmentManager()
+	genSegment := func(id int64, typ SegmentType, partition int64) *MockSegment {
+		segment := NewMockSegment(s.T())
+		segment.EXPECT().ID().Return(id).Maybe()
+		segment.EXPECT().Collection().Return(100).Maybe()
+		segment.EXPECT().Partition().Return(partition).Maybe()
+		segment.EXPECT().Type().Return(typ).Maybe()
+		segment.EXPECT().Level().Return(datapb.SegmentLevel_L1).Maybe()
+		segment.EXPECT().Version().Return(0).Maybe()
+		segment.EXPECT().Indexes().Return(nil).Maybe()
+		segment.EXPECT().Shard().Return("dml").Maybe()
+		return segment
+	}
+
+	growing := genSegment(201, SegmentTypeGrowing, 10)
+	mgr.Put(SegmentTypeGrowing, growing)
+
+	_, err := mgr.Handoff(202, genSegment(202, SegmentTypeSealed, 10))
+	s.ErrorIs(err, merr.ErrSegmentNotFound)
+	_, err = mgr.Handoff(201, genSegment(201, SegmentTypeSealed, 11))
+	s.ErrorIs(err, merr.ErrParameterInvalid)
+	_, err = mgr.Handoff(201, genSegment(201, SegmentTypeGrowing, 10))
+	s.ErrorIs(err, merr.ErrParameterInvalid)
+
+	// readers shall always see exactly one of the growing and sealed segments
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				s.Len(mgr.GetBy(WithID(201)), 1)
+			}
+		}
+	}()
+
+	sealed := genSegment(201, SegmentTypeSealed, 10)
+	removed, err := mgr.Handoff(201, sealed)
+	close(done)
+	wg.Wait()
+	s.NoError(err)
+	s.Same(growing, removed)
+	s.Nil(mgr.GetGrowing(201))
+	s.Same(sealed, mgr.GetSealed(201))
+}
+
+func (s *ManagerSuite) TestNestedPin() {
+	id := s.segmentIDs[1]
+	outer, err := s.mgr.GetAndPin([]int64{id})
+	s.Require().NoError(err)
+	inner, err := s.mgr.GetAndPinBy(WithID(id))
+	s.Require().NoError(err)
+	s.Equal(2, s.mgr.pinned[s.mgr.Get(id)])
+
+	s.mgr.Unpin(inner)
+	s.True(s.mgr.pinnedSegments().Contain(s.mgr.Get(id)), "still pinned by the outer pin")
+
+	s.mgr.Unpin(outer)
+	s.Empty(s.mgr.pinnedSegments())
+
+	// the read lock acquired without pinning is still released by Unpin
+	s.Require().NoError(outer[0].RLock())
+	s.mgr.Unpin(outer)
+	s.Empty(s.mgr.pinnedSegments())
+
+	// no read lock left, release shall not block
+	s.NoError(s.mgr.ClearWaitPins(context.Background()))
+}
+
+func (s *ManagerSuite) TestUnpinWithNil() {
+	segments, err := s.mgr.GetAndPin(s.segmentIDs[:2])
+	s.Require().NoError(err)
+	s.Len(segments, 2)
+
+	// the holes of the skipped segments are tolerated
+	s.NotPanics(func() {
+		s.mgr.Unpin([]Segment{nil, segments[0], nil, segments[1], nil})
+	})
+	s.Empty(s.mgr.pinnedSegments())
+	s.NotPanics(func() {
+		s.mgr.Unpin([]Segment{nil})
+	})
+
+	// no read lock left, release shall not block
+	s.NoError(s.mgr.ClearWaitPins(context.Background()))
+}
+
 func (s *ManagerSuite) TestRemoveGrowing() {
 	for i, id := range s.segmentIDs {
 		isGrowing := s.types[i] == SegmentTypeGrowing
@@ -122,6 +636,224 @@ func (s *ManagerSuite) TestRemoveAll() {
 	}
 }
 
+func (s *ManagerSuite) TestSegmentCount() {
+	s.Equal(3, s.mgr.SealedCount())
+	s.Equal(1, s.mgr.GrowingCount())
+
+	s.mgr.Put(SegmentTypeSealed, s.newSegment(5, 0, 0))
+	s.mgr.Put(SegmentTypeGrowing, s.newSegment(6, 1, 0))
+	s.mgr.Remove(s.segmentIDs[0], querypb.DataScope_Historical)
+	s.Equal(3, s.mgr.SealedCount())
+	s.Equal(2, s.mgr.GrowingCount())
+
+	s.mgr.RemoveBy(WithType(SegmentTypeGrowing))
+	s.Equal(3, s.mgr.SealedCount())
+	s.Equal(0, s.mgr.GrowingCount())
+}
+
+func (s *ManagerSuite) TestLoadedCollections() {
+	s.ElementsMatch(s.collectionIDs, s.mgr.LoadedCollections())
+
+	// another segment of the same collection is not counted twice
+	s.mgr.Put(SegmentTypeSealed, s.newSegment(5, 0, 0))
+	s.ElementsMatch(s.collectionIDs, s.mgr.LoadedCollections())
+
+	s.mgr.Remove(2, querypb.DataScope_All)
+	s.ElementsMatch([]int64{100, 300, 400}, s.mgr.LoadedCollections())
+
+	s.mgr.Remove(1, querypb.DataScope_All)
+	s.ElementsMatch([]int64{100, 300, 400}, s.mgr.LoadedCollections())
+
+	s.NoError(s.mgr.ClearWaitPins(context.Background()))
+	s.Empty(s.mgr.LoadedCollections())
+}
+
+func (s *ManagerSuite) TestHasCollection() {
+	for _, collectionID := range s.collectionIDs {
+		s.True(s.mgr.HasCollection(collectionID))
+	}
+	s.False(s.mgr.HasCollection(1000))
+
+	// another segment of the same collection
+	s.mgr.Put(SegmentTypeSealed, s.newSegment(5, 0, 0))
+	s.mgr.Remove(1, querypb.DataScope_All)
+	s.True(s.mgr.HasCollection(s.collectionIDs[0]))
+	s.mgr.Remove(5, querypb.DataScope_All)
+	s.False(s.mgr.HasCollection(s.collectionIDs[0]))
+
+	s.mgr.Remove(2, querypb.DataScope_All)
+	s.False(s.mgr.HasCollection(s.collectionIDs[1]))
+	s.True(s.mgr.HasCollection(s.collectionIDs[2]))
+
+	s.NoError(s.mgr.ClearWaitPins(context.Background()))
+	s.False(s.mgr.HasCollection(s.collectionIDs[2]))
+}
+
+func (s *ManagerSuite) TestHierarchy() {
+	s.mgr.Put(SegmentTypeSealed, s.newSegment(6, 0, 0), s.newSegment(5, 0, 0))
+
+	hierarchy := s.mgr.Hierarchy()
+	s.Len(hierarchy, len(s.collectionIDs))
+	for i, collectionID := range s.collectionIDs {
+		s.Len(hierarchy[collectionID], 1)
+		infos := hierarchy[collectionID][s.partitionIDs[i]]
+		if i == 0 {
+			s.Equal([]int64{1, 5, 6}, lo.Map(infos, func(info SegmentInfo, _ int) int64 { return info.SegmentID }))
+		} else {
+			s.Len(infos, 1)
+			s.Equal(s.segmentIDs[i], infos[0].SegmentID)
+		}
+		for _, info := range infos {
+			s.Equal(collectionID, info.CollectionID)
+			s.Equal(s.partitionIDs[i], info.PartitionID)
+			s.Equal(s.channels[i], info.Shard)
+		}
+	}
+	s.Equal(SegmentTypeGrowing, hierarchy[s.collectionIDs[1]][s.partitionIDs[1]][0].Type)
+
+	hierarchy = s.mgr.Hierarchy(WithType(SegmentTypeGrowing))
+	s.Len(hierarchy, 1)
+	s.Len(hierarchy[s.collectionIDs[1]][s.partitionIDs[1]], 1)
+}
+
+func (s *ManagerSuite) TestDumpAudit() {
+	// disabled by default
+	s.Empty(s.mgr.DumpAudit())
+
+	paramtable.Get().Save(paramtable.Get().QueryNodeCfg.SegmentAuditTrailSize.Key, "3")
+	defer paramtable.Get().Reset(paramtable.Get().QueryNodeCfg.SegmentAuditTrailSize.Key)
+	mgr := NewSegmentManager()
+
+	mgr.Put(SegmentTypeSealed, s.newSegment(5, 0, 0))
+	mgr.Put(SegmentTypeSealed, s.newSegment(6, 0, 0))
+	entries := mgr.DumpAudit()
+	s.Len(entries, 2)
+	s.Equal(AuditOpPut, entries[0].Op)
+	s.Equal([]int64{5}, entries[0].SegmentIDs)
+	s.Equal([]int64{6}, entries[1].SegmentIDs)
+
+	s.Equal(1, mgr.UpdateBy(func(Segment) bool { return true }, WithID(5)))
+	mgr.Remove(6, querypb.DataScope_All)
+	s.NoError(mgr.ClearWaitPins(context.Background()))
+
+	// the puts rolled off
+	entries = mgr.DumpAudit()
+	s.Equal([]AuditOp{AuditOpUpdate, AuditOpRemove, AuditOpClear}, lo.Map(entries, func(entry AuditEntry, _ int) AuditOp { return entry.Op }))
+	s.Equal([]int64{5}, entries[0].SegmentIDs)
+	s.Equal([]int64{6}, entries[1].SegmentIDs)
+	s.Equal([]int64{5}, entries[2].SegmentIDs)
+	s.False(entries[2].Time.Before(entries[0].Time))
+}
+
+func (s *ManagerSuite) TestCloneForTest() {
+	s.mgr.Quarantine(1, "corrupted")
+	clone := s.mgr.CloneForTest()
+	s.ElementsMatch(s.segmentIDs, lo.Map(clone.GetBy(), func(segment Segment, _ int) int64 { return segment.ID() }))
+	s.Same(s.mgr.Get(1), clone.Get(1))
+	s.Equal(s.mgr.QuarantinedSegments(), clone.QuarantinedSegments())
+
+	clone.Put(SegmentTypeSealed, s.newSegment(5, 0, 0))
+	clone.Unquarantine(1)
+	s.True(clone.Exists(5))
+	s.False(s.mgr.Exists(5))
+	s.Len(s.mgr.QuarantinedSegments(), 1)
+	s.Equal(len(s.segmentIDs), s.mgr.SealedCount()+s.mgr.GrowingCount())
+
+	s.mgr.Put(SegmentTypeSealed, s.newSegment(6, 0, 0))
+	s.False(clone.Exists(6))
+}
+
+func (s *ManagerSuite) TestGetByPaged() {
+	ids := func(segments []Segment) []int64 {
+		return lo.Map(segments, func(segment Segment, _ int) int64 { return segment.ID() })
+	}
+
+	segments, total := s.mgr.GetByPaged(0, 3)
+	s.Equal(4, total)
+	s.Equal([]int64{1, 2, 3}, ids(segments))
+
+	segments, total = s.mgr.GetByPaged(3, 3)
+	s.Equal(4, total)
+	s.Equal([]int64{4}, ids(segments))
+
+	segments, total = s.mgr.GetByPaged(4, 3)
+	s.Equal(4, total)
+	s.Empty(segments)
+
+	segments, total = s.mgr.GetByPaged(1, 1, WithType(SegmentTypeSealed))
+	s.Equal(lo.Count(s.types, SegmentTypeSealed), total)
+	s.Equal([]int64{3}, ids(segments))
+}
+
+func (s *ManagerSuite) TestGetByFilterCache() {
+	// the cache is set up by the manager on creation
+	paramtable.Get().Save(paramtable.Get().QueryNodeCfg.EnableSegmentFilterCache.Key, "true")
+	defer paramtable.Get().Reset(paramtable.Get().QueryNodeCfg.EnableSegmentFilterCache.Key)
+	s.mgr = NewSegmentManager()
+	for i, id := range s.segmentIDs {
+		s.mgr.Put(s.types[i], s.newSegment(id, i, 0))
+	}
+
+	ids := func(segments []Segment) []int64 {
+		return lo.Map(segments, func(segment Segment, _ int) int64 { return segment.ID() })
+	}
+	getBy := func(filters ...SegmentFilter) []int64 {
+		cached := ids(s.mgr.GetBy(filters...))
+		// GetByWithStats always scans
+		scanned, _ := s.mgr.GetByWithStats(filters...)
+		s.ElementsMatch(ids(scanned), cached)
+		return cached
+	}
+
+	s.ElementsMatch([]int64{1, 3, 4}, getBy(WithType(SegmentTypeSealed)))
+	s.ElementsMatch([]int64{1}, getBy(WithChannel(s.channels[0])))
+	s.ElementsMatch([]int64{1}, getBy(WithChannel(s.channels[0]), WithType(SegmentTypeSealed)))
+	s.ElementsMatch([]int64{2, 3, 4}, getBy(Not(WithPartition(s.partitionIDs[0]))))
+	s.Equal(4, s.mgr.filterCache.len())
+
+	// the filter without signature is not cached
+	getBy(WithSkipEmpty())
+	getBy(WithType(SegmentTypeSealed), WithSkipEmpty())
+	s.Equal(4, s.mgr.filterCache.len())
+
+	// put
+	s.mgr.Put(SegmentTypeSealed, s.newSegment(5, 0, 0))
+	s.Zero(s.mgr.filterCache.len())
+	s.ElementsMatch([]int64{1, 3, 4, 5}, getBy(WithType(SegmentTypeSealed)))
+	s.ElementsMatch([]int64{1, 5}, getBy(WithChannel(s.channels[0])))
+
+	// remove
+	s.mgr.Remove(1, querypb.DataScope_Historical)
+	s.Zero(s.mgr.filterCache.len())
+	s.ElementsMatch([]int64{3, 4, 5}, getBy(WithType(SegmentTypeSealed)))
+
+	s.mgr.RemoveBy(WithID(5))
+	s.Zero(s.mgr.filterCache.len())
+	s.ElementsMatch([]int64{3, 4}, getBy(WithType(SegmentTypeSealed)))
+
+	// the cached result is not affected by the caller
+	segments := s.mgr.GetBy(WithType(SegmentTypeSealed))
+	segments[0] = nil
+	s.NotContains(s.mgr.GetBy(WithType(SegmentTypeSealed)), nil)
+
+	// clear
+	s.NoError(s.mgr.ClearWaitPins(context.Background()))
+	s.Zero(s.mgr.filterCache.len())
+	s.Empty(getBy(WithType(SegmentTypeSealed)))
+}
+
+func (s *ManagerSuite) TestGetByFilterCacheDisabled() {
+	// disabled by default
+	s.Nil(s.mgr.filterCache)
+	s.Len(s.mgr.GetBy(WithType(SegmentTypeSealed)), 3)
+
+	paramtable.Get().Save(paramtable.Get().QueryNodeCfg.EnableSegmentFilterCache.Key, "true")
+	defer paramtable.Get().Reset(paramtable.Get().QueryNodeCfg.EnableSegmentFilterCache.Key)
+	paramtable.Get().Save(paramtable.Get().QueryNodeCfg.SegmentFilterCacheSize.Key, "0")
+	defer paramtable.Get().Reset(paramtable.Get().QueryNodeCfg.SegmentFilterCacheSize.Key)
+	s.Nil(NewSegmentManager().filterCache)
+}
+
 func (s *ManagerSuite) TestRemoveBy() {
 	for _, id := range s.segmentIDs {
 		s.mgr.RemoveBy(WithID(id))
@@ -129,6 +861,80 @@ func (s *ManagerSuite) TestRemoveBy() {
 	}
 }
 
+func (s *ManagerSuite) TestRemoveByAsync() {
+	growing, sealed := s.mgr.RemoveByAsync(WithChannel(s.channels[0]))
+	s.Equal(0, growing)
+	s.Equal(1, sealed)
+	// unqueryable immediately
+	s.Nil(s.mgr.Get(s.segmentIDs[0]))
+	segments, err := s.mgr.GetAndPinBy(WithChannel(s.channels[0]))
+	s.NoError(err)
+	s.Empty(segments)
+
+	// released eventually
+	s.Eventually(func() bool {
+		records := s.mgr.RecentReleases(1)
+		return len(records) == 1 && records[0].SegmentID == s.segmentIDs[0] && records[0].Reason == ReleaseReasonRemoved
+	}, time.Second, 10*time.Millisecond)
+
+	growing, sealed = s.mgr.RemoveByAsync(WithID(1000))
+	s.Equal(0, growing)
+	s.Equal(0, sealed)
+}
+
+func (s *ManagerSuite) TestRemoveByWaitPins() {
+	heldID, releasedID := s.segmentIDs[0], s.segmentIDs[1]
+	held, err := s.mgr.GetAndPin([]int64{heldID})
+	s.Require().NoError(err)
+	released, err := s.mgr.GetAndPin([]int64{releasedID})
+	s.Require().NoError(err)
+
+	// the segment unpinned in time is removed, the one pinned until timeout is kept
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		s.mgr.Unpin(released)
+	}()
+	growing, sealed, pinned := s.mgr.RemoveByWaitPins(100 * time.Millisecond)
+	s.Equal(1, growing)
+	s.Equal(2, sealed)
+	s.Equal([]int64{heldID}, pinned)
+	s.NotNil(s.mgr.Get(heldID))
+	s.Len(s.mgr.GetBy(), 1)
+
+	// woken up by the unpin rather than waiting until timeout
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		s.mgr.Unpin(held)
+	}()
+	start := time.Now()
+	growing, sealed, pinned = s.mgr.RemoveByWaitPins(10*time.Second, WithID(heldID))
+	s.Less(time.Since(start), 5*time.Second)
+	s.Equal(0, growing)
+	s.Equal(1, sealed)
+	s.Empty(pinned)
+	s.True(s.mgr.Empty())
+}
+
+func (s *ManagerSuite) TestRelabel() {
+	// warm up the cached results of the channel filter
+	s.Len(s.mgr.GetBy(WithChannel(s.channels[0])), 1)
+	s.Empty(s.mgr.GetBy(WithChannel("dml-migrated")))
+
+	s.True(s.mgr.Relabel(s.segmentIDs[0], "dml-migrated"))
+	s.Empty(s.mgr.GetBy(WithChannel(s.channels[0])))
+	segments := s.mgr.GetBy(WithChannel("dml-migrated"))
+	s.Len(segments, 1)
+	s.Equal(s.segmentIDs[0], segments[0].ID())
+	s.Equal("dml-migrated", s.mgr.Get(s.segmentIDs[0]).Shard())
+
+	pinned, err := s.mgr.GetAndPinBy(WithChannel("dml-migrated"))
+	s.NoError(err)
+	s.Len(pinned, 1)
+	s.mgr.Unpin(pinned)
+
+	s.False(s.mgr.Relabel(1000, "dml-migrated"))
+}
+
 func (s *ManagerSuite) TestUpdateBy() {
 	action := IncreaseVersion(1)
 
@@ -141,27 +947,1949 @@ func (s *ManagerSuite) TestUpdateBy() {
 	}
 }
 
-func (s *ManagerSuite) TestIncreaseVersion() {
-	action := IncreaseVersion(1)
+func (s *ManagerSuite) TestReadOnly() {
+	readOnlyID := s.segmentIDs[0]
+	s.mgr.SetReadOnly(readOnlyID, true)
+	s.mgr.SetReadOnly(1000, true)
+	s.Equal([]int64{readOnlyID}, s.mgr.ReadOnlySegments())
 
-	segment := NewMockSegment(s.T())
-	segment.EXPECT().ID().Return(100)
-	segment.EXPECT().Type().Return(commonpb.SegmentState_Sealed)
-	segment.EXPECT().Version().Return(1)
+	// the read-only segment isn't version bumped
+	s.Equal(lo.Count(s.types, SegmentTypeSealed)-1, s.mgr.UpdateBy(IncreaseVersion(1), WithType(SegmentTypeSealed)))
+	s.Zero(s.mgr.UpdateByExclusive(IncreaseVersion(2), WithID(readOnlyID)))
+	applied, conflicted := s.mgr.SetVersions(3, WithID(readOnlyID))
+	s.Empty(applied)
+	s.Equal([]int64{readOnlyID}, conflicted)
+	_, removed, updated, err := s.mgr.Reconcile(map[int64]int64{readOnlyID: 4, s.segmentIDs[2]: 4, s.segmentIDs[3]: 4}, SegmentTypeSealed, nil)
+	s.NoError(err)
+	s.Empty(removed)
+	s.ElementsMatch([]int64{s.segmentIDs[2], s.segmentIDs[3]}, updated)
+	s.EqualValues(0, s.mgr.Get(readOnlyID).Version())
 
-	s.False(action(segment), "version already gte version")
-	segment.AssertExpectations(s.T())
+	// but still serves reads
+	segments := s.mgr.GetBy(WithID(readOnlyID))
+	s.Len(segments, 1)
+	s.Equal(readOnlyID, segments[0].ID())
+	s.Len(s.mgr.GetBy(), len(s.segmentIDs))
 
-	segment = NewMockSegment(s.T())
-	segment.EXPECT().ID().Return(100)
-	segment.EXPECT().Type().Return(commonpb.SegmentState_Sealed)
-	segment.EXPECT().Version().Return(0)
-	segment.EXPECT().CASVersion(int64(0), int64(1)).Return(true)
+	s.mgr.SetReadOnly(readOnlyID, false)
+	s.Empty(s.mgr.ReadOnlySegments())
+	s.Equal(1, s.mgr.UpdateBy(IncreaseVersion(1), WithID(readOnlyID)))
+	s.EqualValues(1, s.mgr.Get(readOnlyID).Version())
 
-	s.True(action(segment), "version lt execute CAS")
-	segment.AssertExpectations(s.T())
+	// unmarked once released
+	s.mgr.SetReadOnly(readOnlyID, true)
+	s.mgr.Remove(readOnlyID, querypb.DataScope_All)
+	s.Empty(s.mgr.ReadOnlySegments())
 }
 
-func TestManager(t *testing.T) {
-	suite.Run(t, new(ManagerSuite))
+func (s *ManagerSuite) TestThrashingSegments() {
+	thrashing, evictedOnce, cached := s.segmentIDs[0], s.segmentIDs[1], s.segmentIDs[2]
+	// the first caching isn't a cycle
+	s.mgr.recordReload(cached)
+	for i := 0; i < 3; i++ {
+		s.mgr.recordEviction(thrashing)
+		s.mgr.recordReload(thrashing)
+	}
+	s.mgr.recordEviction(evictedOnce)
+	s.mgr.recordReload(evictedOnce)
+	// evicted but not reloaded yet
+	s.mgr.recordEviction(evictedOnce)
+
+	s.Equal([]int64{thrashing, evictedOnce}, s.mgr.ThrashingSegments(1, time.Minute))
+	s.Equal([]int64{thrashing}, s.mgr.ThrashingSegments(3, time.Minute))
+	s.Empty(s.mgr.ThrashingSegments(4, time.Minute))
+
+	// the cycles out of the window are not counted
+	time.Sleep(10 * time.Millisecond)
+	s.Empty(s.mgr.ThrashingSegments(1, 5*time.Millisecond))
+	s.mgr.recordEviction(thrashing)
+	s.mgr.recordReload(thrashing)
+	s.Equal([]int64{thrashing}, s.mgr.ThrashingSegments(1, 5*time.Millisecond))
+	s.Empty(s.mgr.ThrashingSegments(2, 5*time.Millisecond))
+
+	// dropped once released
+	s.mgr.Remove(thrashing, querypb.DataScope_All)
+	s.Equal([]int64{evictedOnce}, s.mgr.ThrashingSegments(1, time.Minute))
+}
+
+func (s *ManagerSuite) TestUpdateByExclusive() {
+	putDone := make(chan struct{})
+	updated := s.mgr.UpdateByExclusive(func(segment Segment) bool {
+		// the lock-free lookups are safe to call back, run them aside to fail rather than hang on a deadlock
+		lookupDone := make(chan struct{})
+		go func() {
+			defer close(lookupDone)
+			s.True(s.mgr.Exists(segment.ID()))
+			s.Equal(segment, s.mgr.Get(segment.ID()))
+			s.Equal(map[int64]Segment{segment.ID(): segment}, s.mgr.GetMany([]int64{segment.ID()}))
+			s.Equal(segment, s.mgr.GetWithType(segment.ID(), SegmentTypeSealed))
+			s.Equal(segment, s.mgr.GetSealed(segment.ID()))
+			s.Nil(s.mgr.GetGrowing(segment.ID()))
+			s.True(s.mgr.HasCollection(segment.Collection()))
+			s.False(s.mgr.Empty())
+			s.Equal(lo.Count(s.types, SegmentTypeSealed), s.mgr.SealedCount())
+			s.Equal(lo.Count(s.types, SegmentTypeGrowing), s.mgr.GrowingCount())
+		}()
+		select {
+		case <-lookupDone:
+		case <-time.After(5 * time.Second):
+			s.FailNow("the lookups deadlock during the exclusive update")
+		}
+
+		// the mutations wait for the exclusive update
+		if segment.ID() == 1 {
+			go func() {
+				defer close(putDone)
+				s.mgr.Put(SegmentTypeSealed, s.newSegment(5, 0, 0))
+			}()
+			select {
+			case <-putDone:
+				s.Fail("put during the exclusive update")
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+		return IncreaseVersion(1)(segment)
+	}, WithType(SegmentTypeSealed), WithID(1))
+	s.Equal(1, updated)
+	s.EqualValues(1, s.mgr.Get(1).Version())
+
+	<-putDone
+	s.True(s.mgr.Exists(5))
+}
+
+func (s *ManagerSuite) TestWithIndexStale() {
+	genSegment := func(indexIDs ...int64) Segment {
+		segment := NewMockSegment(s.T())
+		segment.EXPECT().Indexes().Return(lo.Map(indexIDs, func(indexID int64, _ int) *IndexedFieldInfo {
+			return &IndexedFieldInfo{IndexInfo: &querypb.FieldIndexInfo{IndexID: indexID}}
+		}))
+		return segment
+	}
+
+	filter := WithIndexStale(1001)
+	s.False(filter.Filter(genSegment(1001)), "index up to date")
+	s.False(filter.Filter(genSegment(1000, 1001)), "index up to date with other field index")
+	s.True(filter.Filter(genSegment(1000)), "index built with outdated index id")
+	s.True(filter.Filter(genSegment()), "index missing")
+
+	_, ok := filter.SegmentType()
+	s.False(ok)
+	_, ok = filter.SegmentIDs()
+	s.False(ok)
+}
+
+func (s *ManagerSuite) TestWithRowsIndexThreshold() {
+	genSegment := func(insertCount int64) Segment {
+		segment := NewMockSegment(s.T())
+		segment.EXPECT().InsertCount().Return(insertCount)
+		return segment
+	}
+
+	below, reach := WithRowsBelowIndexThreshold(1024), WithRowsReachIndexThreshold(1024)
+	for _, c := range []struct {
+		insertCount int64
+		below       bool
+	}{
+		{0, true},
+		{1023, true},
+		{1024, false},
+		{1025, false},
+	} {
+		s.Equal(c.below, below.Filter(genSegment(c.insertCount)), "insert count %d", c.insertCount)
+		s.Equal(!c.below, reach.Filter(genSegment(c.insertCount)), "insert count %d", c.insertCount)
+	}
+}
+
+func (s *ManagerSuite) TestWithResourceAbove() {
+	genSegment := func(memorySize, diskSize uint64) Segment {
+		segment := NewMockSegment(s.T())
+		segment.EXPECT().ResourceUsageEstimate().Return(ResourceUsage{MemorySize: memorySize, DiskSize: diskSize})
+		return segment
+	}
+
+	memory, disk := WithResourceAbove(ResourceDimensionMemory, 1024), WithResourceAbove(ResourceDimensionDisk, 1024)
+	for _, c := range []struct {
+		size  uint64
+		above bool
+	}{
+		{0, false},
+		{1023, false},
+		{1024, false},
+		{1025, true},
+	} {
+		s.Equal(c.above, memory.Filter(genSegment(c.size, 0)), "memory size %d", c.size)
+		s.False(memory.Filter(genSegment(0, c.size)), "disk size %d", c.size)
+		s.Equal(c.above, disk.Filter(genSegment(0, c.size)), "disk size %d", c.size)
+		s.False(disk.Filter(genSegment(c.size, 0)), "memory size %d", c.size)
+	}
+
+	s.True(WithResourceAbove(ResourceDimensionMemory, 0).Filter(genSegment(1, 0)))
+	s.False(WithResourceAbove(ResourceDimensionMemory, 0).Filter(genSegment(0, 1)))
+
+	// the negative threshold is rejected rather than matching every segment
+	s.False(WithResourceAbove(ResourceDimensionMemory, -1).Filter(NewMockSegment(s.T())))
+	s.False(WithResourceAbove(ResourceDimensionDisk, -1).Filter(NewMockSegment(s.T())))
+	s.Empty(s.mgr.GetBy(WithResourceAbove(ResourceDimensionMemory, -1)))
+}
+
+func (s *ManagerSuite) TestWithPendingDeletes() {
+	mgr := NewSegmentManager()
+	checkpoints := map[int64]uint64{1: 0, 2: 100, 3: 200, 4: 300}
+	for id, ts := range checkpoints {
+		segment := newMockSealedSegment(s.T(), id)
+		segment.EXPECT().LastDeltaTimestamp().Return(ts).Maybe()
+		mgr.Put(SegmentTypeSealed, segment)
+	}
+
+	ids := func(segments []Segment) []int64 {
+		return lo.Map(segments, func(segment Segment, _ int) int64 { return segment.ID() })
+	}
+	s.Empty(mgr.GetBy(WithPendingDeletes(0)))
+	s.ElementsMatch([]int64{1}, ids(mgr.GetBy(WithPendingDeletes(100))))
+	s.ElementsMatch([]int64{1, 2, 3}, ids(mgr.GetBy(WithPendingDeletes(201))))
+	s.ElementsMatch([]int64{1, 2, 3, 4}, ids(mgr.GetBy(WithPendingDeletes(301))))
+	s.ElementsMatch([]int64{2}, ids(mgr.GetBy(WithPendingDeletes(200), Not(WithPendingDeletes(100)))))
+}
+
+func (s *ManagerSuite) TestNot() {
+	filter := Not(WithType(SegmentTypeSealed))
+	_, ok := filter.SegmentType()
+	s.False(ok)
+	_, ok = filter.SegmentIDs()
+	s.False(ok)
+
+	segments := s.mgr.GetBy(filter)
+	s.ElementsMatch(
+		lo.Filter(s.segmentIDs, func(_ int64, idx int) bool { return s.types[idx] != SegmentTypeSealed }),
+		lo.Map(segments, func(segment Segment, _ int) int64 { return segment.ID() }),
+	)
+
+	filter = Not(WithID(s.segmentIDs[0]))
+	_, ok = filter.SegmentIDs()
+	s.False(ok)
+
+	segments = s.mgr.GetBy(filter)
+	s.ElementsMatch(s.segmentIDs[1:], lo.Map(segments, func(segment Segment, _ int) int64 { return segment.ID() }))
+
+	// combined with fast path filter
+	segments = s.mgr.GetBy(WithType(SegmentTypeSealed), Not(WithID(s.segmentIDs[0])))
+	s.ElementsMatch(
+		lo.Filter(s.segmentIDs[1:], func(_ int64, idx int) bool { return s.types[idx+1] == SegmentTypeSealed }),
+		lo.Map(segments, func(segment Segment, _ int) int64 { return segment.ID() }),
+	)
+}
+
+func (s *ManagerSuite) TestChannelCheckpoint() {
+	genGrowing := func(id int64, channel string, lastInsertTs, startTs uint64) Segment {
+		segment := NewMockSegment(s.T())
+		segment.EXPECT().ID().Return(id).Maybe()
+		segment.EXPECT().Collection().Return(s.collectionIDs[0]).Maybe()
+		segment.EXPECT().Partition().Return(s.partitionIDs[0]).Maybe()
+		segment.EXPECT().Type().Return(SegmentTypeGrowing).Maybe()
+		segment.EXPECT().Level().Return(datapb.SegmentLevel_Legacy).Maybe()
+		segment.EXPECT().Indexes().Return(nil).Maybe()
+		segment.EXPECT().Shard().Return(channel).Maybe()
+		segment.EXPECT().LastInsertTimestamp().Return(lastInsertTs).Maybe()
+		segment.EXPECT().StartPosition().Return(&msgpb.MsgPosition{Timestamp: startTs}).Maybe()
+		return segment
+	}
+
+	mgr := NewSegmentManager()
+	mgr.Put(SegmentTypeGrowing,
+		genGrowing(101, "dml1", 300, 100),
+		genGrowing(102, "dml1", 200, 100),
+		genGrowing(103, "dml1", 0, 150),
+		genGrowing(104, "dml2", 500, 100),
+	)
+
+	ts, ok := mgr.ChannelCheckpoint("dml1")
+	s.True(ok)
+	s.EqualValues(150, ts)
+
+	ts, ok = mgr.ChannelCheckpoint("dml2")
+	s.True(ok)
+	s.EqualValues(500, ts)
+
+	_, ok = mgr.ChannelCheckpoint("dml3")
+	s.False(ok)
+
+	// sealed segments are not counted
+	_, ok = s.mgr.ChannelCheckpoint(s.channels[0])
+	s.False(ok)
+}
+
+func (s *ManagerSuite) TestMaxTimestamp() {
+	genGrowing := func(id int64, channel string, lastInsertTs uint64) Segment {
+		segment := NewMockSegment(s.T())
+		segment.EXPECT().ID().Return(id).Maybe()
+		segment.EXPECT().Collection().Return(s.collectionIDs[0]).Maybe()
+		segment.EXPECT().Partition().Return(s.partitionIDs[0]).Maybe()
+		segment.EXPECT().Type().Return(SegmentTypeGrowing).Maybe()
+		segment.EXPECT().Level().Return(datapb.SegmentLevel_Legacy).Maybe()
+		segment.EXPECT().Indexes().Return(nil).Maybe()
+		segment.EXPECT().Shard().Return(channel).Maybe()
+		segment.EXPECT().LastInsertTimestamp().Return(lastInsertTs).Maybe()
+		return segment
+	}
+
+	mgr := NewSegmentManager()
+	mgr.Put(SegmentTypeGrowing,
+		genGrowing(101, "dml1", 300),
+		genGrowing(102, "dml1", 200),
+		genGrowing(103, "dml1", 0),
+		genGrowing(104, "dml2", 500),
+	)
+
+	s.EqualValues(300, mgr.MaxTimestamp(WithChannel("dml1")))
+	s.EqualValues(500, mgr.MaxTimestamp(WithChannel("dml2")))
+	s.EqualValues(500, mgr.MaxTimestamp(WithType(SegmentTypeGrowing)))
+	s.EqualValues(0, mgr.MaxTimestamp(WithID(103)))
+	s.EqualValues(0, mgr.MaxTimestamp(WithChannel("dml3")))
+}
+
+func (s *ManagerSuite) TestTopByResource() {
+	mgr := NewSegmentManager()
+	usages := map[int64]ResourceUsage{
+		101: {MemorySize: 100, DiskSize: 400},
+		102: {MemorySize: 300, DiskSize: 100},
+		103: {MemorySize: 200, DiskSize: 300},
+		104: {MemorySize: 400, DiskSize: 200},
+	}
+	for id, usage := range usages {
+		segment := newMockSealedSegment(s.T(), id)
+		segment.EXPECT().ResourceUsageEstimate().Return(usage).Maybe()
+		mgr.Put(SegmentTypeSealed, segment)
+	}
+	ids := func(segments []Segment) []int64 {
+		return lo.Map(segments, func(segment Segment, _ int) int64 { return segment.ID() })
+	}
+
+	s.Equal([]int64{104, 102}, ids(mgr.TopByResource(2, ResourceDimensionMemory)))
+	s.Equal([]int64{101, 103, 104}, ids(mgr.TopByResource(3, ResourceDimensionDisk)))
+	s.Equal([]int64{101, 103, 104, 102}, ids(mgr.TopByResource(10, ResourceDimensionDisk)))
+	s.Empty(mgr.TopByResource(0, ResourceDimensionMemory))
+}
+
+func (s *ManagerSuite) TestDiskCacheEventLog() {
+	paramtable.Get().Save(paramtable.Get().QueryNodeCfg.DiskCapacityLimit.Key, "1")
+	defer paramtable.Get().Reset(paramtable.Get().QueryNodeCfg.DiskCapacityLimit.Key)
+
+	var events []string
+	registerEventRecorder(s.T(), "manager-suite-disk-cache", func(evt eventlog.Evt) {
+		events = append(events, string(evt.Raw()))
+	})
+
+	manager := NewManager()
+	schema := GenTestCollectionSchema("manager-suite", schemapb.DataType_Int64, true)
+	manager.Collection.PutOrRef(s.collectionIDs[0], schema, GenTestIndexMeta(s.collectionIDs[0], schema), &querypb.LoadMetaInfo{
+		LoadType: querypb.LoadType_LoadCollection,
+	})
+
+	// each segment takes the whole disk capacity, loading one evicts the other
+	diskSize := uint64(1024 * 1024 * 1024)
+	first, second := s.newSegment(101, 0, 0), s.newSegment(102, 0, 0)
+	for _, segment := range []Segment{first, second} {
+		segment.(*LocalSegment).resourceUsageCache.Store(&ResourceUsage{DiskSize: diskSize})
+	}
+	manager.Segment.Put(SegmentTypeSealed, first, second)
+
+	events = events[:0]
+	noop := func(Segment) error { return nil }
+	s.NoError(manager.DiskCache.Do(context.Background(), first.ID(), noop))
+	s.NoError(manager.DiskCache.Do(context.Background(), second.ID(), noop))
+
+	s.Equal([]string{
+		fmt.Sprintf("Segment %d[%d] cached, disk size %d", first.ID(), first.Collection(), diskSize),
+		fmt.Sprintf("Segment %d[%d] cached, disk size %d", second.ID(), second.Collection(), diskSize),
+		fmt.Sprintf("Segment %d[%d] evicted, disk size %d", first.ID(), first.Collection(), diskSize),
+	}, events)
+}
+
+func (s *ManagerSuite) TestDiskCacheLoadFailure() {
+	var events []string
+	registerEventRecorder(s.T(), "manager-suite-disk-cache-failure", func(evt eventlog.Evt) {
+		events = append(events, string(evt.Raw()))
+	})
+
+	var loadErr error
+	loadCachedSegmentFields = func(context.Context, *Collection, *LocalSegment, []*datapb.FieldBinlog, int64, ...loadOption) error {
+		return loadErr
+	}
+	defer func() { loadCachedSegmentFields = loadSealedSegmentFields }()
+
+	manager := NewManager()
+	schema := GenTestCollectionSchema("manager-suite", schemapb.DataType_Int64, true)
+	manager.Collection.PutOrRef(s.collectionIDs[0], schema, GenTestIndexMeta(s.collectionIDs[0], schema), &querypb.LoadMetaInfo{
+		LoadType: querypb.LoadType_LoadCollection,
+	})
+	failedCount := func(category LoadFailureCategory) float64 {
+		m := &dto.Metric{}
+		s.NoError(metrics.QueryNodeSegmentCacheLoadFailedCount.WithLabelValues(fmt.Sprint(paramtable.GetNodeID()), string(category)).Write(m))
+		return m.GetCounter().GetValue()
+	}
+
+	cases := []struct {
+		err      error
+		idx      int
+		category LoadFailureCategory
+	}{
+		{merr.WrapErrIoFailed("binlog", errors.New("connection reset")), 0, LoadFailureNetwork},
+		{merr.SegcoreError(2018, "s3 error"), 0, LoadFailureNetwork},
+		{merr.SegcoreError(2024, "data format broken"), 0, LoadFailureDecode},
+		{merr.WrapErrServiceDiskLimitExceeded(2, 1), 0, LoadFailureCapacity},
+		// the collection of the segment is not loaded
+		{nil, 1, LoadFailureCollectionMissing},
+		{errors.New("unknown"), 0, LoadFailureOther},
+	}
+	for i, c := range cases {
+		loadErr = c.err
+		segment := s.newSegment(int64(201+i), c.idx, 0)
+		manager.Segment.Put(SegmentTypeSealed, segment)
+
+		events = events[:0]
+		before := failedCount(c.category)
+		err := manager.DiskCache.Do(context.Background(), segment.ID(), func(Segment) error { return nil })
+		s.ErrorIs(err, cache.ErrNoSuchItem)
+		s.Equal(before+1, failedCount(c.category))
+		s.Require().Len(events, 1)
+		s.Contains(events[0], fmt.Sprintf("Segment %d[%d] failed to cache, %s failure", segment.ID(), segment.Collection(), c.category))
+	}
+}
+
+func (s *ManagerSuite) TestRegisterLazy() {
+	loaded := typeutil.NewConcurrentSet[int64]()
+	var loads atomic.Int32
+	loadCachedSegmentFields = func(_ context.Context, _ *Collection, segment *LocalSegment, _ []*datapb.FieldBinlog, _ int64, _ ...loadOption) error {
+		loaded.Insert(segment.ID())
+		loads.Inc()
+		return nil
+	}
+	defer func() { loadCachedSegmentFields = loadSealedSegmentFields }()
+
+	manager := NewManager()
+	schema := GenTestCollectionSchema("manager-suite", schemapb.DataType_Int64, true)
+	manager.Collection.PutOrRef(s.collectionIDs[0], schema, GenTestIndexMeta(s.collectionIDs[0], schema), &querypb.LoadMetaInfo{
+		LoadType: querypb.LoadType_LoadCollection,
+	})
+	newInfo := func(id int64, collectionID int64) *querypb.SegmentLoadInfo {
+		return &querypb.SegmentLoadInfo{
+			SegmentID:     id,
+			PartitionID:   s.partitionIDs[0],
+			CollectionID:  collectionID,
+			InsertChannel: s.channels[0],
+			Level:         datapb.SegmentLevel_L1,
+		}
+	}
+
+	s.NoError(manager.RegisterLazy(context.Background(), []*querypb.SegmentLoadInfo{newInfo(301, s.collectionIDs[0]), newInfo(302, s.collectionIDs[0])}))
+	// visible without loading the data
+	for _, id := range []int64{301, 302} {
+		segment := manager.Segment.GetSealed(id)
+		s.Require().NotNil(segment)
+		s.Equal(LoadStatusMeta, segment.LoadStatus())
+	}
+	s.Zero(loads.Load())
+
+	// loaded on the first access only
+	for i := 0; i < 2; i++ {
+		pinned, err := manager.Segment.GetAndPin([]int64{301})
+		s.NoError(err)
+		s.Len(pinned, 1)
+		manager.Segment.Unpin(pinned)
+	}
+	s.EqualValues(1, loads.Load())
+	s.True(loaded.Contain(301))
+	s.False(loaded.Contain(302))
+
+	// the collection not loaded
+	err := manager.RegisterLazy(context.Background(), []*querypb.SegmentLoadInfo{newInfo(303, s.collectionIDs[1])})
+	s.ErrorIs(err, merr.ErrCollectionNotLoaded)
+	s.Nil(manager.Segment.GetSealed(303))
+}
+
+func (s *ManagerSuite) TestDiskCacheLoadSingleflight() {
+	entered, unblock := make(chan struct{}, 1), make(chan struct{})
+	loadCachedSegmentFields = func(context.Context, *Collection, *LocalSegment, []*datapb.FieldBinlog, int64, ...loadOption) error {
+		entered <- struct{}{}
+		<-unblock
+		return nil
+	}
+	defer func() { loadCachedSegmentFields = loadSealedSegmentFields }()
+	count := func(role string) float64 {
+		m := &dto.Metric{}
+		s.NoError(metrics.QueryNodeSegmentCacheLoadSingleflightCount.WithLabelValues(fmt.Sprint(paramtable.GetNodeID()), role).Write(m))
+		return m.GetCounter().GetValue()
+	}
+
+	collections := NewCollectionManager()
+	schema := GenTestCollectionSchema("manager-suite", schemapb.DataType_Int64, true)
+	collections.PutOrRef(s.collectionIDs[0], schema, GenTestIndexMeta(s.collectionIDs[0], schema), &querypb.LoadMetaInfo{
+		LoadType: querypb.LoadType_LoadCollection,
+	})
+	segment := s.newSegment(301, 0, 0)
+	s.mgr.Put(SegmentTypeSealed, segment)
+
+	leaders, shared := count(metrics.SingleflightLeaderLabel), count(metrics.SingleflightSharedLabel)
+	sf := singleflight.Group{}
+	const followers = 4
+	wg := sync.WaitGroup{}
+	load := func() {
+		defer wg.Done()
+		loaded, ok := loadCachedSegment(context.Background(), s.mgr, collections, &sf, segment.ID())
+		s.True(ok)
+		s.Equal(segment, loaded)
+	}
+	wg.Add(1)
+	go load()
+	// the followers join the load in flight
+	<-entered
+	for i := 0; i < followers; i++ {
+		wg.Add(1)
+		go load()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(unblock)
+	wg.Wait()
+
+	s.Equal(leaders+1, count(metrics.SingleflightLeaderLabel))
+	s.Equal(shared+followers, count(metrics.SingleflightSharedLabel))
+}
+
+func (s *ManagerSuite) TestDiskCacheSkipPinned() {
+	paramtable.Get().Save(paramtable.Get().QueryNodeCfg.DiskCapacityLimit.Key, "2")
+	defer paramtable.Get().Reset(paramtable.Get().QueryNodeCfg.DiskCapacityLimit.Key)
+
+	var evicted []string
+	registerEventRecorder(s.T(), "manager-suite-disk-cache-pinned", func(evt eventlog.Evt) {
+		if msg := string(evt.Raw()); strings.Contains(msg, "evicted") {
+			evicted = append(evicted, msg)
+		}
+	})
+
+	manager := NewManager()
+	schema := GenTestCollectionSchema("manager-suite", schemapb.DataType_Int64, true)
+	manager.Collection.PutOrRef(s.collectionIDs[0], schema, GenTestIndexMeta(s.collectionIDs[0], schema), &querypb.LoadMetaInfo{
+		LoadType: querypb.LoadType_LoadCollection,
+	})
+
+	// each segment takes half of the disk capacity
+	diskSize := uint64(1024 * 1024 * 1024)
+	first, second, third := s.newSegment(101, 0, 0), s.newSegment(102, 0, 0), s.newSegment(103, 0, 0)
+	for _, segment := range []Segment{first, second, third} {
+		segment.(*LocalSegment).resourceUsageCache.Store(&ResourceUsage{DiskSize: diskSize})
+	}
+	manager.Segment.Put(SegmentTypeSealed, first, second, third)
+
+	noop := func(Segment) error { return nil }
+	s.NoError(manager.DiskCache.Do(context.Background(), first.ID(), noop))
+	s.NoError(manager.DiskCache.Do(context.Background(), second.ID(), noop))
+
+	// the LRU candidate is pinned by a running query, the next one is evicted instead
+	pinned, err := manager.Segment.GetAndPin([]int64{first.ID()})
+	s.Require().NoError(err)
+	s.NoError(manager.DiskCache.Do(context.Background(), third.ID(), noop))
+	manager.Segment.Unpin(pinned)
+
+	s.Equal([]string{
+		fmt.Sprintf("Segment %d[%d] evicted, disk size %d", second.ID(), second.Collection(), diskSize),
+	}, evicted)
+}
+
+func (s *ManagerSuite) TestPauseEviction() {
+	params := paramtable.Get()
+	params.Save(params.QueryNodeCfg.DiskCapacityLimit.Key, "2")
+	defer params.Reset(params.QueryNodeCfg.DiskCapacityLimit.Key)
+	params.Save(params.QueryNodeCfg.DiskCacheOvercommitRatio.Key, "2")
+	defer params.Reset(params.QueryNodeCfg.DiskCacheOvercommitRatio.Key)
+
+	var evicted []int64
+	registerEventRecorder(s.T(), "manager-suite-disk-cache-pause", func(evt eventlog.Evt) {
+		var id, collection int64
+		var size uint64
+		if _, err := fmt.Sscanf(string(evt.Raw()), "Segment %d[%d] evicted, disk size %d", &id, &collection, &size); err == nil {
+			evicted = append(evicted, id)
+		}
+	})
+
+	manager := NewManager()
+	schema := GenTestCollectionSchema("manager-suite", schemapb.DataType_Int64, true)
+	manager.Collection.PutOrRef(s.collectionIDs[0], schema, GenTestIndexMeta(s.collectionIDs[0], schema), &querypb.LoadMetaInfo{
+		LoadType: querypb.LoadType_LoadCollection,
+	})
+
+	// each segment takes half of the disk capacity
+	segments := make([]Segment, 0, 4)
+	for i := int64(0); i < 4; i++ {
+		segment := s.newSegment(101+i, 0, 0)
+		segment.(*LocalSegment).resourceUsageCache.Store(&ResourceUsage{DiskSize: 1024 * 1024 * 1024})
+		segments = append(segments, segment)
+	}
+	manager.Segment.Put(SegmentTypeSealed, segments...)
+
+	noop := func(Segment) error { return nil }
+	manager.PauseEviction()
+	for _, segment := range segments[:3] {
+		s.NoError(manager.DiskCache.Do(context.Background(), segment.ID(), noop))
+	}
+	s.Empty(evicted)
+
+	// evicts down to the capacity once resumed
+	manager.ResumeEviction()
+	s.NoError(manager.DiskCache.Do(context.Background(), segments[3].ID(), noop))
+	s.Equal([]int64{segments[0].ID(), segments[1].ID()}, evicted)
+}
+
+func (s *ManagerSuite) TestUnhealthySegments() {
+	s.Empty(s.mgr.UnhealthySegments(0))
+
+	for i := 0; i < 3; i++ {
+		s.mgr.RecordQueryError(1)
+	}
+	s.mgr.RecordQueryError(3)
+	// not loaded
+	s.mgr.RecordQueryError(1000)
+
+	s.Equal([]int64{1, 3}, s.mgr.UnhealthySegments(0))
+	s.Equal([]int64{1}, s.mgr.UnhealthySegments(1))
+	s.Empty(s.mgr.UnhealthySegments(3))
+
+	// the errors are dropped with the released segment
+	s.mgr.Remove(1, querypb.DataScope_All)
+	s.Equal([]int64{3}, s.mgr.UnhealthySegments(0))
+}
+
+func (s *ManagerSuite) TestReleaseReason() {
+	var events []string
+	registerEventRecorder(s.T(), "manager-suite-release-reason", func(evt eventlog.Evt) {
+		events = append(events, string(evt.Raw()))
+	})
+
+	mgr := NewSegmentManager()
+	reasons := func(n int) []ReleaseReason {
+		return lo.Map(mgr.RecentReleases(n), func(record ReleaseRecord, _ int) ReleaseReason { return record.Reason })
+	}
+	s.Empty(mgr.RecentReleases(10))
+
+	// removed
+	mgr.Put(SegmentTypeSealed, newMockSealedSegment(s.T(), 1001))
+	mgr.RemoveBy(WithID(1001))
+	s.Equal([]ReleaseReason{ReleaseReasonRemoved}, reasons(10))
+
+	// replaced
+	old := newMockSealedSegment(s.T(), 1002)
+	mgr.Put(SegmentTypeSealed, old)
+	newer := NewMockSegment(s.T())
+	newer.EXPECT().ID().Return(1002).Maybe()
+	newer.EXPECT().Collection().Return(100).Maybe()
+	newer.EXPECT().Partition().Return(10).Maybe()
+	newer.EXPECT().Type().Return(SegmentTypeSealed).Maybe()
+	newer.EXPECT().Level().Return(datapb.SegmentLevel_L1).Maybe()
+	newer.EXPECT().Version().Return(1).Maybe()
+	newer.EXPECT().Indexes().Return(nil).Maybe()
+	newer.EXPECT().Shard().Return("dml").Maybe()
+	newer.EXPECT().Release(mock.Anything).Return().Maybe()
+	mgr.Put(SegmentTypeSealed, newer)
+	s.Eventually(func() bool {
+		return len(mgr.RecentReleases(10)) == 2
+	}, time.Second, 10*time.Millisecond)
+	s.Equal([]ReleaseReason{ReleaseReasonReplaced, ReleaseReasonRemoved}, reasons(10))
+
+	// cleared
+	s.NoError(mgr.ClearWaitPins(context.Background()))
+	s.Equal([]ReleaseReason{ReleaseReasonCleared, ReleaseReasonReplaced}, reasons(2))
+	latest := mgr.RecentReleases(1)[0]
+	s.EqualValues(1002, latest.SegmentID)
+	s.EqualValues(100, latest.CollectionID)
+
+	s.Contains(events, "Segment 1001[100] removed")
+	s.Contains(events, "Segment 1002[100] replaced")
+	s.Contains(events, "Segment 1002[100] cleared")
+
+	// the oldest records are overwritten
+	capacity := recentReleasesCapacity
+	recentReleasesCapacity = 2
+	defer func() { recentReleasesCapacity = capacity }()
+	mgr = NewSegmentManager()
+	for i := int64(0); i < 3; i++ {
+		mgr.Put(SegmentTypeSealed, newMockSealedSegment(s.T(), 2000+i))
+		mgr.RemoveBy(WithID(2000 + i))
+	}
+	s.Equal([]int64{2002, 2001}, lo.Map(mgr.RecentReleases(10), func(record ReleaseRecord, _ int) int64 { return record.SegmentID }))
+}
+
+func (s *ManagerSuite) TestReleaseReasonEvicted() {
+	paramtable.Get().Save(paramtable.Get().QueryNodeCfg.DiskCapacityLimit.Key, "1")
+	defer paramtable.Get().Reset(paramtable.Get().QueryNodeCfg.DiskCapacityLimit.Key)
+
+	manager := NewManager()
+	schema := GenTestCollectionSchema("manager-suite", schemapb.DataType_Int64, true)
+	manager.Collection.PutOrRef(s.collectionIDs[0], schema, GenTestIndexMeta(s.collectionIDs[0], schema), &querypb.LoadMetaInfo{
+		LoadType: querypb.LoadType_LoadCollection,
+	})
+
+	diskSize := uint64(1024 * 1024 * 1024)
+	first, second := s.newSegment(101, 0, 0), s.newSegment(102, 0, 0)
+	for _, segment := range []Segment{first, second} {
+		segment.(*LocalSegment).resourceUsageCache.Store(&ResourceUsage{DiskSize: diskSize})
+	}
+	manager.Segment.Put(SegmentTypeSealed, first, second)
+
+	noop := func(Segment) error { return nil }
+	s.NoError(manager.DiskCache.Do(context.Background(), first.ID(), noop))
+	s.NoError(manager.DiskCache.Do(context.Background(), second.ID(), noop))
+
+	releases := manager.Segment.RecentReleases(10)
+	s.Require().Len(releases, 1)
+	s.Equal(first.ID(), releases[0].SegmentID)
+	s.Equal(ReleaseReasonEvicted, releases[0].Reason)
+}
+
+func (s *ManagerSuite) TestOperationTraceID() {
+	var events []string
+	registerEventRecorder(s.T(), "manager-suite-trace", func(evt eventlog.Evt) {
+		events = append(events, string(evt.Raw()))
+	})
+
+	buf := &bytes.Buffer{}
+	zapLogger, _, err := log.InitLoggerWithWriteSyncer(&log.Config{Level: "info", Format: "text"}, zapcore.AddSync(buf))
+	s.Require().NoError(err)
+	traceID := trace.TraceID{0x01, 0x02, 0x03}
+	ctx := context.WithValue(context.Background(), log.CtxLogKey, &log.MLogger{Logger: zapLogger})
+	ctx = log.WithTraceID(ctx, traceID.String())
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{0x01},
+	}))
+
+	mgr := NewSegmentManager()
+	events = events[:0]
+	mgr.PutCtx(ctx, SegmentTypeSealed, newMockSealedSegment(s.T(), 1001))
+	// stale segment is skipped with a warning
+	mgr.PutCtx(ctx, SegmentTypeSealed, newMockSealedSegment(s.T(), 1001))
+	mgr.RemoveCtx(ctx, 1001, querypb.DataScope_All)
+
+	s.Equal([]string{
+		fmt.Sprintf("Segment 1001[100] loaded, traceID %s", traceID),
+		fmt.Sprintf("Segment 1001[100] removed, traceID %s", traceID),
+	}, events)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	s.Len(lines, 2)
+	for _, line := range lines {
+		s.Contains(line, "traceID="+traceID.String())
+	}
+
+	// no trace info without context
+	events = events[:0]
+	mgr.Put(SegmentTypeSealed, newMockSealedSegment(s.T(), 1002))
+	mgr.RemoveBy(WithID(1002))
+	s.Equal([]string{"Segment 1002[100] loaded", "Segment 1002[100] removed"}, events)
+}
+
+func (s *ManagerSuite) TestSetVersions() {
+	mgr := NewSegmentManager()
+	mgr.Put(SegmentTypeSealed,
+		s.newSegment(101, 0, 0),
+		s.newSegment(102, 0, 3),
+		s.newSegment(103, 0, 5),
+		s.newSegment(104, 0, 7),
+	)
+
+	applied, conflicted := mgr.SetVersions(5, WithType(SegmentTypeSealed))
+	s.ElementsMatch([]int64{101, 102}, applied)
+	s.ElementsMatch([]int64{103, 104}, conflicted)
+	for _, id := range []int64{101, 102, 103} {
+		s.EqualValues(5, mgr.Get(id).Version())
+	}
+	s.EqualValues(7, mgr.Get(104).Version())
+
+	applied, conflicted = mgr.SetVersions(6, WithID(104))
+	s.Empty(applied)
+	s.Equal([]int64{104}, conflicted)
+
+	applied, conflicted = mgr.SetVersions(6, WithID(1000))
+	s.Empty(applied)
+	s.Empty(conflicted)
+}
+
+func (s *ManagerSuite) TestVersionLag() {
+	maxLag := func() float64 {
+		m := &dto.Metric{}
+		s.NoError(metrics.QueryNodeSegmentMaxVersionLag.WithLabelValues(fmt.Sprint(paramtable.GetNodeID())).Write(m))
+		return m.GetGauge().GetValue()
+	}
+
+	s.Equal(1, s.mgr.UpdateBy(IncreaseVersion(5), WithID(3)))
+	lags := s.mgr.VersionLag(map[int64]int64{1: 3, 2: 0, 3: 5, 1000: 10})
+	s.Equal(map[int64]int64{1: 3, 2: 0, 3: 0}, lags)
+	s.EqualValues(3, maxLag())
+
+	// all at the latest versions
+	lags = s.mgr.VersionLag(map[int64]int64{3: 4})
+	s.Equal(map[int64]int64{3: 0}, lags)
+	s.EqualValues(0, maxLag())
+}
+
+func (s *ManagerSuite) TestChannelSegmentNumMetric() {
+	nodeID := fmt.Sprint(paramtable.GetNodeID())
+	segmentNum := func(channel string) float64 {
+		m := &dto.Metric{}
+		s.NoError(metrics.QueryNodeChannelSegmentNum.WithLabelValues(nodeID, channel).Write(m))
+		return m.GetGauge().GetValue()
+	}
+
+	s.mgr.Put(SegmentTypeSealed, s.newSegment(5, 0, 0))
+	s.EqualValues(2, segmentNum(s.channels[0]))
+	for _, channel := range s.channels[1:] {
+		s.EqualValues(1, segmentNum(channel))
+	}
+
+	s.mgr.Remove(1, querypb.DataScope_All)
+	s.EqualValues(1, segmentNum(s.channels[0]))
+	s.mgr.Remove(5, querypb.DataScope_All)
+	s.mgr.RemoveBy(WithChannel(s.channels[1]))
+	s.EqualValues(0, segmentNum(s.channels[0]))
+	s.EqualValues(0, segmentNum(s.channels[1]))
+	s.EqualValues(1, segmentNum(s.channels[2]))
+}
+
+func (s *ManagerSuite) TestOrphans() {
+	s.Equal([]int64{2, 4}, s.mgr.Orphans(typeutil.NewSet[int64](1, 3, 1000)))
+	s.Equal(s.segmentIDs, s.mgr.Orphans(typeutil.NewSet[int64]()))
+	s.Empty(s.mgr.Orphans(typeutil.NewSet(s.segmentIDs...)))
+}
+
+func (s *ManagerSuite) TestTryPutGrowingLimit() {
+	paramtable.Get().Save(paramtable.Get().QueryNodeCfg.MaxGrowingSegmentNumPerChannel.Key, "2")
+	defer paramtable.Get().Reset(paramtable.Get().QueryNodeCfg.MaxGrowingSegmentNumPerChannel.Key)
+
+	// segment 2 is growing on channel dml2 already
+	s.NoError(s.mgr.TryPut(context.Background(), SegmentTypeGrowing, s.newSegment(5, 1, 0)))
+	s.NotNil(s.mgr.GetGrowing(5))
+
+	err := s.mgr.TryPut(context.Background(), SegmentTypeGrowing, s.newSegment(6, 1, 0))
+	s.ErrorIs(err, merr.ErrServiceRateLimit)
+	s.Nil(s.mgr.GetGrowing(6))
+
+	// nothing is put if any of the segments exceeds the limit
+	err = s.mgr.TryPut(context.Background(), SegmentTypeGrowing, s.newSegment(5, 1, 1), s.newSegment(6, 1, 0))
+	s.ErrorIs(err, merr.ErrServiceRateLimit)
+	s.EqualValues(0, s.mgr.GetGrowing(5).Version())
+
+	// replacing the existing one doesn't grow the number
+	s.NoError(s.mgr.TryPut(context.Background(), SegmentTypeGrowing, s.newSegment(5, 1, 1)))
+	s.EqualValues(1, s.mgr.GetGrowing(5).Version())
+	// the sealed segments and Put are not limited
+	s.NoError(s.mgr.TryPut(context.Background(), SegmentTypeSealed, s.newSegment(7, 0, 0), s.newSegment(8, 0, 0)))
+	s.mgr.Put(SegmentTypeGrowing, s.newSegment(9, 1, 0))
+	s.NotNil(s.mgr.GetGrowing(9))
+
+	paramtable.Get().Save(paramtable.Get().QueryNodeCfg.MaxGrowingSegmentNumPerChannel.Key, "0")
+	s.NoError(s.mgr.TryPut(context.Background(), SegmentTypeGrowing, s.newSegment(6, 1, 0)))
+}
+
+func (s *ManagerSuite) TestReconcile() {
+	mgr := NewSegmentManager()
+	mgr.Put(SegmentTypeSealed,
+		s.newSegment(101, 0, 1),
+		s.newSegment(102, 0, 1),
+		s.newSegment(103, 0, 3),
+	)
+	mgr.Put(SegmentTypeGrowing, s.newSegment(201, 1, 1))
+
+	loadErr := merr.WrapErrSegmentNotLoaded(105, "mock load failure")
+	var loading []int64
+	loader := func(id int64) (Segment, error) {
+		loading = append(loading, id)
+		if id == 105 {
+			return nil, loadErr
+		}
+		return s.newSegment(id, 0, 2), nil
+	}
+
+	added, removed, updated, err := mgr.Reconcile(map[int64]int64{101: 2, 103: 2, 104: 2, 105: 2}, SegmentTypeSealed, loader)
+	s.ErrorIs(err, merr.ErrSegmentNotLoaded)
+	s.Equal([]int64{104}, added)
+	s.Equal([]int64{102}, removed)
+	s.Equal([]int64{101}, updated)
+	s.ElementsMatch([]int64{104, 105}, loading)
+
+	s.EqualValues(2, mgr.GetSealed(101).Version())
+	s.Nil(mgr.GetSealed(102))
+	s.EqualValues(3, mgr.GetSealed(103).Version())
+	s.EqualValues(2, mgr.GetSealed(104).Version())
+	s.Nil(mgr.GetSealed(105))
+	// the segments of the other type are untouched
+	s.NotNil(mgr.GetGrowing(201))
+
+	// converged
+	loading = loading[:0]
+	added, removed, updated, err = mgr.Reconcile(map[int64]int64{101: 2, 103: 2, 104: 2}, SegmentTypeSealed, loader)
+	s.NoError(err)
+	s.Empty(added)
+	s.Empty(removed)
+	s.Empty(updated)
+	s.Empty(loading)
+
+	// remove all
+	_, removed, _, err = mgr.Reconcile(nil, SegmentTypeSealed, loader)
+	s.NoError(err)
+	s.ElementsMatch([]int64{101, 103, 104}, removed)
+	s.Equal(0, mgr.SealedCount())
+	s.Equal(1, mgr.GrowingCount())
+}
+
+func (s *ManagerSuite) TestIncreaseVersion() {
+	action := IncreaseVersion(1)
+
+	segment := NewMockSegment(s.T())
+	segment.EXPECT().ID().Return(100)
+	segment.EXPECT().Type().Return(commonpb.SegmentState_Sealed)
+	segment.EXPECT().Version().Return(1)
+
+	s.False(action(segment), "version already gte version")
+	segment.AssertExpectations(s.T())
+
+	segment = NewMockSegment(s.T())
+	segment.EXPECT().ID().Return(100)
+	segment.EXPECT().Type().Return(commonpb.SegmentState_Sealed)
+	segment.EXPECT().Version().Return(0)
+	segment.EXPECT().CASVersion(int64(0), int64(1)).Return(true)
+
+	s.True(action(segment), "version lt execute CAS")
+	segment.AssertExpectations(s.T())
+}
+
+func TestManager(t *testing.T) {
+	suite.Run(t, new(ManagerSuite))
+}
+
+// registerEventRecorder registers the logger calling record on the events recorded until the test ends,
+// the loggers can't be unregistered, so it keeps receiving the events of the following tests.
+func registerEventRecorder(t *testing.T, key string, record func(evt eventlog.Evt)) {
+	var mu sync.Mutex
+	done := false
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		done = true
+	})
+	logger := eventlog.NewMockLogger(t)
+	logger.EXPECT().Record(mock.Anything).Run(func(evt eventlog.Evt) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !done {
+			record(evt)
+		}
+	}).Maybe()
+	eventlog.Register(key, logger)
+}
+
+func newMockSealedSegment(t interface {
+	mock.TestingT
+	Cleanup(func())
+}, id int64,
+) *MockSegment {
+	return newMockSealedSegmentOf(t, id, 100)
+}
+
+// newMockSealedSegmentOf returns the mock sealed segment of the collection.
+func newMockSealedSegmentOf(t interface {
+	mock.TestingT
+	Cleanup(func())
+}, id int64, collection int64,
+) *MockSegment {
+	segment := NewMockSegment(t)
+	segment.EXPECT().ID().Return(id).Maybe()
+	segment.EXPECT().Collection().Return(collection).Maybe()
+	segment.EXPECT().Partition().Return(10).Maybe()
+	segment.EXPECT().Type().Return(SegmentTypeSealed).Maybe()
+	segment.EXPECT().Level().Return(datapb.SegmentLevel_L1).Maybe()
+	segment.EXPECT().Version().Return(0).Maybe()
+	segment.EXPECT().Indexes().Return(nil).Maybe()
+	segment.EXPECT().Shard().Return("dml").Maybe()
+	segment.EXPECT().Release(mock.Anything).Return().Maybe()
+	return segment
+}
+
+func TestManagerConcurrentPutAndGet(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager(withMetricsDisabled())
+
+	const workers, segmentsPerWorker = 8, 64
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < segmentsPerWorker; j++ {
+				id := int64(worker*segmentsPerWorker + j)
+				mgr.Put(SegmentTypeSealed, newMockSealedSegment(t, id))
+				assert.NotNil(t, mgr.Get(id))
+				mgr.GetBy(WithType(SegmentTypeSealed))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, mgr.GetBy(), workers*segmentsPerWorker)
+	for id := int64(0); id < workers*segmentsPerWorker; id++ {
+		assert.NotNil(t, mgr.GetSealed(id))
+	}
+}
+
+func TestManagerConcurrentHandoff(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager(withMetricsDisabled())
+
+	// the pairs spread over the collections, the scans lock all their shards
+	const pairs, offset, collections = 64, 1001, 4
+	for id := int64(0); id < pairs; id++ {
+		mgr.Put(SegmentTypeGrowing, newMockSealedSegmentOf(t, id, 100+id%collections))
+	}
+	sealed := make([]Segment, pairs)
+	for i := range sealed {
+		sealed[i] = newMockSealedSegmentOf(t, int64(i+offset), int64(100+i%collections))
+	}
+
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for i, segment := range sealed {
+			_, err := mgr.Handoff(int64(i), segment)
+			assert.NoError(t, err)
+		}
+	}()
+
+	// the scans see either the growing segment or its sealed one, never both or neither
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				loaded := typeutil.NewSet[int64]()
+				mgr.RangeCtx(context.Background(), func(segment Segment) bool {
+					loaded.Insert(segment.ID())
+					return true
+				})
+				for id := int64(0); id < pairs; id++ {
+					assert.NotEqual(t, loaded.Contain(id), loaded.Contain(id+offset), "segment %d", id)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, mgr.GrowingCount())
+	assert.Equal(t, pairs, mgr.SealedCount())
+}
+
+func TestManagerCollectionShards(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager(withMetricsDisabled())
+
+	for id := int64(0); id < 32; id++ {
+		mgr.Put(SegmentTypeSealed, newMockSealedSegmentOf(t, id, 100+id%2))
+	}
+	assert.ElementsMatch(t, []int64{100, 101}, lo.Keys(mgr.shards))
+	assert.Len(t, mgr.GetBy(WithCollections(100)), 16)
+	assert.Len(t, mgr.GetBy(WithID(31)), 1)
+	assert.Empty(t, mgr.GetBy(WithCollections(102)))
+	assert.ElementsMatch(t, []int64{100, 101}, lo.Keys(mgr.CloneForTest().shards))
+
+	// the collection filter scans the shard of the collection only
+	_, stats := mgr.GetByWithStats(WithCollections(100), WithLevel(datapb.SegmentLevel_L1))
+	assert.Equal(t, 16, stats.Scanned)
+
+	// the shard left empty is dropped
+	mgr.RemoveBy(WithCollections(101))
+	assert.ElementsMatch(t, []int64{100}, lo.Keys(mgr.shards))
+	assert.Equal(t, 16, mgr.SealedCount())
+	assert.ElementsMatch(t, []int64{100}, mgr.LoadedCollections())
+	assert.Nil(t, mgr.Get(1))
+
+	// and created again by the next put of the collection
+	mgr.Put(SegmentTypeSealed, newMockSealedSegmentOf(t, 1, 101))
+	assert.True(t, mgr.HasCollection(101))
+	assert.NotNil(t, mgr.Get(1))
+	mgr.Remove(1, querypb.DataScope_All)
+	assert.False(t, mgr.HasCollection(101))
+	assert.NotContains(t, mgr.shards, int64(101))
+}
+
+func TestManagerCollectionShardsIndependent(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager(withMetricsDisabled())
+	pinnable := newMockSealedSegmentOf(t, 2, 200)
+	pinnable.EXPECT().LoadStatus().Return(LoadStatusInMemory).Maybe()
+	pinnable.EXPECT().RLock().Return(nil).Maybe()
+	pinnable.EXPECT().RUnlock().Return().Maybe()
+	mgr.Put(SegmentTypeSealed, newMockSealedSegmentOf(t, 1, 100), pinnable)
+
+	// hold the write lock of collection 100 by an exclusive update
+	held, release := make(chan struct{}), make(chan struct{})
+	updated := make(chan struct{})
+	go func() {
+		defer close(updated)
+		mgr.UpdateByExclusive(func(segment Segment) bool {
+			close(held)
+			<-release
+			return false
+		}, WithCollections(100))
+	}()
+	<-held
+
+	// the operations on collection 200 don't wait for it
+	mgr.Put(SegmentTypeSealed, newMockSealedSegmentOf(t, 3, 200))
+	assert.Len(t, mgr.GetBy(WithCollections(200)), 2)
+	pinned, err := mgr.GetAndPin([]int64{2})
+	assert.NoError(t, err)
+	mgr.Unpin(pinned)
+	mgr.Remove(3, querypb.DataScope_Historical)
+	assert.Len(t, mgr.GetBy(WithCollections(200)), 1)
+
+	// while the put of collection 100 waits for the update
+	put := make(chan struct{})
+	go func() {
+		defer close(put)
+		mgr.Put(SegmentTypeSealed, newMockSealedSegmentOf(t, 4, 100))
+	}()
+	select {
+	case <-put:
+		t.Fatal("put of the locked collection should wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-updated
+	<-put
+	assert.Len(t, mgr.GetBy(WithCollections(100)), 2)
+}
+
+func TestManagerConcurrentPutWhileShardDropped(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager(withMetricsDisabled())
+
+	// the flapping segment keeps dropping and creating the shard of the collection,
+	// the puts racing with the drops retry with the new shard rather than being lost
+	const rounds = 200
+	flapping := newMockSealedSegmentOf(t, 0, 100)
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			mgr.Put(SegmentTypeSealed, flapping)
+			mgr.Remove(0, querypb.DataScope_Historical)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for id := int64(1); id <= rounds; id++ {
+			mgr.Put(SegmentTypeSealed, newMockSealedSegmentOf(t, id, 100))
+			mgr.Remove(id, querypb.DataScope_Historical)
+			mgr.Put(SegmentTypeSealed, newMockSealedSegmentOf(t, id, 100))
+		}
+	}()
+	wg.Wait()
+
+	assert.Len(t, mgr.GetBy(WithCollections(100)), rounds)
+	for id := int64(1); id <= rounds; id++ {
+		assert.NotNil(t, mgr.GetSealed(id), "segment %d", id)
+	}
+	shard, ok := mgr.segmentShards.Get(1)
+	assert.True(t, ok)
+	assert.Same(t, mgr.shards[100], shard)
+}
+
+func TestManagerLockFreeGetUnderMutation(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager(withMetricsDisabled())
+
+	const stable, volatile = 64, 64
+	for id := int64(0); id < stable; id++ {
+		mgr.Put(SegmentTypeSealed, newMockSealedSegment(t, id))
+	}
+	volatiles := make([]Segment, volatile)
+	for i := range volatiles {
+		volatiles[i] = newMockSealedSegment(t, int64(stable+i))
+	}
+
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for round := 0; round < 10; round++ {
+			for _, segment := range volatiles {
+				mgr.Put(SegmentTypeSealed, segment)
+			}
+			for _, segment := range volatiles {
+				mgr.Remove(segment.ID(), querypb.DataScope_All)
+			}
+		}
+		close(done)
+	}()
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for id := int64(0); id < stable+volatile; id++ {
+					segment := mgr.Get(id)
+					if id < stable {
+						assert.True(t, mgr.Exists(id))
+						assert.NotNil(t, segment)
+					}
+					if segment != nil {
+						assert.Equal(t, id, segment.ID())
+					}
+				}
+				assert.GreaterOrEqual(t, mgr.SealedCount(), stable)
+				assert.LessOrEqual(t, mgr.SealedCount(), stable+volatile)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, stable, mgr.SealedCount())
+	for _, segment := range volatiles {
+		assert.False(t, mgr.Exists(segment.ID()))
+		assert.Nil(t, mgr.GetSealed(segment.ID()))
+	}
+}
+
+func TestGetAndPinWithDeadline(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+
+	unlocked := atomic.NewInt32(0)
+	fast := newMockSealedSegment(t, 1)
+	fast.EXPECT().LoadStatus().Return(LoadStatusInMemory).Maybe()
+	fast.EXPECT().RLock().Return(nil).Maybe()
+	fast.EXPECT().RUnlock().Run(func() { unlocked.Inc() }).Return().Maybe()
+
+	// the slow segment can't be pinned until loaded
+	loaded := make(chan struct{})
+	slow := newMockSealedSegment(t, 2)
+	slow.EXPECT().LoadStatus().Return(LoadStatusInMemory).Maybe()
+	slow.EXPECT().RLock().RunAndReturn(func() error {
+		<-loaded
+		return nil
+	}).Maybe()
+	slow.EXPECT().RUnlock().Run(func() { unlocked.Inc() }).Return().Maybe()
+	mgr.Put(SegmentTypeSealed, fast, slow)
+
+	pinned, skipped, err := mgr.GetAndPinWithDeadline([]int64{1, 2}, 20*time.Millisecond, PinTimeoutSkip)
+	assert.NoError(t, err)
+	assert.Equal(t, []Segment{fast}, pinned)
+	assert.Equal(t, []int64{2}, skipped)
+	mgr.Unpin(pinned)
+
+	pinned, skipped, err = mgr.GetAndPinWithDeadline([]int64{1, 2}, 20*time.Millisecond, PinTimeoutError)
+	assert.ErrorIs(t, err, merr.ErrServiceUnavailable)
+	assert.Nil(t, pinned)
+	assert.Nil(t, skipped)
+	assert.Empty(t, mgr.pinnedSegments())
+
+	// the abandoned acquisitions release the read lock once succeeded
+	close(loaded)
+	assert.Eventually(t, func() bool {
+		return unlocked.Load() == 4
+	}, time.Second, 10*time.Millisecond)
+
+	pinned, skipped, err = mgr.GetAndPinWithDeadline([]int64{1, 2}, 20*time.Millisecond, PinTimeoutError)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Segment{fast, slow}, pinned)
+	assert.Empty(t, skipped)
+	mgr.Unpin(pinned)
+}
+
+func TestGetAndPinPreferLoadMode(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+
+	newSegment := func(id int64, typ SegmentType, status LoadStatus) *MockSegment {
+		segment := NewMockSegment(t)
+		segment.EXPECT().ID().Return(id).Maybe()
+		segment.EXPECT().Collection().Return(100).Maybe()
+		segment.EXPECT().Partition().Return(10).Maybe()
+		segment.EXPECT().Type().Return(typ).Maybe()
+		segment.EXPECT().Level().Return(datapb.SegmentLevel_L1).Maybe()
+		segment.EXPECT().Version().Return(0).Maybe()
+		segment.EXPECT().Indexes().Return(nil).Maybe()
+		segment.EXPECT().Shard().Return("dml").Maybe()
+		segment.EXPECT().LoadStatus().Return(status).Maybe()
+		segment.EXPECT().RLock().Return(nil).Maybe()
+		segment.EXPECT().RUnlock().Return().Maybe()
+		return segment
+	}
+	// segment 1 is both growing in memory and sealed mapped,
+	// segment 2 is in the same mode both growing and sealed
+	growing1, sealed1 := newSegment(1, SegmentTypeGrowing, LoadStatusInMemory), newSegment(1, SegmentTypeSealed, LoadStatusMapped)
+	growing2, sealed2 := newSegment(2, SegmentTypeGrowing, LoadStatusInMemory), newSegment(2, SegmentTypeSealed, LoadStatusInMemory)
+	mgr.Put(SegmentTypeGrowing, growing1, growing2)
+	mgr.Put(SegmentTypeSealed, sealed1, sealed2)
+
+	pinned, err := mgr.GetAndPin([]int64{1, 2})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Segment{growing1, sealed1, growing2, sealed2}, pinned)
+	mgr.Unpin(pinned)
+
+	pinned, err = mgr.GetAndPinWithOptions([]int64{1, 2}, []PinOption{PreferLoadMode(LoadStatusMapped)})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Segment{sealed1, growing2, sealed2}, pinned)
+	mgr.Unpin(pinned)
+
+	pinned, err = mgr.GetAndPinWithOptions([]int64{1}, []PinOption{PreferLoadMode(LoadStatusInMemory)})
+	assert.NoError(t, err)
+	assert.Equal(t, []Segment{growing1}, pinned)
+	mgr.Unpin(pinned)
+
+	// no duplicate in the mode
+	pinned, err = mgr.GetAndPinWithOptions([]int64{1}, []PinOption{PreferLoadMode(LoadStatusMeta)})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Segment{growing1, sealed1}, pinned)
+	mgr.Unpin(pinned)
+
+	// the filters still apply with the preference
+	pinned, err = mgr.GetAndPinWithOptions([]int64{1, 2}, []PinOption{PreferLoadMode(LoadStatusMapped)}, WithType(SegmentTypeGrowing))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Segment{growing1, growing2}, pinned)
+	mgr.Unpin(pinned)
+	assert.Empty(t, mgr.pinnedSegments())
+}
+
+func TestGetAndPinMetrics(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+
+	waitLatency := func() *dto.Histogram {
+		m := &dto.Metric{}
+		err := metrics.QueryNodeSegmentPinWaitLatency.WithLabelValues(fmt.Sprint(paramtable.GetNodeID()), SegmentTypeSealed.String()).(prometheus.Histogram).Write(m)
+		assert.NoError(t, err)
+		return m.GetHistogram()
+	}
+	failedCount := func() float64 {
+		m := &dto.Metric{}
+		err := metrics.QueryNodeSegmentPinFailedCount.WithLabelValues(fmt.Sprint(paramtable.GetNodeID()), SegmentTypeSealed.String()).Write(m)
+		assert.NoError(t, err)
+		return m.GetCounter().GetValue()
+	}
+
+	// the read lock is held by a releasing goroutine for a while
+	contended := newMockSealedSegment(t, 1)
+	contended.EXPECT().LoadStatus().Return(LoadStatusInMemory).Maybe()
+	contended.EXPECT().RLock().RunAndReturn(func() error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}).Maybe()
+	contended.EXPECT().RUnlock().Return().Maybe()
+	released := newMockSealedSegment(t, 2)
+	released.EXPECT().LoadStatus().Return(LoadStatusInMemory).Maybe()
+	released.EXPECT().RLock().Return(merr.WrapErrSegmentNotLoaded(2, "segment released")).Maybe()
+	mgr.Put(SegmentTypeSealed, contended, released)
+
+	before, failed := waitLatency(), failedCount()
+	pinned, err := mgr.GetAndPin([]int64{1})
+	assert.NoError(t, err)
+	mgr.Unpin(pinned)
+	after := waitLatency()
+	assert.Equal(t, before.GetSampleCount()+1, after.GetSampleCount())
+	assert.GreaterOrEqual(t, after.GetSampleSum()-before.GetSampleSum(), float64(50))
+	assert.Equal(t, failed, failedCount())
+
+	_, err = mgr.GetAndPinBy(WithID(2))
+	assert.ErrorIs(t, err, merr.ErrSegmentNotLoaded)
+	assert.Equal(t, before.GetSampleCount()+2, waitLatency().GetSampleCount())
+	assert.Equal(t, failed+1, failedCount())
+}
+
+func TestReleaseLatencyMetrics(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+
+	releaseLatency := func() *dto.Histogram {
+		m := &dto.Metric{}
+		err := metrics.QueryNodeSegmentReleaseLatency.WithLabelValues(fmt.Sprint(paramtable.GetNodeID()), SegmentTypeSealed.String(), datapb.SegmentLevel_L1.String()).(prometheus.Histogram).Write(m)
+		assert.NoError(t, err)
+		return m.GetHistogram()
+	}
+
+	slow := NewMockSegment(t)
+	slow.EXPECT().ID().Return(1).Maybe()
+	slow.EXPECT().Collection().Return(100).Maybe()
+	slow.EXPECT().Partition().Return(10).Maybe()
+	slow.EXPECT().Type().Return(SegmentTypeSealed).Maybe()
+	slow.EXPECT().Level().Return(datapb.SegmentLevel_L1).Maybe()
+	slow.EXPECT().Version().Return(0).Maybe()
+	slow.EXPECT().Indexes().Return(nil).Maybe()
+	slow.EXPECT().Shard().Return("dml").Maybe()
+	slow.EXPECT().Release(mock.Anything).Run(func(_ ...releaseOption) {
+		time.Sleep(50 * time.Millisecond)
+	}).Return()
+	mgr.Put(SegmentTypeSealed, slow, newMockSealedSegment(t, 2))
+
+	before := releaseLatency()
+	mgr.RemoveBy(WithType(SegmentTypeSealed))
+	after := releaseLatency()
+	assert.Equal(t, before.GetSampleCount()+2, after.GetSampleCount())
+	assert.GreaterOrEqual(t, after.GetSampleSum()-before.GetSampleSum(), float64(50))
+}
+
+func BenchmarkManagerPutAndGet(b *testing.B) {
+	paramtable.Init()
+
+	// keep the remove logs out of the measurement
+	level := log.GetLevel()
+	log.SetLevel(zapcore.WarnLevel)
+	defer log.SetLevel(level)
+
+	// the segments of a single collection share one lock, which is the baseline of the global lock
+	for _, n := range []int64{1, 16} {
+		b.Run(fmt.Sprintf("collections=%d", n), func(b *testing.B) {
+			segments := make([]Segment, 1024)
+			for i := range segments {
+				segment := newMockSealedSegmentOf(b, int64(i), 100+int64(i)%n)
+				segment.EXPECT().LoadStatus().Return(LoadStatusInMemory).Maybe()
+				segment.EXPECT().RLock().Return(nil).Maybe()
+				segment.EXPECT().RUnlock().Return().Maybe()
+				segments[i] = segment
+			}
+			mgr := NewSegmentManager(withMetricsDisabled())
+			mgr.Put(SegmentTypeSealed, segments...)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					id := int64(i % len(segments))
+					if i%4 == 0 {
+						mgr.Remove(id, querypb.DataScope_Historical)
+						mgr.Put(SegmentTypeSealed, segments[id])
+					} else if pinned, err := mgr.GetAndPin([]int64{id}); err == nil {
+						mgr.Unpin(pinned)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+func TestCollectionSummary(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+
+	newSegment := func(id, collection int64, typ SegmentType, rows int64, indexed bool) *MockSegment {
+		var indexes []*IndexedFieldInfo
+		if indexed {
+			indexes = []*IndexedFieldInfo{{IndexInfo: &querypb.FieldIndexInfo{FieldID: 101, IndexID: 1000, BuildID: id}}}
+		}
+		segment := NewMockSegment(t)
+		segment.EXPECT().ID().Return(id).Maybe()
+		segment.EXPECT().Collection().Return(collection).Maybe()
+		segment.EXPECT().Partition().Return(10).Maybe()
+		segment.EXPECT().Type().Return(typ).Maybe()
+		segment.EXPECT().Level().Return(datapb.SegmentLevel_L1).Maybe()
+		segment.EXPECT().Version().Return(0).Maybe()
+		segment.EXPECT().Indexes().Return(indexes).Maybe()
+		segment.EXPECT().Shard().Return("dml").Maybe()
+		segment.EXPECT().InsertCount().Return(rows).Maybe()
+		return segment
+	}
+	// collection 100 is half indexed, collection 200 is fully indexed, collection 400 has no row yet
+	mgr.Put(SegmentTypeSealed,
+		newSegment(1, 100, SegmentTypeSealed, 1000, true),
+		newSegment(2, 100, SegmentTypeSealed, 500, false),
+		newSegment(4, 200, SegmentTypeSealed, 300, true),
+	)
+	mgr.Put(SegmentTypeGrowing,
+		newSegment(3, 100, SegmentTypeGrowing, 500, false),
+		newSegment(5, 400, SegmentTypeGrowing, 0, false),
+	)
+
+	assert.Equal(t, CollectionSummary{
+		CollectionID:  100,
+		NumSegments:   3,
+		NumSealed:     2,
+		NumGrowing:    1,
+		NumRows:       2000,
+		IndexedRows:   1000,
+		IndexCoverage: 50,
+	}, mgr.CollectionSummary(100))
+
+	summary := mgr.CollectionSummary(200)
+	assert.Equal(t, 1, summary.NumSealed)
+	assert.EqualValues(t, 300, summary.NumRows)
+	assert.Equal(t, float64(100), summary.IndexCoverage)
+
+	summary = mgr.CollectionSummary(400)
+	assert.Equal(t, 1, summary.NumGrowing)
+	assert.Zero(t, summary.IndexCoverage)
+
+	assert.Equal(t, CollectionSummary{CollectionID: 300}, mgr.CollectionSummary(300))
+}
+
+func TestCollectionFreshness(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+
+	newSegment := func(id, collection int64, typ SegmentType, appliedTs uint64) *MockSegment {
+		segment := NewMockSegment(t)
+		segment.EXPECT().ID().Return(id).Maybe()
+		segment.EXPECT().Collection().Return(collection).Maybe()
+		segment.EXPECT().Partition().Return(10).Maybe()
+		segment.EXPECT().Type().Return(typ).Maybe()
+		segment.EXPECT().Level().Return(datapb.SegmentLevel_L1).Maybe()
+		segment.EXPECT().Version().Return(0).Maybe()
+		segment.EXPECT().Indexes().Return(nil).Maybe()
+		segment.EXPECT().Shard().Return("dml").Maybe()
+		segment.EXPECT().MaxAppliedTimestamp().Return(appliedTs).Maybe()
+		return segment
+	}
+	mgr.Put(SegmentTypeSealed,
+		newSegment(1, 100, SegmentTypeSealed, 1000),
+		newSegment(2, 100, SegmentTypeSealed, 3000),
+		newSegment(4, 200, SegmentTypeSealed, 5000),
+	)
+	mgr.Put(SegmentTypeGrowing,
+		newSegment(3, 100, SegmentTypeGrowing, 2000),
+		newSegment(5, 400, SegmentTypeGrowing, 0),
+	)
+
+	assert.EqualValues(t, 3000, mgr.CollectionFreshness(100))
+	assert.EqualValues(t, 5000, mgr.CollectionFreshness(200))
+	// nothing applied yet
+	assert.Zero(t, mgr.CollectionFreshness(400))
+	assert.Zero(t, mgr.CollectionFreshness(300))
+}
+
+func TestGetByIndexBuildID(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+
+	// the index of the segment is built asynchronously after it's put
+	var indexes []*IndexedFieldInfo
+	segment := NewMockSegment(t)
+	segment.EXPECT().ID().Return(1).Maybe()
+	segment.EXPECT().Collection().Return(100).Maybe()
+	segment.EXPECT().Partition().Return(10).Maybe()
+	segment.EXPECT().Type().Return(SegmentTypeSealed).Maybe()
+	segment.EXPECT().Level().Return(datapb.SegmentLevel_L1).Maybe()
+	segment.EXPECT().Version().Return(0).Maybe()
+	segment.EXPECT().Indexes().RunAndReturn(func() []*IndexedFieldInfo { return indexes }).Maybe()
+	segment.EXPECT().Shard().Return("dml").Maybe()
+	segment.EXPECT().Release(mock.Anything).Return().Maybe()
+	mgr.Put(SegmentTypeSealed, segment, newMockSealedSegment(t, 2))
+
+	_, ok := mgr.GetByIndexBuildID(1001)
+	assert.False(t, ok)
+
+	// found once re-indexed after the index build completed
+	indexes = []*IndexedFieldInfo{
+		{IndexInfo: &querypb.FieldIndexInfo{FieldID: 101, IndexID: 1000, BuildID: 1001}},
+		{IndexInfo: &querypb.FieldIndexInfo{FieldID: 102, IndexID: 2000, BuildID: 2001}},
+	}
+	_, ok = mgr.GetByIndexBuildID(1001)
+	assert.False(t, ok)
+	mgr.RefreshIndexes()
+	for _, buildID := range []int64{1001, 2001} {
+		found, ok := mgr.GetByIndexBuildID(buildID)
+		assert.True(t, ok)
+		assert.Equal(t, segment, found)
+	}
+	_, ok = mgr.GetByIndexBuildID(3001)
+	assert.False(t, ok)
+
+	// removed along with the segment
+	mgr.Remove(1, querypb.DataScope_All)
+	_, ok = mgr.GetByIndexBuildID(1001)
+	assert.False(t, ok)
+}
+
+func TestGrowingRowRange(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+
+	growing := NewMockSegment(t)
+	growing.EXPECT().ID().Return(1).Maybe()
+	growing.EXPECT().Collection().Return(100).Maybe()
+	growing.EXPECT().Partition().Return(10).Maybe()
+	growing.EXPECT().Type().Return(SegmentTypeGrowing).Maybe()
+	growing.EXPECT().Level().Return(datapb.SegmentLevel_L1).Maybe()
+	growing.EXPECT().Version().Return(0).Maybe()
+	growing.EXPECT().Indexes().Return(nil).Maybe()
+	growing.EXPECT().Shard().Return("dml").Maybe()
+	growing.EXPECT().FirstInsertTimestamp().Return(100)
+	growing.EXPECT().LastInsertTimestamp().Return(300)
+	growing.EXPECT().InsertCount().Return(20)
+	mgr.Put(SegmentTypeGrowing, growing)
+	mgr.Put(SegmentTypeSealed, newMockSealedSegment(t, 2))
+
+	minTs, maxTs, rows, ok := mgr.GrowingRowRange(1)
+	assert.True(t, ok)
+	assert.EqualValues(t, 100, minTs)
+	assert.EqualValues(t, 300, maxTs)
+	assert.EqualValues(t, 20, rows)
+
+	// sealed
+	_, _, _, ok = mgr.GrowingRowRange(2)
+	assert.False(t, ok)
+	// not loaded
+	_, _, _, ok = mgr.GrowingRowRange(3)
+	assert.False(t, ok)
+}
+
+func TestManagerWaitForDiskBelow(t *testing.T) {
+	evicted := make([]int64, 0)
+	mgr := &Manager{
+		DiskCache: cache.NewCacheBuilder[int64, Segment]().WithLoader(func(ctx context.Context, key int64) (Segment, bool) {
+			return nil, true
+		}).WithLazyScavenger(func(key int64) int64 {
+			return 10
+		}, 100).WithFinalizer(func(key int64, segment Segment) error {
+			evicted = append(evicted, key)
+			return nil
+		}).Build(),
+	}
+	noop := func(Segment) error { return nil }
+	for i := int64(0); i < 5; i++ {
+		assert.NoError(t, mgr.DiskCache.Do(context.Background(), i, noop))
+	}
+
+	assert.NoError(t, mgr.WaitForDiskBelow(context.Background(), 35))
+	assert.Equal(t, []int64{0, 1}, evicted)
+
+	// wait for the pinned segment to be unpinned
+	pinned, unpin := make(chan struct{}), make(chan struct{})
+	go func() {
+		mgr.DiskCache.Do(context.Background(), 4, func(Segment) error {
+			close(pinned)
+			<-unpin
+			return nil
+		})
+	}()
+	<-pinned
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, mgr.WaitForDiskBelow(ctx, 5), context.DeadlineExceeded)
+	assert.Equal(t, []int64{0, 1, 2, 3}, evicted)
+
+	close(unpin)
+	assert.NoError(t, mgr.WaitForDiskBelow(context.Background(), 5))
+	assert.Equal(t, []int64{0, 1, 2, 3, 4}, evicted)
+}
+
+func TestManagerVerifyCacheConsistency(t *testing.T) {
+	paramtable.Init()
+	mgr := &Manager{
+		Collection: NewCollectionManager(),
+		Segment:    NewSegmentManager(),
+		DiskCache: cache.NewCacheBuilder[int64, Segment]().WithLoader(func(ctx context.Context, key int64) (Segment, bool) {
+			return nil, true
+		}).WithCapacity(10).Build(),
+	}
+	schema := GenTestCollectionSchema("verify-cache", schemapb.DataType_Int64, true)
+	schema.Properties = append(schema.Properties, &commonpb.KeyValuePair{Key: common.LazyLoadEnableKey, Value: "true"})
+	mgr.Collection.PutOrRef(100, schema, GenTestIndexMeta(100, schema), &querypb.LoadMetaInfo{
+		LoadType: querypb.LoadType_LoadCollection,
+	})
+
+	genSegment := func(id int64, status LoadStatus) Segment {
+		segment := newMockSealedSegment(t, id)
+		segment.EXPECT().LoadStatus().Return(status).Maybe()
+		return segment
+	}
+	mgr.Segment.Put(SegmentTypeSealed, genSegment(1, LoadStatusMapped), genSegment(2, LoadStatusMeta))
+	noop := func(Segment) error { return nil }
+	assert.NoError(t, mgr.DiskCache.Do(context.Background(), 1, noop))
+	assert.NoError(t, mgr.VerifyCacheConsistency())
+
+	// the removal misses the cache
+	mgr.Segment.Remove(1, querypb.DataScope_All)
+	err := mgr.VerifyCacheConsistency()
+	assert.ErrorIs(t, err, merr.ErrServiceInternal)
+	assert.Contains(t, err.Error(), "stale cached segments [1]")
+
+	// the resident segment is not cached
+	mgr.Segment.Put(SegmentTypeSealed, genSegment(1, LoadStatusMapped), genSegment(3, LoadStatusMapped))
+	mgr.DiskCache.Shrink(0)
+	err = mgr.VerifyCacheConsistency()
+	assert.ErrorIs(t, err, merr.ErrServiceInternal)
+	assert.Contains(t, err.Error(), "untracked resident segments [1 3]")
+}
+
+func TestManagerMetricsDisabled(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager(withMetricsDisabled())
+	nodeID := fmt.Sprint(paramtable.GetNodeID())
+
+	segmentNum := func() float64 {
+		m := &dto.Metric{}
+		err := metrics.QueryNodeNumSegments.WithLabelValues(nodeID, "100", "10", SegmentTypeSealed.String(), "0", datapb.SegmentLevel_L1.String()).Write(m)
+		assert.NoError(t, err)
+		return m.GetGauge().GetValue()
+	}
+	collectionNum := func() float64 {
+		m := &dto.Metric{}
+		err := metrics.QueryNodeNumCollections.WithLabelValues(nodeID).Write(m)
+		assert.NoError(t, err)
+		return m.GetGauge().GetValue()
+	}
+	releaseCount := func() uint64 {
+		m := &dto.Metric{}
+		err := metrics.QueryNodeSegmentReleaseLatency.WithLabelValues(nodeID, SegmentTypeSealed.String(), datapb.SegmentLevel_L1.String()).(prometheus.Histogram).Write(m)
+		assert.NoError(t, err)
+		return m.GetHistogram().GetSampleCount()
+	}
+
+	metrics.QueryNodeNumCollections.WithLabelValues(nodeID).Set(-1)
+	segments, releases := segmentNum(), releaseCount()
+
+	mgr.Put(SegmentTypeSealed, newMockSealedSegment(t, 1), newMockSealedSegment(t, 2))
+	mgr.Remove(1, querypb.DataScope_All)
+	mgr.RemoveBy(WithType(SegmentTypeSealed))
+
+	assert.True(t, mgr.Empty())
+	assert.Equal(t, segments, segmentNum())
+	assert.EqualValues(t, -1, collectionNum())
+	assert.Equal(t, releases, releaseCount())
+}
+
+func BenchmarkManagerGet(b *testing.B) {
+	paramtable.Init()
+	mgr := NewSegmentManager()
+
+	segments := make([]Segment, 1024)
+	for i := range segments {
+		segments[i] = newMockSealedSegment(b, int64(i))
+	}
+	mgr.Put(SegmentTypeSealed, segments...)
+
+	// the baseline locating the shard and reading its maps under the read lock
+	b.Run("locked", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				id := int64(i % len(segments))
+				shard, _ := mgr.segmentShards.Get(id)
+				shard.mu.RLock()
+				_ = shard.sealedSegments[id]
+				shard.mu.RUnlock()
+				i++
+			}
+		})
+	})
+
+	b.Run("lock-free", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				mgr.Get(int64(i % len(segments)))
+				i++
+			}
+		})
+	})
+}
+
+// BenchmarkManagerGetUnderWrites measures the Get throughput while a writer keeps putting and removing segments.
+func BenchmarkManagerGetUnderWrites(b *testing.B) {
+	paramtable.Init()
+	mgr := NewSegmentManager(withMetricsDisabled())
+
+	const stable, volatile = 1024, 64
+	segments := make([]Segment, stable)
+	for i := range segments {
+		segments[i] = newMockSealedSegment(b, int64(i))
+	}
+	mgr.Put(SegmentTypeSealed, segments...)
+	volatiles := make([]Segment, volatile)
+	for i := range volatiles {
+		volatiles[i] = newMockSealedSegment(b, int64(stable+i))
+	}
+
+	run := func(b *testing.B, get func(id int64)) {
+		done := make(chan struct{})
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				segment := volatiles[i%volatile]
+				mgr.Put(SegmentTypeSealed, segment)
+				mgr.Remove(segment.ID(), querypb.DataScope_Historical)
+			}
+		}()
+
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				get(int64(i % stable))
+				i++
+			}
+		})
+		b.StopTimer()
+		close(done)
+		wg.Wait()
+	}
+
+	// the baseline reading the maps under the read lock, which waits for the writer of the same collection
+	b.Run("locked", func(b *testing.B) {
+		run(b, func(id int64) {
+			shard, _ := mgr.segmentShards.Get(id)
+			shard.mu.RLock()
+			_ = shard.sealedSegments[id]
+			shard.mu.RUnlock()
+		})
+	})
+
+	b.Run("lock-free", func(b *testing.B) {
+		run(b, func(id int64) {
+			mgr.Get(id)
+		})
+	})
+}
+
+func TestManagerLookupsUnderMutation(t *testing.T) {
+	paramtable.Init()
+	mgr := NewSegmentManager(withMetricsDisabled())
+
+	indexed := NewMockSegment(t)
+	indexed.EXPECT().ID().Return(1).Maybe()
+	indexed.EXPECT().Collection().Return(100).Maybe()
+	indexed.EXPECT().Partition().Return(10).Maybe()
+	indexed.EXPECT().Type().Return(SegmentTypeSealed).Maybe()
+	indexed.EXPECT().Level().Return(datapb.SegmentLevel_L1).Maybe()
+	indexed.EXPECT().Version().Return(0).Maybe()
+	indexed.EXPECT().Shard().Return("dml").Maybe()
+	indexed.EXPECT().Indexes().Return([]*IndexedFieldInfo{
+		{IndexInfo: &querypb.FieldIndexInfo{FieldID: 101, IndexID: 1000, BuildID: 1001}},
+	}).Maybe()
+	indexed.EXPECT().Release(mock.Anything).Return().Maybe()
+
+	// the lookups follow the puts without refreshing
+	mgr.Put(SegmentTypeSealed, indexed)
+	mgr.Put(SegmentTypeGrowing, newMockSealedSegmentOf(t, 1, 100), newMockSealedSegmentOf(t, 2, 200))
+	found, ok := mgr.GetByIndexBuildID(1001)
+	assert.True(t, ok)
+	assert.Equal(t, indexed, found)
+	assert.ElementsMatch(t, []int64{100, 200}, mgr.LoadedCollections())
+	assert.Len(t, mgr.GetBy(WithCollections(100)), 2)
+
+	// the sealed segment of the same ID keeps the collection loaded
+	mgr.Remove(1, querypb.DataScope_Streaming)
+	assert.True(t, mgr.HasCollection(100))
+	assert.Len(t, mgr.GetBy(WithCollections(100)), 1)
+
+	mgr.Remove(1, querypb.DataScope_Historical)
+	assert.False(t, mgr.HasCollection(100))
+	assert.Empty(t, mgr.GetBy(WithCollections(100)))
+	assert.ElementsMatch(t, []int64{200}, mgr.LoadedCollections())
+	_, ok = mgr.GetByIndexBuildID(1001)
+	assert.False(t, ok)
+
+	// the handoff moves the segment between the types in both the snapshot and the lookups
+	_, err := mgr.Handoff(2, newMockSealedSegmentOf(t, 3, 200))
+	assert.NoError(t, err)
+	assert.Nil(t, mgr.GetGrowing(2))
+	assert.NotNil(t, mgr.GetSealed(3))
+	assert.ElementsMatch(t, []int64{3}, lo.Map(mgr.GetBy(WithCollections(200)), func(segment Segment, _ int) int64 {
+		return segment.ID()
+	}))
+}
+
+func BenchmarkManagerMultiCollection(b *testing.B) {
+	paramtable.Init()
+
+	// keep the remove logs out of the measurement
+	level := log.GetLevel()
+	log.SetLevel(zapcore.WarnLevel)
+	defer log.SetLevel(level)
+
+	// the same load on a single collection serializes on its shard, which is the baseline
+	const segmentNum = 1024
+	for _, collections := range []int64{1, 16} {
+		b.Run(fmt.Sprintf("collections=%d", collections), func(b *testing.B) {
+			mgr := NewSegmentManager(withMetricsDisabled())
+			segments := make([]Segment, 0, segmentNum)
+			for i := int64(0); i < segmentNum; i++ {
+				segments = append(segments, newMockSealedSegmentOf(b, i, 100+i%collections))
+			}
+			mgr.Put(SegmentTypeSealed, segments...)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					id := int64(i % segmentNum)
+					if i%8 == 0 {
+						mgr.Remove(id, querypb.DataScope_Historical)
+						mgr.Put(SegmentTypeSealed, segments[id])
+					} else {
+						mgr.GetBy(WithCollections(100+id%collections), WithType(SegmentTypeSealed))
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+func TestFilterResultCacheLRU(t *testing.T) {
+	segments := []Segment{NewMockSegment(t), NewMockSegment(t), NewMockSegment(t)}
+	cache := newFilterResultCache(2)
+
+	cache.put("a", segments[:1])
+	cache.put("b", segments[:2])
+	// touch a, b becomes the least recently used
+	cached, ok := cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, segments[:1], cached)
+
+	cache.put("c", segments)
+	assert.Equal(t, 2, cache.len())
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	cached, ok = cache.get("c")
+	assert.True(t, ok)
+	assert.Equal(t, segments, cached)
+
+	// the entry is replaced in place
+	cache.put("a", segments[1:])
+	assert.Equal(t, 2, cache.len())
+	cached, _ = cache.get("a")
+	assert.Equal(t, segments[1:], cached)
+
+	cache.clear()
+	assert.Equal(t, 0, cache.len())
+	_, ok = cache.get("c")
+	assert.False(t, ok)
+
+	// the segments found before the clear are not cached
+	generation := cache.current()
+	cache.clear()
+	cache.putIfCurrent(generation, "a", segments)
+	assert.Equal(t, 0, cache.len())
+	cache.putIfCurrent(cache.current(), "a", segments)
+	assert.Equal(t, 1, cache.len())
 }
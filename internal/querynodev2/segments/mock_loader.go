@@ -14,6 +14,8 @@ import (
 	pkoracle "github.com/milvus-io/milvus/internal/querynodev2/pkoracle"
 
 	querypb "github.com/milvus-io/milvus/internal/proto/querypb"
+
+	time "time"
 )
 
 // MockLoader is an autogenerated mock type for the Loader type
@@ -29,6 +31,48 @@ func (_m *MockLoader) EXPECT() *MockLoader_Expecter {
 	return &MockLoader_Expecter{mock: &_m.Mock}
 }
 
+// EstimateLoadDuration provides a mock function with given fields: segmentID
+func (_m *MockLoader) EstimateLoadDuration(segmentID int64) time.Duration {
+	ret := _m.Called(segmentID)
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func(int64) time.Duration); ok {
+		r0 = rf(segmentID)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	return r0
+}
+
+// MockLoader_EstimateLoadDuration_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EstimateLoadDuration'
+type MockLoader_EstimateLoadDuration_Call struct {
+	*mock.Call
+}
+
+// EstimateLoadDuration is a helper method to define mock.On call
+//   - segmentID int64
+func (_e *MockLoader_Expecter) EstimateLoadDuration(segmentID interface{}) *MockLoader_EstimateLoadDuration_Call {
+	return &MockLoader_EstimateLoadDuration_Call{Call: _e.mock.On("EstimateLoadDuration", segmentID)}
+}
+
+func (_c *MockLoader_EstimateLoadDuration_Call) Run(run func(segmentID int64)) *MockLoader_EstimateLoadDuration_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockLoader_EstimateLoadDuration_Call) Return(_a0 time.Duration) *MockLoader_EstimateLoadDuration_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockLoader_EstimateLoadDuration_Call) RunAndReturn(run func(int64) time.Duration) *MockLoader_EstimateLoadDuration_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Load provides a mock function with given fields: ctx, collectionID, segmentType, version, segments
 func (_m *MockLoader) Load(ctx context.Context, collectionID int64, segmentType commonpb.SegmentState, version int64, segments ...*querypb.SegmentLoadInfo) ([]Segment, error) {
 	_va := make([]interface{}, len(segments))
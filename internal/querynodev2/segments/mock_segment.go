@@ -203,6 +203,47 @@ func (_c *MockSegment_ExistIndex_Call) RunAndReturn(run func(int64) bool) *MockS
 	return _c
 }
 
+// FirstInsertTimestamp provides a mock function with given fields:
+func (_m *MockSegment) FirstInsertTimestamp() uint64 {
+	ret := _m.Called()
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func() uint64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	return r0
+}
+
+// MockSegment_FirstInsertTimestamp_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FirstInsertTimestamp'
+type MockSegment_FirstInsertTimestamp_Call struct {
+	*mock.Call
+}
+
+// FirstInsertTimestamp is a helper method to define mock.On call
+func (_e *MockSegment_Expecter) FirstInsertTimestamp() *MockSegment_FirstInsertTimestamp_Call {
+	return &MockSegment_FirstInsertTimestamp_Call{Call: _e.mock.On("FirstInsertTimestamp")}
+}
+
+func (_c *MockSegment_FirstInsertTimestamp_Call) Run(run func()) *MockSegment_FirstInsertTimestamp_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSegment_FirstInsertTimestamp_Call) Return(_a0 uint64) *MockSegment_FirstInsertTimestamp_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegment_FirstInsertTimestamp_Call) RunAndReturn(run func() uint64) *MockSegment_FirstInsertTimestamp_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetIndex provides a mock function with given fields: fieldID
 func (_m *MockSegment) GetIndex(fieldID int64) *IndexedFieldInfo {
 	ret := _m.Called(fieldID)
@@ -500,6 +541,47 @@ func (_c *MockSegment_LastDeltaTimestamp_Call) RunAndReturn(run func() uint64) *
 	return _c
 }
 
+// LastInsertTimestamp provides a mock function with given fields:
+func (_m *MockSegment) LastInsertTimestamp() uint64 {
+	ret := _m.Called()
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func() uint64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	return r0
+}
+
+// MockSegment_LastInsertTimestamp_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LastInsertTimestamp'
+type MockSegment_LastInsertTimestamp_Call struct {
+	*mock.Call
+}
+
+// LastInsertTimestamp is a helper method to define mock.On call
+func (_e *MockSegment_Expecter) LastInsertTimestamp() *MockSegment_LastInsertTimestamp_Call {
+	return &MockSegment_LastInsertTimestamp_Call{Call: _e.mock.On("LastInsertTimestamp")}
+}
+
+func (_c *MockSegment_LastInsertTimestamp_Call) Run(run func()) *MockSegment_LastInsertTimestamp_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSegment_LastInsertTimestamp_Call) Return(_a0 uint64) *MockSegment_LastInsertTimestamp_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegment_LastInsertTimestamp_Call) RunAndReturn(run func() uint64) *MockSegment_LastInsertTimestamp_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Level provides a mock function with given fields:
 func (_m *MockSegment) Level() datapb.SegmentLevel {
 	ret := _m.Called()
@@ -668,6 +750,47 @@ func (_c *MockSegment_LoadStatus_Call) RunAndReturn(run func() LoadStatus) *Mock
 	return _c
 }
 
+// MaxAppliedTimestamp provides a mock function with given fields:
+func (_m *MockSegment) MaxAppliedTimestamp() uint64 {
+	ret := _m.Called()
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func() uint64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	return r0
+}
+
+// MockSegment_MaxAppliedTimestamp_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MaxAppliedTimestamp'
+type MockSegment_MaxAppliedTimestamp_Call struct {
+	*mock.Call
+}
+
+// MaxAppliedTimestamp is a helper method to define mock.On call
+func (_e *MockSegment_Expecter) MaxAppliedTimestamp() *MockSegment_MaxAppliedTimestamp_Call {
+	return &MockSegment_MaxAppliedTimestamp_Call{Call: _e.mock.On("MaxAppliedTimestamp")}
+}
+
+func (_c *MockSegment_MaxAppliedTimestamp_Call) Run(run func()) *MockSegment_MaxAppliedTimestamp_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSegment_MaxAppliedTimestamp_Call) Return(_a0 uint64) *MockSegment_MaxAppliedTimestamp_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegment_MaxAppliedTimestamp_Call) RunAndReturn(run func() uint64) *MockSegment_MaxAppliedTimestamp_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // MayPkExist provides a mock function with given fields: pk
 func (_m *MockSegment) MayPkExist(pk storage.PrimaryKey) bool {
 	ret := _m.Called(pk)
@@ -1103,6 +1226,39 @@ func (_c *MockSegment_Search_Call) RunAndReturn(run func(context.Context, *Searc
 	return _c
 }
 
+// SetShard provides a mock function with given fields: channel
+func (_m *MockSegment) SetShard(channel string) {
+	_m.Called(channel)
+}
+
+// MockSegment_SetShard_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetShard'
+type MockSegment_SetShard_Call struct {
+	*mock.Call
+}
+
+// SetShard is a helper method to define mock.On call
+//   - channel string
+func (_e *MockSegment_Expecter) SetShard(channel interface{}) *MockSegment_SetShard_Call {
+	return &MockSegment_SetShard_Call{Call: _e.mock.On("SetShard", channel)}
+}
+
+func (_c *MockSegment_SetShard_Call) Run(run func(channel string)) *MockSegment_SetShard_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSegment_SetShard_Call) Return() *MockSegment_SetShard_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockSegment_SetShard_Call) RunAndReturn(run func(string)) *MockSegment_SetShard_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Shard provides a mock function with given fields:
 func (_m *MockSegment) Shard() string {
 	ret := _m.Called()
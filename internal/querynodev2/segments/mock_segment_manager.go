@@ -3,10 +3,16 @@
 package segments
 
 import (
+	context "context"
+
 	commonpb "github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	mock "github.com/stretchr/testify/mock"
 
 	querypb "github.com/milvus-io/milvus/internal/proto/querypb"
+
+	time "time"
+
+	typeutil "github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
 // MockSegmentManager is an autogenerated mock type for the SegmentManager type
@@ -22,6 +28,58 @@ func (_m *MockSegmentManager) EXPECT() *MockSegmentManager_Expecter {
 	return &MockSegmentManager_Expecter{mock: &_m.Mock}
 }
 
+// ChannelCheckpoint provides a mock function with given fields: channel
+func (_m *MockSegmentManager) ChannelCheckpoint(channel string) (uint64, bool) {
+	ret := _m.Called(channel)
+
+	var r0 uint64
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(string) (uint64, bool)); ok {
+		return rf(channel)
+	}
+	if rf, ok := ret.Get(0).(func(string) uint64); ok {
+		r0 = rf(channel)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(channel)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_ChannelCheckpoint_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ChannelCheckpoint'
+type MockSegmentManager_ChannelCheckpoint_Call struct {
+	*mock.Call
+}
+
+// ChannelCheckpoint is a helper method to define mock.On call
+//   - channel string
+func (_e *MockSegmentManager_Expecter) ChannelCheckpoint(channel interface{}) *MockSegmentManager_ChannelCheckpoint_Call {
+	return &MockSegmentManager_ChannelCheckpoint_Call{Call: _e.mock.On("ChannelCheckpoint", channel)}
+}
+
+func (_c *MockSegmentManager_ChannelCheckpoint_Call) Run(run func(channel string)) *MockSegmentManager_ChannelCheckpoint_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_ChannelCheckpoint_Call) Return(_a0 uint64, _a1 bool) *MockSegmentManager_ChannelCheckpoint_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_ChannelCheckpoint_Call) RunAndReturn(run func(string) (uint64, bool)) *MockSegmentManager_ChannelCheckpoint_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Clear provides a mock function with given fields:
 func (_m *MockSegmentManager) Clear() {
 	_m.Called()
@@ -54,6 +112,175 @@ func (_c *MockSegmentManager_Clear_Call) RunAndReturn(run func()) *MockSegmentMa
 	return _c
 }
 
+// ClearWaitPins provides a mock function with given fields: ctx
+func (_m *MockSegmentManager) ClearWaitPins(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockSegmentManager_ClearWaitPins_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClearWaitPins'
+type MockSegmentManager_ClearWaitPins_Call struct {
+	*mock.Call
+}
+
+// ClearWaitPins is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockSegmentManager_Expecter) ClearWaitPins(ctx interface{}) *MockSegmentManager_ClearWaitPins_Call {
+	return &MockSegmentManager_ClearWaitPins_Call{Call: _e.mock.On("ClearWaitPins", ctx)}
+}
+
+func (_c *MockSegmentManager_ClearWaitPins_Call) Run(run func(ctx context.Context)) *MockSegmentManager_ClearWaitPins_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_ClearWaitPins_Call) Return(_a0 error) *MockSegmentManager_ClearWaitPins_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_ClearWaitPins_Call) RunAndReturn(run func(context.Context) error) *MockSegmentManager_ClearWaitPins_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CollectionFreshness provides a mock function with given fields: collectionID
+func (_m *MockSegmentManager) CollectionFreshness(collectionID int64) uint64 {
+	ret := _m.Called(collectionID)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(int64) uint64); ok {
+		r0 = rf(collectionID)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	return r0
+}
+
+// MockSegmentManager_CollectionFreshness_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CollectionFreshness'
+type MockSegmentManager_CollectionFreshness_Call struct {
+	*mock.Call
+}
+
+// CollectionFreshness is a helper method to define mock.On call
+//   - collectionID int64
+func (_e *MockSegmentManager_Expecter) CollectionFreshness(collectionID interface{}) *MockSegmentManager_CollectionFreshness_Call {
+	return &MockSegmentManager_CollectionFreshness_Call{Call: _e.mock.On("CollectionFreshness", collectionID)}
+}
+
+func (_c *MockSegmentManager_CollectionFreshness_Call) Run(run func(collectionID int64)) *MockSegmentManager_CollectionFreshness_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_CollectionFreshness_Call) Return(_a0 uint64) *MockSegmentManager_CollectionFreshness_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_CollectionFreshness_Call) RunAndReturn(run func(int64) uint64) *MockSegmentManager_CollectionFreshness_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CollectionSummary provides a mock function with given fields: collectionID
+func (_m *MockSegmentManager) CollectionSummary(collectionID int64) CollectionSummary {
+	ret := _m.Called(collectionID)
+
+	var r0 CollectionSummary
+	if rf, ok := ret.Get(0).(func(int64) CollectionSummary); ok {
+		r0 = rf(collectionID)
+	} else {
+		r0 = ret.Get(0).(CollectionSummary)
+	}
+
+	return r0
+}
+
+// MockSegmentManager_CollectionSummary_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CollectionSummary'
+type MockSegmentManager_CollectionSummary_Call struct {
+	*mock.Call
+}
+
+// CollectionSummary is a helper method to define mock.On call
+//   - collectionID int64
+func (_e *MockSegmentManager_Expecter) CollectionSummary(collectionID interface{}) *MockSegmentManager_CollectionSummary_Call {
+	return &MockSegmentManager_CollectionSummary_Call{Call: _e.mock.On("CollectionSummary", collectionID)}
+}
+
+func (_c *MockSegmentManager_CollectionSummary_Call) Run(run func(collectionID int64)) *MockSegmentManager_CollectionSummary_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_CollectionSummary_Call) Return(_a0 CollectionSummary) *MockSegmentManager_CollectionSummary_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_CollectionSummary_Call) RunAndReturn(run func(int64) CollectionSummary) *MockSegmentManager_CollectionSummary_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DumpAudit provides a mock function with given fields:
+func (_m *MockSegmentManager) DumpAudit() []AuditEntry {
+	ret := _m.Called()
+
+	var r0 []AuditEntry
+	if rf, ok := ret.Get(0).(func() []AuditEntry); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]AuditEntry)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_DumpAudit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DumpAudit'
+type MockSegmentManager_DumpAudit_Call struct {
+	*mock.Call
+}
+
+// DumpAudit is a helper method to define mock.On call
+func (_e *MockSegmentManager_Expecter) DumpAudit() *MockSegmentManager_DumpAudit_Call {
+	return &MockSegmentManager_DumpAudit_Call{Call: _e.mock.On("DumpAudit")}
+}
+
+func (_c *MockSegmentManager_DumpAudit_Call) Run(run func()) *MockSegmentManager_DumpAudit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_DumpAudit_Call) Return(_a0 []AuditEntry) *MockSegmentManager_DumpAudit_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_DumpAudit_Call) RunAndReturn(run func() []AuditEntry) *MockSegmentManager_DumpAudit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Empty provides a mock function with given fields:
 func (_m *MockSegmentManager) Empty() bool {
 	ret := _m.Called()
@@ -95,6 +322,48 @@ func (_c *MockSegmentManager_Empty_Call) RunAndReturn(run func() bool) *MockSegm
 	return _c
 }
 
+// Exists provides a mock function with given fields: segmentID
+func (_m *MockSegmentManager) Exists(segmentID int64) bool {
+	ret := _m.Called(segmentID)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(int64) bool); ok {
+		r0 = rf(segmentID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MockSegmentManager_Exists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Exists'
+type MockSegmentManager_Exists_Call struct {
+	*mock.Call
+}
+
+// Exists is a helper method to define mock.On call
+//   - segmentID int64
+func (_e *MockSegmentManager_Expecter) Exists(segmentID interface{}) *MockSegmentManager_Exists_Call {
+	return &MockSegmentManager_Exists_Call{Call: _e.mock.On("Exists", segmentID)}
+}
+
+func (_c *MockSegmentManager_Exists_Call) Run(run func(segmentID int64)) *MockSegmentManager_Exists_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_Exists_Call) Return(_a0 bool) *MockSegmentManager_Exists_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_Exists_Call) RunAndReturn(run func(int64) bool) *MockSegmentManager_Exists_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Get provides a mock function with given fields: segmentID
 func (_m *MockSegmentManager) Get(segmentID int64) Segment {
 	ret := _m.Called(segmentID)
@@ -275,8 +544,8 @@ func (_c *MockSegmentManager_GetAndPinBy_Call) RunAndReturn(run func(...SegmentF
 	return _c
 }
 
-// GetBy provides a mock function with given fields: filters
-func (_m *MockSegmentManager) GetBy(filters ...SegmentFilter) []Segment {
+// GetAndPinByInfo provides a mock function with given fields: filters
+func (_m *MockSegmentManager) GetAndPinByInfo(filters ...SegmentFilter) ([]PinnedSegment, error) {
 	_va := make([]interface{}, len(filters))
 	for _i := range filters {
 		_va[_i] = filters[_i]
@@ -285,31 +554,41 @@ func (_m *MockSegmentManager) GetBy(filters ...SegmentFilter) []Segment {
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 []Segment
-	if rf, ok := ret.Get(0).(func(...SegmentFilter) []Segment); ok {
+	var r0 []PinnedSegment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) ([]PinnedSegment, error)); ok {
+		return rf(filters...)
+	}
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) []PinnedSegment); ok {
 		r0 = rf(filters...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]Segment)
+			r0 = ret.Get(0).([]PinnedSegment)
 		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(...SegmentFilter) error); ok {
+		r1 = rf(filters...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockSegmentManager_GetBy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBy'
-type MockSegmentManager_GetBy_Call struct {
+// MockSegmentManager_GetAndPinByInfo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAndPinByInfo'
+type MockSegmentManager_GetAndPinByInfo_Call struct {
 	*mock.Call
 }
 
-// GetBy is a helper method to define mock.On call
+// GetAndPinByInfo is a helper method to define mock.On call
 //   - filters ...SegmentFilter
-func (_e *MockSegmentManager_Expecter) GetBy(filters ...interface{}) *MockSegmentManager_GetBy_Call {
-	return &MockSegmentManager_GetBy_Call{Call: _e.mock.On("GetBy",
+func (_e *MockSegmentManager_Expecter) GetAndPinByInfo(filters ...interface{}) *MockSegmentManager_GetAndPinByInfo_Call {
+	return &MockSegmentManager_GetAndPinByInfo_Call{Call: _e.mock.On("GetAndPinByInfo",
 		append([]interface{}{}, filters...)...)}
 }
 
-func (_c *MockSegmentManager_GetBy_Call) Run(run func(filters ...SegmentFilter)) *MockSegmentManager_GetBy_Call {
+func (_c *MockSegmentManager_GetAndPinByInfo_Call) Run(run func(filters ...SegmentFilter)) *MockSegmentManager_GetAndPinByInfo_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		variadicArgs := make([]SegmentFilter, len(args)-0)
 		for i, a := range args[0:] {
@@ -322,344 +601,2533 @@ func (_c *MockSegmentManager_GetBy_Call) Run(run func(filters ...SegmentFilter))
 	return _c
 }
 
-func (_c *MockSegmentManager_GetBy_Call) Return(_a0 []Segment) *MockSegmentManager_GetBy_Call {
-	_c.Call.Return(_a0)
+func (_c *MockSegmentManager_GetAndPinByInfo_Call) Return(_a0 []PinnedSegment, _a1 error) *MockSegmentManager_GetAndPinByInfo_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockSegmentManager_GetBy_Call) RunAndReturn(run func(...SegmentFilter) []Segment) *MockSegmentManager_GetBy_Call {
+func (_c *MockSegmentManager_GetAndPinByInfo_Call) RunAndReturn(run func(...SegmentFilter) ([]PinnedSegment, error)) *MockSegmentManager_GetAndPinByInfo_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetGrowing provides a mock function with given fields: segmentID
-func (_m *MockSegmentManager) GetGrowing(segmentID int64) Segment {
-	ret := _m.Called(segmentID)
+// GetAndPinByRequired provides a mock function with given fields: filters
+func (_m *MockSegmentManager) GetAndPinByRequired(filters ...SegmentFilter) ([]Segment, error) {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
-	var r0 Segment
-	if rf, ok := ret.Get(0).(func(int64) Segment); ok {
-		r0 = rf(segmentID)
+	var r0 []Segment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) ([]Segment, error)); ok {
+		return rf(filters...)
+	}
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) []Segment); ok {
+		r0 = rf(filters...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(Segment)
+			r0 = ret.Get(0).([]Segment)
 		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(...SegmentFilter) error); ok {
+		r1 = rf(filters...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockSegmentManager_GetGrowing_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGrowing'
-type MockSegmentManager_GetGrowing_Call struct {
+// MockSegmentManager_GetAndPinByRequired_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAndPinByRequired'
+type MockSegmentManager_GetAndPinByRequired_Call struct {
 	*mock.Call
 }
 
-// GetGrowing is a helper method to define mock.On call
-//   - segmentID int64
-func (_e *MockSegmentManager_Expecter) GetGrowing(segmentID interface{}) *MockSegmentManager_GetGrowing_Call {
-	return &MockSegmentManager_GetGrowing_Call{Call: _e.mock.On("GetGrowing", segmentID)}
+// GetAndPinByRequired is a helper method to define mock.On call
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) GetAndPinByRequired(filters ...interface{}) *MockSegmentManager_GetAndPinByRequired_Call {
+	return &MockSegmentManager_GetAndPinByRequired_Call{Call: _e.mock.On("GetAndPinByRequired",
+		append([]interface{}{}, filters...)...)}
 }
 
-func (_c *MockSegmentManager_GetGrowing_Call) Run(run func(segmentID int64)) *MockSegmentManager_GetGrowing_Call {
+func (_c *MockSegmentManager_GetAndPinByRequired_Call) Run(run func(filters ...SegmentFilter)) *MockSegmentManager_GetAndPinByRequired_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(int64))
+		variadicArgs := make([]SegmentFilter, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(variadicArgs...)
 	})
 	return _c
 }
 
-func (_c *MockSegmentManager_GetGrowing_Call) Return(_a0 Segment) *MockSegmentManager_GetGrowing_Call {
-	_c.Call.Return(_a0)
+func (_c *MockSegmentManager_GetAndPinByRequired_Call) Return(_a0 []Segment, _a1 error) *MockSegmentManager_GetAndPinByRequired_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockSegmentManager_GetGrowing_Call) RunAndReturn(run func(int64) Segment) *MockSegmentManager_GetGrowing_Call {
+func (_c *MockSegmentManager_GetAndPinByRequired_Call) RunAndReturn(run func(...SegmentFilter) ([]Segment, error)) *MockSegmentManager_GetAndPinByRequired_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetSealed provides a mock function with given fields: segmentID
-func (_m *MockSegmentManager) GetSealed(segmentID int64) Segment {
-	ret := _m.Called(segmentID)
-
-	var r0 Segment
-	if rf, ok := ret.Get(0).(func(int64) Segment); ok {
-		r0 = rf(segmentID)
-	} else {
-		if ret.Get(0) != nil {
+// GetAndPinWithDeadline provides a mock function with given fields: segments, deadline, policy, filters
+func (_m *MockSegmentManager) GetAndPinWithDeadline(segments []int64, deadline time.Duration, policy PinTimeoutPolicy, filters ...SegmentFilter) ([]Segment, []int64, error) {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, segments)
+	_ca = append(_ca, deadline)
+	_ca = append(_ca, policy)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []Segment
+	var r1 []int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func([]int64, time.Duration, PinTimeoutPolicy, ...SegmentFilter) ([]Segment, []int64, error)); ok {
+		return rf(segments, deadline, policy, filters...)
+	}
+	if rf, ok := ret.Get(0).(func([]int64, time.Duration, PinTimeoutPolicy, ...SegmentFilter) []Segment); ok {
+		r0 = rf(segments, deadline, policy, filters...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Segment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]int64, time.Duration, PinTimeoutPolicy, ...SegmentFilter) []int64); ok {
+		r1 = rf(segments, deadline, policy, filters...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func([]int64, time.Duration, PinTimeoutPolicy, ...SegmentFilter) error); ok {
+		r2 = rf(segments, deadline, policy, filters...)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockSegmentManager_GetAndPinWithDeadline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAndPinWithDeadline'
+type MockSegmentManager_GetAndPinWithDeadline_Call struct {
+	*mock.Call
+}
+
+// GetAndPinWithDeadline is a helper method to define mock.On call
+//   - segments []int64
+//   - deadline time.Duration
+//   - policy PinTimeoutPolicy
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) GetAndPinWithDeadline(segments interface{}, deadline interface{}, policy interface{}, filters ...interface{}) *MockSegmentManager_GetAndPinWithDeadline_Call {
+	return &MockSegmentManager_GetAndPinWithDeadline_Call{Call: _e.mock.On("GetAndPinWithDeadline",
+		append([]interface{}{segments, deadline, policy}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_GetAndPinWithDeadline_Call) Run(run func(segments []int64, deadline time.Duration, policy PinTimeoutPolicy, filters ...SegmentFilter)) *MockSegmentManager_GetAndPinWithDeadline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-3)
+		for i, a := range args[3:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(args[0].([]int64), args[1].(time.Duration), args[2].(PinTimeoutPolicy), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GetAndPinWithDeadline_Call) Return(_a0 []Segment, _a1 []int64, _a2 error) *MockSegmentManager_GetAndPinWithDeadline_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockSegmentManager_GetAndPinWithDeadline_Call) RunAndReturn(run func([]int64, time.Duration, PinTimeoutPolicy, ...SegmentFilter) ([]Segment, []int64, error)) *MockSegmentManager_GetAndPinWithDeadline_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAndPinWithOptions provides a mock function with given fields: segments, opts, filters
+func (_m *MockSegmentManager) GetAndPinWithOptions(segments []int64, opts []PinOption, filters ...SegmentFilter) ([]Segment, error) {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, segments)
+	_ca = append(_ca, opts)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []Segment
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]int64, []PinOption, ...SegmentFilter) ([]Segment, error)); ok {
+		return rf(segments, opts, filters...)
+	}
+	if rf, ok := ret.Get(0).(func([]int64, []PinOption, ...SegmentFilter) []Segment); ok {
+		r0 = rf(segments, opts, filters...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Segment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]int64, []PinOption, ...SegmentFilter) error); ok {
+		r1 = rf(segments, opts, filters...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_GetAndPinWithOptions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAndPinWithOptions'
+type MockSegmentManager_GetAndPinWithOptions_Call struct {
+	*mock.Call
+}
+
+// GetAndPinWithOptions is a helper method to define mock.On call
+//   - segments []int64
+//   - opts []PinOption
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) GetAndPinWithOptions(segments interface{}, opts interface{}, filters ...interface{}) *MockSegmentManager_GetAndPinWithOptions_Call {
+	return &MockSegmentManager_GetAndPinWithOptions_Call{Call: _e.mock.On("GetAndPinWithOptions",
+		append([]interface{}{segments, opts}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_GetAndPinWithOptions_Call) Run(run func(segments []int64, opts []PinOption, filters ...SegmentFilter)) *MockSegmentManager_GetAndPinWithOptions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(args[0].([]int64), args[1].([]PinOption), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GetAndPinWithOptions_Call) Return(_a0 []Segment, _a1 error) *MockSegmentManager_GetAndPinWithOptions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_GetAndPinWithOptions_Call) RunAndReturn(run func([]int64, []PinOption, ...SegmentFilter) ([]Segment, error)) *MockSegmentManager_GetAndPinWithOptions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetBy provides a mock function with given fields: filters
+func (_m *MockSegmentManager) GetBy(filters ...SegmentFilter) []Segment {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []Segment
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) []Segment); ok {
+		r0 = rf(filters...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Segment)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_GetBy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBy'
+type MockSegmentManager_GetBy_Call struct {
+	*mock.Call
+}
+
+// GetBy is a helper method to define mock.On call
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) GetBy(filters ...interface{}) *MockSegmentManager_GetBy_Call {
+	return &MockSegmentManager_GetBy_Call{Call: _e.mock.On("GetBy",
+		append([]interface{}{}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_GetBy_Call) Run(run func(filters ...SegmentFilter)) *MockSegmentManager_GetBy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GetBy_Call) Return(_a0 []Segment) *MockSegmentManager_GetBy_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_GetBy_Call) RunAndReturn(run func(...SegmentFilter) []Segment) *MockSegmentManager_GetBy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetByChecked provides a mock function with given fields: filters
+func (_m *MockSegmentManager) GetByChecked(filters ...SegmentFilter) ([]Segment, error) {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []Segment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) ([]Segment, error)); ok {
+		return rf(filters...)
+	}
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) []Segment); ok {
+		r0 = rf(filters...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Segment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(...SegmentFilter) error); ok {
+		r1 = rf(filters...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_GetByChecked_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetByChecked'
+type MockSegmentManager_GetByChecked_Call struct {
+	*mock.Call
+}
+
+// GetByChecked is a helper method to define mock.On call
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) GetByChecked(filters ...interface{}) *MockSegmentManager_GetByChecked_Call {
+	return &MockSegmentManager_GetByChecked_Call{Call: _e.mock.On("GetByChecked",
+		append([]interface{}{}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_GetByChecked_Call) Run(run func(filters ...SegmentFilter)) *MockSegmentManager_GetByChecked_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GetByChecked_Call) Return(_a0 []Segment, _a1 error) *MockSegmentManager_GetByChecked_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_GetByChecked_Call) RunAndReturn(run func(...SegmentFilter) ([]Segment, error)) *MockSegmentManager_GetByChecked_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetByIndexBuildID provides a mock function with given fields: buildID
+func (_m *MockSegmentManager) GetByIndexBuildID(buildID int64) (Segment, bool) {
+	ret := _m.Called(buildID)
+
+	var r0 Segment
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(int64) (Segment, bool)); ok {
+		return rf(buildID)
+	}
+	if rf, ok := ret.Get(0).(func(int64) Segment); ok {
+		r0 = rf(buildID)
+	} else {
+		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(Segment)
 		}
 	}
 
+	if rf, ok := ret.Get(1).(func(int64) bool); ok {
+		r1 = rf(buildID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_GetByIndexBuildID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetByIndexBuildID'
+type MockSegmentManager_GetByIndexBuildID_Call struct {
+	*mock.Call
+}
+
+// GetByIndexBuildID is a helper method to define mock.On call
+//   - buildID int64
+func (_e *MockSegmentManager_Expecter) GetByIndexBuildID(buildID interface{}) *MockSegmentManager_GetByIndexBuildID_Call {
+	return &MockSegmentManager_GetByIndexBuildID_Call{Call: _e.mock.On("GetByIndexBuildID", buildID)}
+}
+
+func (_c *MockSegmentManager_GetByIndexBuildID_Call) Run(run func(buildID int64)) *MockSegmentManager_GetByIndexBuildID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GetByIndexBuildID_Call) Return(_a0 Segment, _a1 bool) *MockSegmentManager_GetByIndexBuildID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_GetByIndexBuildID_Call) RunAndReturn(run func(int64) (Segment, bool)) *MockSegmentManager_GetByIndexBuildID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetByPaged provides a mock function with given fields: offset, limit, filters
+func (_m *MockSegmentManager) GetByPaged(offset int, limit int, filters ...SegmentFilter) ([]Segment, int) {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, offset)
+	_ca = append(_ca, limit)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []Segment
+	var r1 int
+	if rf, ok := ret.Get(0).(func(int, int, ...SegmentFilter) ([]Segment, int)); ok {
+		return rf(offset, limit, filters...)
+	}
+	if rf, ok := ret.Get(0).(func(int, int, ...SegmentFilter) []Segment); ok {
+		r0 = rf(offset, limit, filters...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Segment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, int, ...SegmentFilter) int); ok {
+		r1 = rf(offset, limit, filters...)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_GetByPaged_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetByPaged'
+type MockSegmentManager_GetByPaged_Call struct {
+	*mock.Call
+}
+
+// GetByPaged is a helper method to define mock.On call
+//   - offset int
+//   - limit int
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) GetByPaged(offset interface{}, limit interface{}, filters ...interface{}) *MockSegmentManager_GetByPaged_Call {
+	return &MockSegmentManager_GetByPaged_Call{Call: _e.mock.On("GetByPaged",
+		append([]interface{}{offset, limit}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_GetByPaged_Call) Run(run func(offset int, limit int, filters ...SegmentFilter)) *MockSegmentManager_GetByPaged_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(args[0].(int), args[1].(int), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GetByPaged_Call) Return(_a0 []Segment, _a1 int) *MockSegmentManager_GetByPaged_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_GetByPaged_Call) RunAndReturn(run func(int, int, ...SegmentFilter) ([]Segment, int)) *MockSegmentManager_GetByPaged_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetByWithStats provides a mock function with given fields: filters
+func (_m *MockSegmentManager) GetByWithStats(filters ...SegmentFilter) ([]Segment, ScanStats) {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []Segment
+	var r1 ScanStats
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) ([]Segment, ScanStats)); ok {
+		return rf(filters...)
+	}
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) []Segment); ok {
+		r0 = rf(filters...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Segment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(...SegmentFilter) ScanStats); ok {
+		r1 = rf(filters...)
+	} else {
+		r1 = ret.Get(1).(ScanStats)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_GetByWithStats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetByWithStats'
+type MockSegmentManager_GetByWithStats_Call struct {
+	*mock.Call
+}
+
+// GetByWithStats is a helper method to define mock.On call
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) GetByWithStats(filters ...interface{}) *MockSegmentManager_GetByWithStats_Call {
+	return &MockSegmentManager_GetByWithStats_Call{Call: _e.mock.On("GetByWithStats",
+		append([]interface{}{}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_GetByWithStats_Call) Run(run func(filters ...SegmentFilter)) *MockSegmentManager_GetByWithStats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GetByWithStats_Call) Return(_a0 []Segment, _a1 ScanStats) *MockSegmentManager_GetByWithStats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_GetByWithStats_Call) RunAndReturn(run func(...SegmentFilter) ([]Segment, ScanStats)) *MockSegmentManager_GetByWithStats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGrowing provides a mock function with given fields: segmentID
+func (_m *MockSegmentManager) GetGrowing(segmentID int64) Segment {
+	ret := _m.Called(segmentID)
+
+	var r0 Segment
+	if rf, ok := ret.Get(0).(func(int64) Segment); ok {
+		r0 = rf(segmentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Segment)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_GetGrowing_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGrowing'
+type MockSegmentManager_GetGrowing_Call struct {
+	*mock.Call
+}
+
+// GetGrowing is a helper method to define mock.On call
+//   - segmentID int64
+func (_e *MockSegmentManager_Expecter) GetGrowing(segmentID interface{}) *MockSegmentManager_GetGrowing_Call {
+	return &MockSegmentManager_GetGrowing_Call{Call: _e.mock.On("GetGrowing", segmentID)}
+}
+
+func (_c *MockSegmentManager_GetGrowing_Call) Run(run func(segmentID int64)) *MockSegmentManager_GetGrowing_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GetGrowing_Call) Return(_a0 Segment) *MockSegmentManager_GetGrowing_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_GetGrowing_Call) RunAndReturn(run func(int64) Segment) *MockSegmentManager_GetGrowing_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetMany provides a mock function with given fields: segmentIDs
+func (_m *MockSegmentManager) GetMany(segmentIDs []int64) map[int64]Segment {
+	ret := _m.Called(segmentIDs)
+
+	var r0 map[int64]Segment
+	if rf, ok := ret.Get(0).(func([]int64) map[int64]Segment); ok {
+		r0 = rf(segmentIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int64]Segment)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_GetMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMany'
+type MockSegmentManager_GetMany_Call struct {
+	*mock.Call
+}
+
+// GetMany is a helper method to define mock.On call
+//   - segmentIDs []int64
+func (_e *MockSegmentManager_Expecter) GetMany(segmentIDs interface{}) *MockSegmentManager_GetMany_Call {
+	return &MockSegmentManager_GetMany_Call{Call: _e.mock.On("GetMany", segmentIDs)}
+}
+
+func (_c *MockSegmentManager_GetMany_Call) Run(run func(segmentIDs []int64)) *MockSegmentManager_GetMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]int64))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GetMany_Call) Return(_a0 map[int64]Segment) *MockSegmentManager_GetMany_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_GetMany_Call) RunAndReturn(run func([]int64) map[int64]Segment) *MockSegmentManager_GetMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSealed provides a mock function with given fields: segmentID
+func (_m *MockSegmentManager) GetSealed(segmentID int64) Segment {
+	ret := _m.Called(segmentID)
+
+	var r0 Segment
+	if rf, ok := ret.Get(0).(func(int64) Segment); ok {
+		r0 = rf(segmentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Segment)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_GetSealed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSealed'
+type MockSegmentManager_GetSealed_Call struct {
+	*mock.Call
+}
+
+// GetSealed is a helper method to define mock.On call
+//   - segmentID int64
+func (_e *MockSegmentManager_Expecter) GetSealed(segmentID interface{}) *MockSegmentManager_GetSealed_Call {
+	return &MockSegmentManager_GetSealed_Call{Call: _e.mock.On("GetSealed", segmentID)}
+}
+
+func (_c *MockSegmentManager_GetSealed_Call) Run(run func(segmentID int64)) *MockSegmentManager_GetSealed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GetSealed_Call) Return(_a0 Segment) *MockSegmentManager_GetSealed_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_GetSealed_Call) RunAndReturn(run func(int64) Segment) *MockSegmentManager_GetSealed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetWithType provides a mock function with given fields: segmentID, typ
+func (_m *MockSegmentManager) GetWithType(segmentID int64, typ commonpb.SegmentState) Segment {
+	ret := _m.Called(segmentID, typ)
+
+	var r0 Segment
+	if rf, ok := ret.Get(0).(func(int64, commonpb.SegmentState) Segment); ok {
+		r0 = rf(segmentID, typ)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Segment)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_GetWithType_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWithType'
+type MockSegmentManager_GetWithType_Call struct {
+	*mock.Call
+}
+
+// GetWithType is a helper method to define mock.On call
+//   - segmentID int64
+//   - typ commonpb.SegmentState
+func (_e *MockSegmentManager_Expecter) GetWithType(segmentID interface{}, typ interface{}) *MockSegmentManager_GetWithType_Call {
+	return &MockSegmentManager_GetWithType_Call{Call: _e.mock.On("GetWithType", segmentID, typ)}
+}
+
+func (_c *MockSegmentManager_GetWithType_Call) Run(run func(segmentID int64, typ commonpb.SegmentState)) *MockSegmentManager_GetWithType_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64), args[1].(commonpb.SegmentState))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GetWithType_Call) Return(_a0 Segment) *MockSegmentManager_GetWithType_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_GetWithType_Call) RunAndReturn(run func(int64, commonpb.SegmentState) Segment) *MockSegmentManager_GetWithType_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GrowingCount provides a mock function with given fields:
+func (_m *MockSegmentManager) GrowingCount() int {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// MockSegmentManager_GrowingCount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GrowingCount'
+type MockSegmentManager_GrowingCount_Call struct {
+	*mock.Call
+}
+
+// GrowingCount is a helper method to define mock.On call
+func (_e *MockSegmentManager_Expecter) GrowingCount() *MockSegmentManager_GrowingCount_Call {
+	return &MockSegmentManager_GrowingCount_Call{Call: _e.mock.On("GrowingCount")}
+}
+
+func (_c *MockSegmentManager_GrowingCount_Call) Run(run func()) *MockSegmentManager_GrowingCount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GrowingCount_Call) Return(_a0 int) *MockSegmentManager_GrowingCount_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_GrowingCount_Call) RunAndReturn(run func() int) *MockSegmentManager_GrowingCount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GrowingRowRange provides a mock function with given fields: segmentID
+func (_m *MockSegmentManager) GrowingRowRange(segmentID int64) (uint64, uint64, int64, bool) {
+	ret := _m.Called(segmentID)
+
+	var r0 uint64
+	var r1 uint64
+	var r2 int64
+	var r3 bool
+	if rf, ok := ret.Get(0).(func(int64) (uint64, uint64, int64, bool)); ok {
+		return rf(segmentID)
+	}
+	if rf, ok := ret.Get(0).(func(int64) uint64); ok {
+		r0 = rf(segmentID)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) uint64); ok {
+		r1 = rf(segmentID)
+	} else {
+		r1 = ret.Get(1).(uint64)
+	}
+
+	if rf, ok := ret.Get(2).(func(int64) int64); ok {
+		r2 = rf(segmentID)
+	} else {
+		r2 = ret.Get(2).(int64)
+	}
+
+	if rf, ok := ret.Get(3).(func(int64) bool); ok {
+		r3 = rf(segmentID)
+	} else {
+		r3 = ret.Get(3).(bool)
+	}
+
+	return r0, r1, r2, r3
+}
+
+// MockSegmentManager_GrowingRowRange_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GrowingRowRange'
+type MockSegmentManager_GrowingRowRange_Call struct {
+	*mock.Call
+}
+
+// GrowingRowRange is a helper method to define mock.On call
+//   - segmentID int64
+func (_e *MockSegmentManager_Expecter) GrowingRowRange(segmentID interface{}) *MockSegmentManager_GrowingRowRange_Call {
+	return &MockSegmentManager_GrowingRowRange_Call{Call: _e.mock.On("GrowingRowRange", segmentID)}
+}
+
+func (_c *MockSegmentManager_GrowingRowRange_Call) Run(run func(segmentID int64)) *MockSegmentManager_GrowingRowRange_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_GrowingRowRange_Call) Return(_a0 uint64, _a1 uint64, _a2 int64, _a3 bool) *MockSegmentManager_GrowingRowRange_Call {
+	_c.Call.Return(_a0, _a1, _a2, _a3)
+	return _c
+}
+
+func (_c *MockSegmentManager_GrowingRowRange_Call) RunAndReturn(run func(int64) (uint64, uint64, int64, bool)) *MockSegmentManager_GrowingRowRange_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Handoff provides a mock function with given fields: growingID, sealed
+func (_m *MockSegmentManager) Handoff(growingID int64, sealed Segment) (Segment, error) {
+	ret := _m.Called(growingID, sealed)
+
+	var r0 Segment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, Segment) (Segment, error)); ok {
+		return rf(growingID, sealed)
+	}
+	if rf, ok := ret.Get(0).(func(int64, Segment) Segment); ok {
+		r0 = rf(growingID, sealed)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Segment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, Segment) error); ok {
+		r1 = rf(growingID, sealed)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_Handoff_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Handoff'
+type MockSegmentManager_Handoff_Call struct {
+	*mock.Call
+}
+
+// Handoff is a helper method to define mock.On call
+//   - growingID int64
+//   - sealed Segment
+func (_e *MockSegmentManager_Expecter) Handoff(growingID interface{}, sealed interface{}) *MockSegmentManager_Handoff_Call {
+	return &MockSegmentManager_Handoff_Call{Call: _e.mock.On("Handoff", growingID, sealed)}
+}
+
+func (_c *MockSegmentManager_Handoff_Call) Run(run func(growingID int64, sealed Segment)) *MockSegmentManager_Handoff_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64), args[1].(Segment))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_Handoff_Call) Return(_a0 Segment, _a1 error) *MockSegmentManager_Handoff_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_Handoff_Call) RunAndReturn(run func(int64, Segment) (Segment, error)) *MockSegmentManager_Handoff_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HasCollection provides a mock function with given fields: collectionID
+func (_m *MockSegmentManager) HasCollection(collectionID int64) bool {
+	ret := _m.Called(collectionID)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(int64) bool); ok {
+		r0 = rf(collectionID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MockSegmentManager_HasCollection_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HasCollection'
+type MockSegmentManager_HasCollection_Call struct {
+	*mock.Call
+}
+
+// HasCollection is a helper method to define mock.On call
+//   - collectionID int64
+func (_e *MockSegmentManager_Expecter) HasCollection(collectionID interface{}) *MockSegmentManager_HasCollection_Call {
+	return &MockSegmentManager_HasCollection_Call{Call: _e.mock.On("HasCollection", collectionID)}
+}
+
+func (_c *MockSegmentManager_HasCollection_Call) Run(run func(collectionID int64)) *MockSegmentManager_HasCollection_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_HasCollection_Call) Return(_a0 bool) *MockSegmentManager_HasCollection_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_HasCollection_Call) RunAndReturn(run func(int64) bool) *MockSegmentManager_HasCollection_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Hierarchy provides a mock function with given fields: filters
+func (_m *MockSegmentManager) Hierarchy(filters ...SegmentFilter) map[int64]map[int64][]SegmentInfo {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 map[int64]map[int64][]SegmentInfo
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) map[int64]map[int64][]SegmentInfo); ok {
+		r0 = rf(filters...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int64]map[int64][]SegmentInfo)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_Hierarchy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Hierarchy'
+type MockSegmentManager_Hierarchy_Call struct {
+	*mock.Call
+}
+
+// Hierarchy is a helper method to define mock.On call
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) Hierarchy(filters ...interface{}) *MockSegmentManager_Hierarchy_Call {
+	return &MockSegmentManager_Hierarchy_Call{Call: _e.mock.On("Hierarchy",
+		append([]interface{}{}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_Hierarchy_Call) Run(run func(filters ...SegmentFilter)) *MockSegmentManager_Hierarchy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_Hierarchy_Call) Return(_a0 map[int64]map[int64][]SegmentInfo) *MockSegmentManager_Hierarchy_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_Hierarchy_Call) RunAndReturn(run func(...SegmentFilter) map[int64]map[int64][]SegmentInfo) *MockSegmentManager_Hierarchy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LoadedCollections provides a mock function with given fields:
+func (_m *MockSegmentManager) LoadedCollections() []int64 {
+	ret := _m.Called()
+
+	var r0 []int64
+	if rf, ok := ret.Get(0).(func() []int64); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_LoadedCollections_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LoadedCollections'
+type MockSegmentManager_LoadedCollections_Call struct {
+	*mock.Call
+}
+
+// LoadedCollections is a helper method to define mock.On call
+func (_e *MockSegmentManager_Expecter) LoadedCollections() *MockSegmentManager_LoadedCollections_Call {
+	return &MockSegmentManager_LoadedCollections_Call{Call: _e.mock.On("LoadedCollections")}
+}
+
+func (_c *MockSegmentManager_LoadedCollections_Call) Run(run func()) *MockSegmentManager_LoadedCollections_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_LoadedCollections_Call) Return(_a0 []int64) *MockSegmentManager_LoadedCollections_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_LoadedCollections_Call) RunAndReturn(run func() []int64) *MockSegmentManager_LoadedCollections_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MaxTimestamp provides a mock function with given fields: filters
+func (_m *MockSegmentManager) MaxTimestamp(filters ...SegmentFilter) uint64 {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) uint64); ok {
+		r0 = rf(filters...)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	return r0
+}
+
+// MockSegmentManager_MaxTimestamp_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MaxTimestamp'
+type MockSegmentManager_MaxTimestamp_Call struct {
+	*mock.Call
+}
+
+// MaxTimestamp is a helper method to define mock.On call
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) MaxTimestamp(filters ...interface{}) *MockSegmentManager_MaxTimestamp_Call {
+	return &MockSegmentManager_MaxTimestamp_Call{Call: _e.mock.On("MaxTimestamp",
+		append([]interface{}{}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_MaxTimestamp_Call) Run(run func(filters ...SegmentFilter)) *MockSegmentManager_MaxTimestamp_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_MaxTimestamp_Call) Return(_a0 uint64) *MockSegmentManager_MaxTimestamp_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_MaxTimestamp_Call) RunAndReturn(run func(...SegmentFilter) uint64) *MockSegmentManager_MaxTimestamp_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Orphans provides a mock function with given fields: target
+func (_m *MockSegmentManager) Orphans(target typeutil.Set[int64]) []int64 {
+	ret := _m.Called(target)
+
+	var r0 []int64
+	if rf, ok := ret.Get(0).(func(typeutil.Set[int64]) []int64); ok {
+		r0 = rf(target)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_Orphans_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Orphans'
+type MockSegmentManager_Orphans_Call struct {
+	*mock.Call
+}
+
+// Orphans is a helper method to define mock.On call
+//   - target typeutil.Set[int64]
+func (_e *MockSegmentManager_Expecter) Orphans(target interface{}) *MockSegmentManager_Orphans_Call {
+	return &MockSegmentManager_Orphans_Call{Call: _e.mock.On("Orphans", target)}
+}
+
+func (_c *MockSegmentManager_Orphans_Call) Run(run func(target typeutil.Set[int64])) *MockSegmentManager_Orphans_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(typeutil.Set[int64]))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_Orphans_Call) Return(_a0 []int64) *MockSegmentManager_Orphans_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_Orphans_Call) RunAndReturn(run func(typeutil.Set[int64]) []int64) *MockSegmentManager_Orphans_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Put provides a mock function with given fields: segmentType, segments
+func (_m *MockSegmentManager) Put(segmentType commonpb.SegmentState, segments ...Segment) {
+	_va := make([]interface{}, len(segments))
+	for _i := range segments {
+		_va[_i] = segments[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, segmentType)
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
+// MockSegmentManager_Put_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Put'
+type MockSegmentManager_Put_Call struct {
+	*mock.Call
+}
+
+// Put is a helper method to define mock.On call
+//   - segmentType commonpb.SegmentState
+//   - segments ...Segment
+func (_e *MockSegmentManager_Expecter) Put(segmentType interface{}, segments ...interface{}) *MockSegmentManager_Put_Call {
+	return &MockSegmentManager_Put_Call{Call: _e.mock.On("Put",
+		append([]interface{}{segmentType}, segments...)...)}
+}
+
+func (_c *MockSegmentManager_Put_Call) Run(run func(segmentType commonpb.SegmentState, segments ...Segment)) *MockSegmentManager_Put_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]Segment, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(Segment)
+			}
+		}
+		run(args[0].(commonpb.SegmentState), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_Put_Call) Return() *MockSegmentManager_Put_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockSegmentManager_Put_Call) RunAndReturn(run func(commonpb.SegmentState, ...Segment)) *MockSegmentManager_Put_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PutCtx provides a mock function with given fields: ctx, segmentType, segments
+func (_m *MockSegmentManager) PutCtx(ctx context.Context, segmentType commonpb.SegmentState, segments ...Segment) {
+	_va := make([]interface{}, len(segments))
+	for _i := range segments {
+		_va[_i] = segments[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, segmentType)
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
+// MockSegmentManager_PutCtx_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutCtx'
+type MockSegmentManager_PutCtx_Call struct {
+	*mock.Call
+}
+
+// PutCtx is a helper method to define mock.On call
+//   - ctx context.Context
+//   - segmentType commonpb.SegmentState
+//   - segments ...Segment
+func (_e *MockSegmentManager_Expecter) PutCtx(ctx interface{}, segmentType interface{}, segments ...interface{}) *MockSegmentManager_PutCtx_Call {
+	return &MockSegmentManager_PutCtx_Call{Call: _e.mock.On("PutCtx",
+		append([]interface{}{ctx, segmentType}, segments...)...)}
+}
+
+func (_c *MockSegmentManager_PutCtx_Call) Run(run func(ctx context.Context, segmentType commonpb.SegmentState, segments ...Segment)) *MockSegmentManager_PutCtx_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]Segment, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(Segment)
+			}
+		}
+		run(args[0].(context.Context), args[1].(commonpb.SegmentState), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_PutCtx_Call) Return() *MockSegmentManager_PutCtx_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockSegmentManager_PutCtx_Call) RunAndReturn(run func(context.Context, commonpb.SegmentState, ...Segment)) *MockSegmentManager_PutCtx_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PutLazy provides a mock function with given fields: segments
+func (_m *MockSegmentManager) PutLazy(segments ...Segment) {
+	_va := make([]interface{}, len(segments))
+	for _i := range segments {
+		_va[_i] = segments[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
+// MockSegmentManager_PutLazy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutLazy'
+type MockSegmentManager_PutLazy_Call struct {
+	*mock.Call
+}
+
+// PutLazy is a helper method to define mock.On call
+//   - segments ...Segment
+func (_e *MockSegmentManager_Expecter) PutLazy(segments ...interface{}) *MockSegmentManager_PutLazy_Call {
+	return &MockSegmentManager_PutLazy_Call{Call: _e.mock.On("PutLazy",
+		append([]interface{}{}, segments...)...)}
+}
+
+func (_c *MockSegmentManager_PutLazy_Call) Run(run func(segments ...Segment)) *MockSegmentManager_PutLazy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]Segment, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(Segment)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_PutLazy_Call) Return() *MockSegmentManager_PutLazy_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockSegmentManager_PutLazy_Call) RunAndReturn(run func(...Segment)) *MockSegmentManager_PutLazy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PutWithReport provides a mock function with given fields: segmentType, segments
+func (_m *MockSegmentManager) PutWithReport(segmentType commonpb.SegmentState, segments ...Segment) ([]int64, []int64) {
+	_va := make([]interface{}, len(segments))
+	for _i := range segments {
+		_va[_i] = segments[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, segmentType)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []int64
+	var r1 []int64
+	if rf, ok := ret.Get(0).(func(commonpb.SegmentState, ...Segment) ([]int64, []int64)); ok {
+		return rf(segmentType, segments...)
+	}
+	if rf, ok := ret.Get(0).(func(commonpb.SegmentState, ...Segment) []int64); ok {
+		r0 = rf(segmentType, segments...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(commonpb.SegmentState, ...Segment) []int64); ok {
+		r1 = rf(segmentType, segments...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]int64)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_PutWithReport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutWithReport'
+type MockSegmentManager_PutWithReport_Call struct {
+	*mock.Call
+}
+
+// PutWithReport is a helper method to define mock.On call
+//   - segmentType commonpb.SegmentState
+//   - segments ...Segment
+func (_e *MockSegmentManager_Expecter) PutWithReport(segmentType interface{}, segments ...interface{}) *MockSegmentManager_PutWithReport_Call {
+	return &MockSegmentManager_PutWithReport_Call{Call: _e.mock.On("PutWithReport",
+		append([]interface{}{segmentType}, segments...)...)}
+}
+
+func (_c *MockSegmentManager_PutWithReport_Call) Run(run func(segmentType commonpb.SegmentState, segments ...Segment)) *MockSegmentManager_PutWithReport_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]Segment, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(Segment)
+			}
+		}
+		run(args[0].(commonpb.SegmentState), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_PutWithReport_Call) Return(_a0 []int64, _a1 []int64) *MockSegmentManager_PutWithReport_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_PutWithReport_Call) RunAndReturn(run func(commonpb.SegmentState, ...Segment) ([]int64, []int64)) *MockSegmentManager_PutWithReport_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Quarantine provides a mock function with given fields: segmentID, reason
+func (_m *MockSegmentManager) Quarantine(segmentID int64, reason string) {
+	_m.Called(segmentID, reason)
+}
+
+// MockSegmentManager_Quarantine_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Quarantine'
+type MockSegmentManager_Quarantine_Call struct {
+	*mock.Call
+}
+
+// Quarantine is a helper method to define mock.On call
+//   - segmentID int64
+//   - reason string
+func (_e *MockSegmentManager_Expecter) Quarantine(segmentID interface{}, reason interface{}) *MockSegmentManager_Quarantine_Call {
+	return &MockSegmentManager_Quarantine_Call{Call: _e.mock.On("Quarantine", segmentID, reason)}
+}
+
+func (_c *MockSegmentManager_Quarantine_Call) Run(run func(segmentID int64, reason string)) *MockSegmentManager_Quarantine_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_Quarantine_Call) Return() *MockSegmentManager_Quarantine_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockSegmentManager_Quarantine_Call) RunAndReturn(run func(int64, string)) *MockSegmentManager_Quarantine_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// QuarantinedSegments provides a mock function with given fields:
+func (_m *MockSegmentManager) QuarantinedSegments() map[int64]string {
+	ret := _m.Called()
+
+	var r0 map[int64]string
+	if rf, ok := ret.Get(0).(func() map[int64]string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int64]string)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_QuarantinedSegments_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QuarantinedSegments'
+type MockSegmentManager_QuarantinedSegments_Call struct {
+	*mock.Call
+}
+
+// QuarantinedSegments is a helper method to define mock.On call
+func (_e *MockSegmentManager_Expecter) QuarantinedSegments() *MockSegmentManager_QuarantinedSegments_Call {
+	return &MockSegmentManager_QuarantinedSegments_Call{Call: _e.mock.On("QuarantinedSegments")}
+}
+
+func (_c *MockSegmentManager_QuarantinedSegments_Call) Run(run func()) *MockSegmentManager_QuarantinedSegments_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_QuarantinedSegments_Call) Return(_a0 map[int64]string) *MockSegmentManager_QuarantinedSegments_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_QuarantinedSegments_Call) RunAndReturn(run func() map[int64]string) *MockSegmentManager_QuarantinedSegments_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RangeCtx provides a mock function with given fields: ctx, fn, filters
+func (_m *MockSegmentManager) RangeCtx(ctx context.Context, fn func(Segment) bool, filters ...SegmentFilter) error {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, fn)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(Segment) bool, ...SegmentFilter) error); ok {
+		r0 = rf(ctx, fn, filters...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockSegmentManager_RangeCtx_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RangeCtx'
+type MockSegmentManager_RangeCtx_Call struct {
+	*mock.Call
+}
+
+// RangeCtx is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fn func(Segment) bool
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) RangeCtx(ctx interface{}, fn interface{}, filters ...interface{}) *MockSegmentManager_RangeCtx_Call {
+	return &MockSegmentManager_RangeCtx_Call{Call: _e.mock.On("RangeCtx",
+		append([]interface{}{ctx, fn}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_RangeCtx_Call) Run(run func(ctx context.Context, fn func(Segment) bool, filters ...SegmentFilter)) *MockSegmentManager_RangeCtx_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(args[0].(context.Context), args[1].(func(Segment) bool), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_RangeCtx_Call) Return(_a0 error) *MockSegmentManager_RangeCtx_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_RangeCtx_Call) RunAndReturn(run func(context.Context, func(Segment) bool, ...SegmentFilter) error) *MockSegmentManager_RangeCtx_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReadOnlySegments provides a mock function with given fields:
+func (_m *MockSegmentManager) ReadOnlySegments() []int64 {
+	ret := _m.Called()
+
+	var r0 []int64
+	if rf, ok := ret.Get(0).(func() []int64); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_ReadOnlySegments_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReadOnlySegments'
+type MockSegmentManager_ReadOnlySegments_Call struct {
+	*mock.Call
+}
+
+// ReadOnlySegments is a helper method to define mock.On call
+func (_e *MockSegmentManager_Expecter) ReadOnlySegments() *MockSegmentManager_ReadOnlySegments_Call {
+	return &MockSegmentManager_ReadOnlySegments_Call{Call: _e.mock.On("ReadOnlySegments")}
+}
+
+func (_c *MockSegmentManager_ReadOnlySegments_Call) Run(run func()) *MockSegmentManager_ReadOnlySegments_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_ReadOnlySegments_Call) Return(_a0 []int64) *MockSegmentManager_ReadOnlySegments_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_ReadOnlySegments_Call) RunAndReturn(run func() []int64) *MockSegmentManager_ReadOnlySegments_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecentReleases provides a mock function with given fields: n
+func (_m *MockSegmentManager) RecentReleases(n int) []ReleaseRecord {
+	ret := _m.Called(n)
+
+	var r0 []ReleaseRecord
+	if rf, ok := ret.Get(0).(func(int) []ReleaseRecord); ok {
+		r0 = rf(n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ReleaseRecord)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_RecentReleases_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecentReleases'
+type MockSegmentManager_RecentReleases_Call struct {
+	*mock.Call
+}
+
+// RecentReleases is a helper method to define mock.On call
+//   - n int
+func (_e *MockSegmentManager_Expecter) RecentReleases(n interface{}) *MockSegmentManager_RecentReleases_Call {
+	return &MockSegmentManager_RecentReleases_Call{Call: _e.mock.On("RecentReleases", n)}
+}
+
+func (_c *MockSegmentManager_RecentReleases_Call) Run(run func(n int)) *MockSegmentManager_RecentReleases_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_RecentReleases_Call) Return(_a0 []ReleaseRecord) *MockSegmentManager_RecentReleases_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_RecentReleases_Call) RunAndReturn(run func(int) []ReleaseRecord) *MockSegmentManager_RecentReleases_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Reconcile provides a mock function with given fields: desired, typ, loader
+func (_m *MockSegmentManager) Reconcile(desired map[int64]int64, typ commonpb.SegmentState, loader func(int64) (Segment, error)) ([]int64, []int64, []int64, error) {
+	ret := _m.Called(desired, typ, loader)
+
+	var r0 []int64
+	var r1 []int64
+	var r2 []int64
+	var r3 error
+	if rf, ok := ret.Get(0).(func(map[int64]int64, commonpb.SegmentState, func(int64) (Segment, error)) ([]int64, []int64, []int64, error)); ok {
+		return rf(desired, typ, loader)
+	}
+	if rf, ok := ret.Get(0).(func(map[int64]int64, commonpb.SegmentState, func(int64) (Segment, error)) []int64); ok {
+		r0 = rf(desired, typ, loader)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(map[int64]int64, commonpb.SegmentState, func(int64) (Segment, error)) []int64); ok {
+		r1 = rf(desired, typ, loader)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(map[int64]int64, commonpb.SegmentState, func(int64) (Segment, error)) []int64); ok {
+		r2 = rf(desired, typ, loader)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(3).(func(map[int64]int64, commonpb.SegmentState, func(int64) (Segment, error)) error); ok {
+		r3 = rf(desired, typ, loader)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
+// MockSegmentManager_Reconcile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Reconcile'
+type MockSegmentManager_Reconcile_Call struct {
+	*mock.Call
+}
+
+// Reconcile is a helper method to define mock.On call
+//   - desired map[int64]int64
+//   - typ commonpb.SegmentState
+//   - loader func(int64) (Segment, error)
+func (_e *MockSegmentManager_Expecter) Reconcile(desired interface{}, typ interface{}, loader interface{}) *MockSegmentManager_Reconcile_Call {
+	return &MockSegmentManager_Reconcile_Call{Call: _e.mock.On("Reconcile", desired, typ, loader)}
+}
+
+func (_c *MockSegmentManager_Reconcile_Call) Run(run func(desired map[int64]int64, typ commonpb.SegmentState, loader func(int64) (Segment, error))) *MockSegmentManager_Reconcile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(map[int64]int64), args[1].(commonpb.SegmentState), args[2].(func(int64) (Segment, error)))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_Reconcile_Call) Return(_a0 []int64, _a1 []int64, _a2 []int64, _a3 error) *MockSegmentManager_Reconcile_Call {
+	_c.Call.Return(_a0, _a1, _a2, _a3)
+	return _c
+}
+
+func (_c *MockSegmentManager_Reconcile_Call) RunAndReturn(run func(map[int64]int64, commonpb.SegmentState, func(int64) (Segment, error)) ([]int64, []int64, []int64, error)) *MockSegmentManager_Reconcile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordQueryError provides a mock function with given fields: segmentID
+func (_m *MockSegmentManager) RecordQueryError(segmentID int64) {
+	_m.Called(segmentID)
+}
+
+// MockSegmentManager_RecordQueryError_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordQueryError'
+type MockSegmentManager_RecordQueryError_Call struct {
+	*mock.Call
+}
+
+// RecordQueryError is a helper method to define mock.On call
+//   - segmentID int64
+func (_e *MockSegmentManager_Expecter) RecordQueryError(segmentID interface{}) *MockSegmentManager_RecordQueryError_Call {
+	return &MockSegmentManager_RecordQueryError_Call{Call: _e.mock.On("RecordQueryError", segmentID)}
+}
+
+func (_c *MockSegmentManager_RecordQueryError_Call) Run(run func(segmentID int64)) *MockSegmentManager_RecordQueryError_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_RecordQueryError_Call) Return() *MockSegmentManager_RecordQueryError_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockSegmentManager_RecordQueryError_Call) RunAndReturn(run func(int64)) *MockSegmentManager_RecordQueryError_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RefreshIndexes provides a mock function with given fields:
+func (_m *MockSegmentManager) RefreshIndexes() {
+	_m.Called()
+}
+
+// MockSegmentManager_RefreshIndexes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RefreshIndexes'
+type MockSegmentManager_RefreshIndexes_Call struct {
+	*mock.Call
+}
+
+// RefreshIndexes is a helper method to define mock.On call
+func (_e *MockSegmentManager_Expecter) RefreshIndexes() *MockSegmentManager_RefreshIndexes_Call {
+	return &MockSegmentManager_RefreshIndexes_Call{Call: _e.mock.On("RefreshIndexes")}
+}
+
+func (_c *MockSegmentManager_RefreshIndexes_Call) Run(run func()) *MockSegmentManager_RefreshIndexes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_RefreshIndexes_Call) Return() *MockSegmentManager_RefreshIndexes_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockSegmentManager_RefreshIndexes_Call) RunAndReturn(run func()) *MockSegmentManager_RefreshIndexes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Relabel provides a mock function with given fields: segmentID, newChannel
+func (_m *MockSegmentManager) Relabel(segmentID int64, newChannel string) bool {
+	ret := _m.Called(segmentID, newChannel)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(int64, string) bool); ok {
+		r0 = rf(segmentID, newChannel)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MockSegmentManager_Relabel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Relabel'
+type MockSegmentManager_Relabel_Call struct {
+	*mock.Call
+}
+
+// Relabel is a helper method to define mock.On call
+//   - segmentID int64
+//   - newChannel string
+func (_e *MockSegmentManager_Expecter) Relabel(segmentID interface{}, newChannel interface{}) *MockSegmentManager_Relabel_Call {
+	return &MockSegmentManager_Relabel_Call{Call: _e.mock.On("Relabel", segmentID, newChannel)}
+}
+
+func (_c *MockSegmentManager_Relabel_Call) Run(run func(segmentID int64, newChannel string)) *MockSegmentManager_Relabel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_Relabel_Call) Return(_a0 bool) *MockSegmentManager_Relabel_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_Relabel_Call) RunAndReturn(run func(int64, string) bool) *MockSegmentManager_Relabel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Remove provides a mock function with given fields: segmentID, scope
+func (_m *MockSegmentManager) Remove(segmentID int64, scope querypb.DataScope) (int, int) {
+	ret := _m.Called(segmentID, scope)
+
+	var r0 int
+	var r1 int
+	if rf, ok := ret.Get(0).(func(int64, querypb.DataScope) (int, int)); ok {
+		return rf(segmentID, scope)
+	}
+	if rf, ok := ret.Get(0).(func(int64, querypb.DataScope) int); ok {
+		r0 = rf(segmentID, scope)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, querypb.DataScope) int); ok {
+		r1 = rf(segmentID, scope)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_Remove_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Remove'
+type MockSegmentManager_Remove_Call struct {
+	*mock.Call
+}
+
+// Remove is a helper method to define mock.On call
+//   - segmentID int64
+//   - scope querypb.DataScope
+func (_e *MockSegmentManager_Expecter) Remove(segmentID interface{}, scope interface{}) *MockSegmentManager_Remove_Call {
+	return &MockSegmentManager_Remove_Call{Call: _e.mock.On("Remove", segmentID, scope)}
+}
+
+func (_c *MockSegmentManager_Remove_Call) Run(run func(segmentID int64, scope querypb.DataScope)) *MockSegmentManager_Remove_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64), args[1].(querypb.DataScope))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_Remove_Call) Return(_a0 int, _a1 int) *MockSegmentManager_Remove_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_Remove_Call) RunAndReturn(run func(int64, querypb.DataScope) (int, int)) *MockSegmentManager_Remove_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveBy provides a mock function with given fields: filters
+func (_m *MockSegmentManager) RemoveBy(filters ...SegmentFilter) (int, int) {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 int
+	var r1 int
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) (int, int)); ok {
+		return rf(filters...)
+	}
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) int); ok {
+		r0 = rf(filters...)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(...SegmentFilter) int); ok {
+		r1 = rf(filters...)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_RemoveBy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveBy'
+type MockSegmentManager_RemoveBy_Call struct {
+	*mock.Call
+}
+
+// RemoveBy is a helper method to define mock.On call
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) RemoveBy(filters ...interface{}) *MockSegmentManager_RemoveBy_Call {
+	return &MockSegmentManager_RemoveBy_Call{Call: _e.mock.On("RemoveBy",
+		append([]interface{}{}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_RemoveBy_Call) Run(run func(filters ...SegmentFilter)) *MockSegmentManager_RemoveBy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_RemoveBy_Call) Return(_a0 int, _a1 int) *MockSegmentManager_RemoveBy_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_RemoveBy_Call) RunAndReturn(run func(...SegmentFilter) (int, int)) *MockSegmentManager_RemoveBy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveByAsync provides a mock function with given fields: filters
+func (_m *MockSegmentManager) RemoveByAsync(filters ...SegmentFilter) (int, int) {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 int
+	var r1 int
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) (int, int)); ok {
+		return rf(filters...)
+	}
+	if rf, ok := ret.Get(0).(func(...SegmentFilter) int); ok {
+		r0 = rf(filters...)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(...SegmentFilter) int); ok {
+		r1 = rf(filters...)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_RemoveByAsync_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveByAsync'
+type MockSegmentManager_RemoveByAsync_Call struct {
+	*mock.Call
+}
+
+// RemoveByAsync is a helper method to define mock.On call
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) RemoveByAsync(filters ...interface{}) *MockSegmentManager_RemoveByAsync_Call {
+	return &MockSegmentManager_RemoveByAsync_Call{Call: _e.mock.On("RemoveByAsync",
+		append([]interface{}{}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_RemoveByAsync_Call) Run(run func(filters ...SegmentFilter)) *MockSegmentManager_RemoveByAsync_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_RemoveByAsync_Call) Return(_a0 int, _a1 int) *MockSegmentManager_RemoveByAsync_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_RemoveByAsync_Call) RunAndReturn(run func(...SegmentFilter) (int, int)) *MockSegmentManager_RemoveByAsync_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveByCtx provides a mock function with given fields: ctx, filters
+func (_m *MockSegmentManager) RemoveByCtx(ctx context.Context, filters ...SegmentFilter) (int, int) {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 int
+	var r1 int
+	if rf, ok := ret.Get(0).(func(context.Context, ...SegmentFilter) (int, int)); ok {
+		return rf(ctx, filters...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ...SegmentFilter) int); ok {
+		r0 = rf(ctx, filters...)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ...SegmentFilter) int); ok {
+		r1 = rf(ctx, filters...)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_RemoveByCtx_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveByCtx'
+type MockSegmentManager_RemoveByCtx_Call struct {
+	*mock.Call
+}
+
+// RemoveByCtx is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) RemoveByCtx(ctx interface{}, filters ...interface{}) *MockSegmentManager_RemoveByCtx_Call {
+	return &MockSegmentManager_RemoveByCtx_Call{Call: _e.mock.On("RemoveByCtx",
+		append([]interface{}{ctx}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_RemoveByCtx_Call) Run(run func(ctx context.Context, filters ...SegmentFilter)) *MockSegmentManager_RemoveByCtx_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(args[0].(context.Context), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_RemoveByCtx_Call) Return(_a0 int, _a1 int) *MockSegmentManager_RemoveByCtx_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_RemoveByCtx_Call) RunAndReturn(run func(context.Context, ...SegmentFilter) (int, int)) *MockSegmentManager_RemoveByCtx_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveByWaitPins provides a mock function with given fields: timeout, filters
+func (_m *MockSegmentManager) RemoveByWaitPins(timeout time.Duration, filters ...SegmentFilter) (int, int, []int64) {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, timeout)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 int
+	var r1 int
+	var r2 []int64
+	if rf, ok := ret.Get(0).(func(time.Duration, ...SegmentFilter) (int, int, []int64)); ok {
+		return rf(timeout, filters...)
+	}
+	if rf, ok := ret.Get(0).(func(time.Duration, ...SegmentFilter) int); ok {
+		r0 = rf(timeout, filters...)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Duration, ...SegmentFilter) int); ok {
+		r1 = rf(timeout, filters...)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(time.Duration, ...SegmentFilter) []int64); ok {
+		r2 = rf(timeout, filters...)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).([]int64)
+		}
+	}
+
+	return r0, r1, r2
+}
+
+// MockSegmentManager_RemoveByWaitPins_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveByWaitPins'
+type MockSegmentManager_RemoveByWaitPins_Call struct {
+	*mock.Call
+}
+
+// RemoveByWaitPins is a helper method to define mock.On call
+//   - timeout time.Duration
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) RemoveByWaitPins(timeout interface{}, filters ...interface{}) *MockSegmentManager_RemoveByWaitPins_Call {
+	return &MockSegmentManager_RemoveByWaitPins_Call{Call: _e.mock.On("RemoveByWaitPins",
+		append([]interface{}{timeout}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_RemoveByWaitPins_Call) Run(run func(timeout time.Duration, filters ...SegmentFilter)) *MockSegmentManager_RemoveByWaitPins_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(args[0].(time.Duration), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_RemoveByWaitPins_Call) Return(_a0 int, _a1 int, _a2 []int64) *MockSegmentManager_RemoveByWaitPins_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockSegmentManager_RemoveByWaitPins_Call) RunAndReturn(run func(time.Duration, ...SegmentFilter) (int, int, []int64)) *MockSegmentManager_RemoveByWaitPins_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveCtx provides a mock function with given fields: ctx, segmentID, scope
+func (_m *MockSegmentManager) RemoveCtx(ctx context.Context, segmentID int64, scope querypb.DataScope) (int, int) {
+	ret := _m.Called(ctx, segmentID, scope)
+
+	var r0 int
+	var r1 int
+	if rf, ok := ret.Get(0).(func(context.Context, int64, querypb.DataScope) (int, int)); ok {
+		return rf(ctx, segmentID, scope)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, querypb.DataScope) int); ok {
+		r0 = rf(ctx, segmentID, scope)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, querypb.DataScope) int); ok {
+		r1 = rf(ctx, segmentID, scope)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_RemoveCtx_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveCtx'
+type MockSegmentManager_RemoveCtx_Call struct {
+	*mock.Call
+}
+
+// RemoveCtx is a helper method to define mock.On call
+//   - ctx context.Context
+//   - segmentID int64
+//   - scope querypb.DataScope
+func (_e *MockSegmentManager_Expecter) RemoveCtx(ctx interface{}, segmentID interface{}, scope interface{}) *MockSegmentManager_RemoveCtx_Call {
+	return &MockSegmentManager_RemoveCtx_Call{Call: _e.mock.On("RemoveCtx", ctx, segmentID, scope)}
+}
+
+func (_c *MockSegmentManager_RemoveCtx_Call) Run(run func(ctx context.Context, segmentID int64, scope querypb.DataScope)) *MockSegmentManager_RemoveCtx_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64), args[2].(querypb.DataScope))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_RemoveCtx_Call) Return(_a0 int, _a1 int) *MockSegmentManager_RemoveCtx_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_RemoveCtx_Call) RunAndReturn(run func(context.Context, int64, querypb.DataScope) (int, int)) *MockSegmentManager_RemoveCtx_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SealedCount provides a mock function with given fields:
+func (_m *MockSegmentManager) SealedCount() int {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
 	return r0
 }
 
-// MockSegmentManager_GetSealed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSealed'
-type MockSegmentManager_GetSealed_Call struct {
+// MockSegmentManager_SealedCount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SealedCount'
+type MockSegmentManager_SealedCount_Call struct {
 	*mock.Call
 }
 
-// GetSealed is a helper method to define mock.On call
+// SealedCount is a helper method to define mock.On call
+func (_e *MockSegmentManager_Expecter) SealedCount() *MockSegmentManager_SealedCount_Call {
+	return &MockSegmentManager_SealedCount_Call{Call: _e.mock.On("SealedCount")}
+}
+
+func (_c *MockSegmentManager_SealedCount_Call) Run(run func()) *MockSegmentManager_SealedCount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_SealedCount_Call) Return(_a0 int) *MockSegmentManager_SealedCount_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_SealedCount_Call) RunAndReturn(run func() int) *MockSegmentManager_SealedCount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetReadOnly provides a mock function with given fields: segmentID, ro
+func (_m *MockSegmentManager) SetReadOnly(segmentID int64, ro bool) {
+	_m.Called(segmentID, ro)
+}
+
+// MockSegmentManager_SetReadOnly_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetReadOnly'
+type MockSegmentManager_SetReadOnly_Call struct {
+	*mock.Call
+}
+
+// SetReadOnly is a helper method to define mock.On call
 //   - segmentID int64
-func (_e *MockSegmentManager_Expecter) GetSealed(segmentID interface{}) *MockSegmentManager_GetSealed_Call {
-	return &MockSegmentManager_GetSealed_Call{Call: _e.mock.On("GetSealed", segmentID)}
+//   - ro bool
+func (_e *MockSegmentManager_Expecter) SetReadOnly(segmentID interface{}, ro interface{}) *MockSegmentManager_SetReadOnly_Call {
+	return &MockSegmentManager_SetReadOnly_Call{Call: _e.mock.On("SetReadOnly", segmentID, ro)}
 }
 
-func (_c *MockSegmentManager_GetSealed_Call) Run(run func(segmentID int64)) *MockSegmentManager_GetSealed_Call {
+func (_c *MockSegmentManager_SetReadOnly_Call) Run(run func(segmentID int64, ro bool)) *MockSegmentManager_SetReadOnly_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(int64))
+		run(args[0].(int64), args[1].(bool))
 	})
 	return _c
 }
 
-func (_c *MockSegmentManager_GetSealed_Call) Return(_a0 Segment) *MockSegmentManager_GetSealed_Call {
+func (_c *MockSegmentManager_SetReadOnly_Call) Return() *MockSegmentManager_SetReadOnly_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockSegmentManager_SetReadOnly_Call) RunAndReturn(run func(int64, bool)) *MockSegmentManager_SetReadOnly_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetVersions provides a mock function with given fields: target, filters
+func (_m *MockSegmentManager) SetVersions(target int64, filters ...SegmentFilter) ([]int64, []int64) {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, target)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []int64
+	var r1 []int64
+	if rf, ok := ret.Get(0).(func(int64, ...SegmentFilter) ([]int64, []int64)); ok {
+		return rf(target, filters...)
+	}
+	if rf, ok := ret.Get(0).(func(int64, ...SegmentFilter) []int64); ok {
+		r0 = rf(target, filters...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, ...SegmentFilter) []int64); ok {
+		r1 = rf(target, filters...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]int64)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_SetVersions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetVersions'
+type MockSegmentManager_SetVersions_Call struct {
+	*mock.Call
+}
+
+// SetVersions is a helper method to define mock.On call
+//   - target int64
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) SetVersions(target interface{}, filters ...interface{}) *MockSegmentManager_SetVersions_Call {
+	return &MockSegmentManager_SetVersions_Call{Call: _e.mock.On("SetVersions",
+		append([]interface{}{target}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_SetVersions_Call) Run(run func(target int64, filters ...SegmentFilter)) *MockSegmentManager_SetVersions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(args[0].(int64), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_SetVersions_Call) Return(_a0 []int64, _a1 []int64) *MockSegmentManager_SetVersions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_SetVersions_Call) RunAndReturn(run func(int64, ...SegmentFilter) ([]int64, []int64)) *MockSegmentManager_SetVersions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ThrashingSegments provides a mock function with given fields: minCycles, window
+func (_m *MockSegmentManager) ThrashingSegments(minCycles int, window time.Duration) []int64 {
+	ret := _m.Called(minCycles, window)
+
+	var r0 []int64
+	if rf, ok := ret.Get(0).(func(int, time.Duration) []int64); ok {
+		r0 = rf(minCycles, window)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_ThrashingSegments_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ThrashingSegments'
+type MockSegmentManager_ThrashingSegments_Call struct {
+	*mock.Call
+}
+
+// ThrashingSegments is a helper method to define mock.On call
+//   - minCycles int
+//   - window time.Duration
+func (_e *MockSegmentManager_Expecter) ThrashingSegments(minCycles interface{}, window interface{}) *MockSegmentManager_ThrashingSegments_Call {
+	return &MockSegmentManager_ThrashingSegments_Call{Call: _e.mock.On("ThrashingSegments", minCycles, window)}
+}
+
+func (_c *MockSegmentManager_ThrashingSegments_Call) Run(run func(minCycles int, window time.Duration)) *MockSegmentManager_ThrashingSegments_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_ThrashingSegments_Call) Return(_a0 []int64) *MockSegmentManager_ThrashingSegments_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockSegmentManager_GetSealed_Call) RunAndReturn(run func(int64) Segment) *MockSegmentManager_GetSealed_Call {
+func (_c *MockSegmentManager_ThrashingSegments_Call) RunAndReturn(run func(int, time.Duration) []int64) *MockSegmentManager_ThrashingSegments_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetWithType provides a mock function with given fields: segmentID, typ
-func (_m *MockSegmentManager) GetWithType(segmentID int64, typ commonpb.SegmentState) Segment {
-	ret := _m.Called(segmentID, typ)
+// TopByResource provides a mock function with given fields: n, by
+func (_m *MockSegmentManager) TopByResource(n int, by ResourceDimension) []Segment {
+	ret := _m.Called(n, by)
 
-	var r0 Segment
-	if rf, ok := ret.Get(0).(func(int64, commonpb.SegmentState) Segment); ok {
-		r0 = rf(segmentID, typ)
+	var r0 []Segment
+	if rf, ok := ret.Get(0).(func(int, ResourceDimension) []Segment); ok {
+		r0 = rf(n, by)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(Segment)
+			r0 = ret.Get(0).([]Segment)
 		}
 	}
 
 	return r0
 }
 
-// MockSegmentManager_GetWithType_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWithType'
-type MockSegmentManager_GetWithType_Call struct {
+// MockSegmentManager_TopByResource_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TopByResource'
+type MockSegmentManager_TopByResource_Call struct {
 	*mock.Call
 }
 
-// GetWithType is a helper method to define mock.On call
-//   - segmentID int64
-//   - typ commonpb.SegmentState
-func (_e *MockSegmentManager_Expecter) GetWithType(segmentID interface{}, typ interface{}) *MockSegmentManager_GetWithType_Call {
-	return &MockSegmentManager_GetWithType_Call{Call: _e.mock.On("GetWithType", segmentID, typ)}
+// TopByResource is a helper method to define mock.On call
+//   - n int
+//   - by ResourceDimension
+func (_e *MockSegmentManager_Expecter) TopByResource(n interface{}, by interface{}) *MockSegmentManager_TopByResource_Call {
+	return &MockSegmentManager_TopByResource_Call{Call: _e.mock.On("TopByResource", n, by)}
 }
 
-func (_c *MockSegmentManager_GetWithType_Call) Run(run func(segmentID int64, typ commonpb.SegmentState)) *MockSegmentManager_GetWithType_Call {
+func (_c *MockSegmentManager_TopByResource_Call) Run(run func(n int, by ResourceDimension)) *MockSegmentManager_TopByResource_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(int64), args[1].(commonpb.SegmentState))
+		run(args[0].(int), args[1].(ResourceDimension))
 	})
 	return _c
 }
 
-func (_c *MockSegmentManager_GetWithType_Call) Return(_a0 Segment) *MockSegmentManager_GetWithType_Call {
+func (_c *MockSegmentManager_TopByResource_Call) Return(_a0 []Segment) *MockSegmentManager_TopByResource_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockSegmentManager_GetWithType_Call) RunAndReturn(run func(int64, commonpb.SegmentState) Segment) *MockSegmentManager_GetWithType_Call {
+func (_c *MockSegmentManager_TopByResource_Call) RunAndReturn(run func(int, ResourceDimension) []Segment) *MockSegmentManager_TopByResource_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Put provides a mock function with given fields: segmentType, segments
-func (_m *MockSegmentManager) Put(segmentType commonpb.SegmentState, segments ...Segment) {
+// TryPut provides a mock function with given fields: ctx, segmentType, segments
+func (_m *MockSegmentManager) TryPut(ctx context.Context, segmentType commonpb.SegmentState, segments ...Segment) error {
 	_va := make([]interface{}, len(segments))
 	for _i := range segments {
 		_va[_i] = segments[_i]
 	}
 	var _ca []interface{}
+	_ca = append(_ca, ctx)
 	_ca = append(_ca, segmentType)
 	_ca = append(_ca, _va...)
-	_m.Called(_ca...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, commonpb.SegmentState, ...Segment) error); ok {
+		r0 = rf(ctx, segmentType, segments...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// MockSegmentManager_Put_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Put'
-type MockSegmentManager_Put_Call struct {
+// MockSegmentManager_TryPut_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TryPut'
+type MockSegmentManager_TryPut_Call struct {
 	*mock.Call
 }
 
-// Put is a helper method to define mock.On call
+// TryPut is a helper method to define mock.On call
+//   - ctx context.Context
 //   - segmentType commonpb.SegmentState
 //   - segments ...Segment
-func (_e *MockSegmentManager_Expecter) Put(segmentType interface{}, segments ...interface{}) *MockSegmentManager_Put_Call {
-	return &MockSegmentManager_Put_Call{Call: _e.mock.On("Put",
-		append([]interface{}{segmentType}, segments...)...)}
+func (_e *MockSegmentManager_Expecter) TryPut(ctx interface{}, segmentType interface{}, segments ...interface{}) *MockSegmentManager_TryPut_Call {
+	return &MockSegmentManager_TryPut_Call{Call: _e.mock.On("TryPut",
+		append([]interface{}{ctx, segmentType}, segments...)...)}
 }
 
-func (_c *MockSegmentManager_Put_Call) Run(run func(segmentType commonpb.SegmentState, segments ...Segment)) *MockSegmentManager_Put_Call {
+func (_c *MockSegmentManager_TryPut_Call) Run(run func(ctx context.Context, segmentType commonpb.SegmentState, segments ...Segment)) *MockSegmentManager_TryPut_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		variadicArgs := make([]Segment, len(args)-1)
-		for i, a := range args[1:] {
+		variadicArgs := make([]Segment, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(Segment)
 			}
 		}
-		run(args[0].(commonpb.SegmentState), variadicArgs...)
+		run(args[0].(context.Context), args[1].(commonpb.SegmentState), variadicArgs...)
 	})
 	return _c
 }
 
-func (_c *MockSegmentManager_Put_Call) Return() *MockSegmentManager_Put_Call {
-	_c.Call.Return()
+func (_c *MockSegmentManager_TryPut_Call) Return(_a0 error) *MockSegmentManager_TryPut_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockSegmentManager_Put_Call) RunAndReturn(run func(commonpb.SegmentState, ...Segment)) *MockSegmentManager_Put_Call {
+func (_c *MockSegmentManager_TryPut_Call) RunAndReturn(run func(context.Context, commonpb.SegmentState, ...Segment) error) *MockSegmentManager_TryPut_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Remove provides a mock function with given fields: segmentID, scope
-func (_m *MockSegmentManager) Remove(segmentID int64, scope querypb.DataScope) (int, int) {
-	ret := _m.Called(segmentID, scope)
-
-	var r0 int
-	var r1 int
-	if rf, ok := ret.Get(0).(func(int64, querypb.DataScope) (int, int)); ok {
-		return rf(segmentID, scope)
-	}
-	if rf, ok := ret.Get(0).(func(int64, querypb.DataScope) int); ok {
-		r0 = rf(segmentID, scope)
-	} else {
-		r0 = ret.Get(0).(int)
-	}
+// UnhealthySegments provides a mock function with given fields: threshold
+func (_m *MockSegmentManager) UnhealthySegments(threshold int) []int64 {
+	ret := _m.Called(threshold)
 
-	if rf, ok := ret.Get(1).(func(int64, querypb.DataScope) int); ok {
-		r1 = rf(segmentID, scope)
+	var r0 []int64
+	if rf, ok := ret.Get(0).(func(int) []int64); ok {
+		r0 = rf(threshold)
 	} else {
-		r1 = ret.Get(1).(int)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
 	}
 
-	return r0, r1
+	return r0
 }
 
-// MockSegmentManager_Remove_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Remove'
-type MockSegmentManager_Remove_Call struct {
+// MockSegmentManager_UnhealthySegments_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UnhealthySegments'
+type MockSegmentManager_UnhealthySegments_Call struct {
 	*mock.Call
 }
 
-// Remove is a helper method to define mock.On call
-//   - segmentID int64
-//   - scope querypb.DataScope
-func (_e *MockSegmentManager_Expecter) Remove(segmentID interface{}, scope interface{}) *MockSegmentManager_Remove_Call {
-	return &MockSegmentManager_Remove_Call{Call: _e.mock.On("Remove", segmentID, scope)}
+// UnhealthySegments is a helper method to define mock.On call
+//   - threshold int
+func (_e *MockSegmentManager_Expecter) UnhealthySegments(threshold interface{}) *MockSegmentManager_UnhealthySegments_Call {
+	return &MockSegmentManager_UnhealthySegments_Call{Call: _e.mock.On("UnhealthySegments", threshold)}
 }
 
-func (_c *MockSegmentManager_Remove_Call) Run(run func(segmentID int64, scope querypb.DataScope)) *MockSegmentManager_Remove_Call {
+func (_c *MockSegmentManager_UnhealthySegments_Call) Run(run func(threshold int)) *MockSegmentManager_UnhealthySegments_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(int64), args[1].(querypb.DataScope))
+		run(args[0].(int))
 	})
 	return _c
 }
 
-func (_c *MockSegmentManager_Remove_Call) Return(_a0 int, _a1 int) *MockSegmentManager_Remove_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *MockSegmentManager_UnhealthySegments_Call) Return(_a0 []int64) *MockSegmentManager_UnhealthySegments_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockSegmentManager_Remove_Call) RunAndReturn(run func(int64, querypb.DataScope) (int, int)) *MockSegmentManager_Remove_Call {
+func (_c *MockSegmentManager_UnhealthySegments_Call) RunAndReturn(run func(int) []int64) *MockSegmentManager_UnhealthySegments_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RemoveBy provides a mock function with given fields: filters
-func (_m *MockSegmentManager) RemoveBy(filters ...SegmentFilter) (int, int) {
-	_va := make([]interface{}, len(filters))
-	for _i := range filters {
-		_va[_i] = filters[_i]
-	}
-	var _ca []interface{}
-	_ca = append(_ca, _va...)
-	ret := _m.Called(_ca...)
-
-	var r0 int
-	var r1 int
-	if rf, ok := ret.Get(0).(func(...SegmentFilter) (int, int)); ok {
-		return rf(filters...)
-	}
-	if rf, ok := ret.Get(0).(func(...SegmentFilter) int); ok {
-		r0 = rf(filters...)
-	} else {
-		r0 = ret.Get(0).(int)
-	}
-
-	if rf, ok := ret.Get(1).(func(...SegmentFilter) int); ok {
-		r1 = rf(filters...)
-	} else {
-		r1 = ret.Get(1).(int)
-	}
-
-	return r0, r1
+// Unpin provides a mock function with given fields: segments
+func (_m *MockSegmentManager) Unpin(segments []Segment) {
+	_m.Called(segments)
 }
 
-// MockSegmentManager_RemoveBy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveBy'
-type MockSegmentManager_RemoveBy_Call struct {
+// MockSegmentManager_Unpin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unpin'
+type MockSegmentManager_Unpin_Call struct {
 	*mock.Call
 }
 
-// RemoveBy is a helper method to define mock.On call
-//   - filters ...SegmentFilter
-func (_e *MockSegmentManager_Expecter) RemoveBy(filters ...interface{}) *MockSegmentManager_RemoveBy_Call {
-	return &MockSegmentManager_RemoveBy_Call{Call: _e.mock.On("RemoveBy",
-		append([]interface{}{}, filters...)...)}
+// Unpin is a helper method to define mock.On call
+//   - segments []Segment
+func (_e *MockSegmentManager_Expecter) Unpin(segments interface{}) *MockSegmentManager_Unpin_Call {
+	return &MockSegmentManager_Unpin_Call{Call: _e.mock.On("Unpin", segments)}
 }
 
-func (_c *MockSegmentManager_RemoveBy_Call) Run(run func(filters ...SegmentFilter)) *MockSegmentManager_RemoveBy_Call {
+func (_c *MockSegmentManager_Unpin_Call) Run(run func(segments []Segment)) *MockSegmentManager_Unpin_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		variadicArgs := make([]SegmentFilter, len(args)-0)
-		for i, a := range args[0:] {
-			if a != nil {
-				variadicArgs[i] = a.(SegmentFilter)
-			}
-		}
-		run(variadicArgs...)
+		run(args[0].([]Segment))
 	})
 	return _c
 }
 
-func (_c *MockSegmentManager_RemoveBy_Call) Return(_a0 int, _a1 int) *MockSegmentManager_RemoveBy_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *MockSegmentManager_Unpin_Call) Return() *MockSegmentManager_Unpin_Call {
+	_c.Call.Return()
 	return _c
 }
 
-func (_c *MockSegmentManager_RemoveBy_Call) RunAndReturn(run func(...SegmentFilter) (int, int)) *MockSegmentManager_RemoveBy_Call {
+func (_c *MockSegmentManager_Unpin_Call) RunAndReturn(run func([]Segment)) *MockSegmentManager_Unpin_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Unpin provides a mock function with given fields: segments
-func (_m *MockSegmentManager) Unpin(segments []Segment) {
-	_m.Called(segments)
+// Unquarantine provides a mock function with given fields: segmentID
+func (_m *MockSegmentManager) Unquarantine(segmentID int64) {
+	_m.Called(segmentID)
 }
 
-// MockSegmentManager_Unpin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unpin'
-type MockSegmentManager_Unpin_Call struct {
+// MockSegmentManager_Unquarantine_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unquarantine'
+type MockSegmentManager_Unquarantine_Call struct {
 	*mock.Call
 }
 
-// Unpin is a helper method to define mock.On call
-//   - segments []Segment
-func (_e *MockSegmentManager_Expecter) Unpin(segments interface{}) *MockSegmentManager_Unpin_Call {
-	return &MockSegmentManager_Unpin_Call{Call: _e.mock.On("Unpin", segments)}
+// Unquarantine is a helper method to define mock.On call
+//   - segmentID int64
+func (_e *MockSegmentManager_Expecter) Unquarantine(segmentID interface{}) *MockSegmentManager_Unquarantine_Call {
+	return &MockSegmentManager_Unquarantine_Call{Call: _e.mock.On("Unquarantine", segmentID)}
 }
 
-func (_c *MockSegmentManager_Unpin_Call) Run(run func(segments []Segment)) *MockSegmentManager_Unpin_Call {
+func (_c *MockSegmentManager_Unquarantine_Call) Run(run func(segmentID int64)) *MockSegmentManager_Unquarantine_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].([]Segment))
+		run(args[0].(int64))
 	})
 	return _c
 }
 
-func (_c *MockSegmentManager_Unpin_Call) Return() *MockSegmentManager_Unpin_Call {
+func (_c *MockSegmentManager_Unquarantine_Call) Return() *MockSegmentManager_Unquarantine_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *MockSegmentManager_Unpin_Call) RunAndReturn(run func([]Segment)) *MockSegmentManager_Unpin_Call {
+func (_c *MockSegmentManager_Unquarantine_Call) RunAndReturn(run func(int64)) *MockSegmentManager_Unquarantine_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -721,6 +3189,163 @@ func (_c *MockSegmentManager_UpdateBy_Call) RunAndReturn(run func(SegmentAction,
 	return _c
 }
 
+// UpdateByExclusive provides a mock function with given fields: action, filters
+func (_m *MockSegmentManager) UpdateByExclusive(action SegmentAction, filters ...SegmentFilter) int {
+	_va := make([]interface{}, len(filters))
+	for _i := range filters {
+		_va[_i] = filters[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, action)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(SegmentAction, ...SegmentFilter) int); ok {
+		r0 = rf(action, filters...)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// MockSegmentManager_UpdateByExclusive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateByExclusive'
+type MockSegmentManager_UpdateByExclusive_Call struct {
+	*mock.Call
+}
+
+// UpdateByExclusive is a helper method to define mock.On call
+//   - action SegmentAction
+//   - filters ...SegmentFilter
+func (_e *MockSegmentManager_Expecter) UpdateByExclusive(action interface{}, filters ...interface{}) *MockSegmentManager_UpdateByExclusive_Call {
+	return &MockSegmentManager_UpdateByExclusive_Call{Call: _e.mock.On("UpdateByExclusive",
+		append([]interface{}{action}, filters...)...)}
+}
+
+func (_c *MockSegmentManager_UpdateByExclusive_Call) Run(run func(action SegmentAction, filters ...SegmentFilter)) *MockSegmentManager_UpdateByExclusive_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]SegmentFilter, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(SegmentFilter)
+			}
+		}
+		run(args[0].(SegmentAction), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_UpdateByExclusive_Call) Return(_a0 int) *MockSegmentManager_UpdateByExclusive_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_UpdateByExclusive_Call) RunAndReturn(run func(SegmentAction, ...SegmentFilter) int) *MockSegmentManager_UpdateByExclusive_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// VersionLag provides a mock function with given fields: latest
+func (_m *MockSegmentManager) VersionLag(latest map[int64]int64) map[int64]int64 {
+	ret := _m.Called(latest)
+
+	var r0 map[int64]int64
+	if rf, ok := ret.Get(0).(func(map[int64]int64) map[int64]int64); ok {
+		r0 = rf(latest)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int64]int64)
+		}
+	}
+
+	return r0
+}
+
+// MockSegmentManager_VersionLag_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'VersionLag'
+type MockSegmentManager_VersionLag_Call struct {
+	*mock.Call
+}
+
+// VersionLag is a helper method to define mock.On call
+//   - latest map[int64]int64
+func (_e *MockSegmentManager_Expecter) VersionLag(latest interface{}) *MockSegmentManager_VersionLag_Call {
+	return &MockSegmentManager_VersionLag_Call{Call: _e.mock.On("VersionLag", latest)}
+}
+
+func (_c *MockSegmentManager_VersionLag_Call) Run(run func(latest map[int64]int64)) *MockSegmentManager_VersionLag_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(map[int64]int64))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_VersionLag_Call) Return(_a0 map[int64]int64) *MockSegmentManager_VersionLag_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSegmentManager_VersionLag_Call) RunAndReturn(run func(map[int64]int64) map[int64]int64) *MockSegmentManager_VersionLag_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WaitForSegment provides a mock function with given fields: ctx, segmentID, typ
+func (_m *MockSegmentManager) WaitForSegment(ctx context.Context, segmentID int64, typ commonpb.SegmentState) (Segment, error) {
+	ret := _m.Called(ctx, segmentID, typ)
+
+	var r0 Segment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, commonpb.SegmentState) (Segment, error)); ok {
+		return rf(ctx, segmentID, typ)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, commonpb.SegmentState) Segment); ok {
+		r0 = rf(ctx, segmentID, typ)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Segment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, commonpb.SegmentState) error); ok {
+		r1 = rf(ctx, segmentID, typ)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockSegmentManager_WaitForSegment_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WaitForSegment'
+type MockSegmentManager_WaitForSegment_Call struct {
+	*mock.Call
+}
+
+// WaitForSegment is a helper method to define mock.On call
+//   - ctx context.Context
+//   - segmentID int64
+//   - typ commonpb.SegmentState
+func (_e *MockSegmentManager_Expecter) WaitForSegment(ctx interface{}, segmentID interface{}, typ interface{}) *MockSegmentManager_WaitForSegment_Call {
+	return &MockSegmentManager_WaitForSegment_Call{Call: _e.mock.On("WaitForSegment", ctx, segmentID, typ)}
+}
+
+func (_c *MockSegmentManager_WaitForSegment_Call) Run(run func(ctx context.Context, segmentID int64, typ commonpb.SegmentState)) *MockSegmentManager_WaitForSegment_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64), args[2].(commonpb.SegmentState))
+	})
+	return _c
+}
+
+func (_c *MockSegmentManager_WaitForSegment_Call) Return(_a0 Segment, _a1 error) *MockSegmentManager_WaitForSegment_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSegmentManager_WaitForSegment_Call) RunAndReturn(run func(context.Context, int64, commonpb.SegmentState) (Segment, error)) *MockSegmentManager_WaitForSegment_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockSegmentManager creates a new instance of MockSegmentManager. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockSegmentManager(t interface {
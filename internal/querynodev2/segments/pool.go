@@ -43,6 +43,9 @@ var (
 	dynOnce  sync.Once
 	loadPool atomic.Pointer[conc.Pool[any]]
 	loadOnce sync.Once
+	// releasePool bounds the concurrency of the segment releases done in background
+	releasePool atomic.Pointer[conc.Pool[any]]
+	releaseOnce sync.Once
 )
 
 // initSQPool initialize
@@ -96,6 +99,19 @@ func initLoadPool() {
 	})
 }
 
+func initReleasePool() {
+	releaseOnce.Do(func() {
+		pool := conc.NewPool[any](
+			hardware.GetCPUNum(),
+			conc.WithPreAlloc(false),
+			conc.WithDisablePurge(false),
+			conc.WithPreHandler(runtime.LockOSThread), // lock os thread for cgo thread disposal
+		)
+
+		releasePool.Store(pool)
+	})
+}
+
 // GetSQPool returns the singleton pool instance for search/query operations.
 func GetSQPool() *conc.Pool[any] {
 	initSQPool()
@@ -113,6 +129,12 @@ func GetLoadPool() *conc.Pool[any] {
 	return loadPool.Load()
 }
 
+// GetReleasePool returns the singleton pool for releasing segments in background.
+func GetReleasePool() *conc.Pool[any] {
+	initReleasePool()
+	return releasePool.Load()
+}
+
 func ResizeSQPool(evt *config.Event) {
 	if evt.HasUpdated {
 		pt := paramtable.Get()
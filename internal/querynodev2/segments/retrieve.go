@@ -68,7 +68,7 @@ func retrieveOnSegments(ctx context.Context, mgr *Manager, segments []Segment, s
 
 			var err error
 			if seg.LoadStatus() == LoadStatusMeta {
-				err = mgr.DiskCache.Do(seg.ID(), retriever)
+				err = mgr.DiskCache.Do(ctx, seg.ID(), retriever)
 			} else {
 				err = retriever(seg)
 			}
@@ -78,7 +78,7 @@ func searchSegments(ctx context.Context, mgr *Manager, segments []Segment, segTy
 			}
 			var err error
 			if seg.LoadStatus() == LoadStatusMeta {
-				err = mgr.DiskCache.Do(seg.ID(), searcher)
+				err = mgr.DiskCache.Do(ctx, seg.ID(), searcher)
 			} else {
 				err = searcher(seg)
 			}
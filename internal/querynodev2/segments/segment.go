@@ -37,6 +37,7 @@ import (
 	"github.com/apache/arrow/go/v12/arrow/array"
 	"github.com/cockroachdb/errors"
 	"github.com/golang/protobuf/proto"
+	"github.com/samber/lo"
 	"go.opentelemetry.io/otel"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
@@ -90,6 +91,9 @@ type baseSegment struct {
 	loadInfo       *querypb.SegmentLoadInfo
 
 	resourceUsageCache *atomic.Pointer[ResourceUsage]
+
+	// the channel of the segment, initialized by the insert channel of the load info and changed by SetShard
+	shard *atomic.String
 }
 
 func newBaseSegment(collection *Collection, segmentType SegmentType, version int64, loadInfo *querypb.SegmentLoadInfo) baseSegment {
@@ -98,6 +102,7 @@ func newBaseSegment(collection *Collection, segmentType SegmentType, version int
 		loadInfo:       loadInfo,
 		version:        atomic.NewInt64(version),
 		loadStatus:     atomic.NewString(string(LoadStatusMeta)),
+		shard:          atomic.NewString(loadInfo.GetInsertChannel()),
 		segmentType:    segmentType,
 		bloomFilterSet: pkoracle.NewBloomFilterSet(loadInfo.GetSegmentID(), loadInfo.GetPartitionID(), segmentType),
 
@@ -119,7 +124,11 @@ func (s *baseSegment) Partition() int64 {
 }
 
 func (s *baseSegment) Shard() string {
-	return s.loadInfo.GetInsertChannel()
+	return s.shard.Load()
+}
+
+func (s *baseSegment) SetShard(channel string) {
+	s.shard.Store(channel)
 }
 
 func (s *baseSegment) Type() SegmentType {
@@ -203,16 +212,19 @@ type LocalSegment struct {
 	baseSegment
 	ptrLock sync.RWMutex // protects segmentPtr
 	ptr     C.CSegmentInterface
+	loadMu  sync.Mutex // serializes the field loading from all the entry points
 
 	// cached results, to avoid too many CGO calls
 	memSize     *atomic.Int64
 	rowNum      *atomic.Int64
 	insertCount *atomic.Int64
 
-	lastDeltaTimestamp *atomic.Uint64
-	fields             *typeutil.ConcurrentMap[int64, *FieldInfo]
-	fieldIndexes       *typeutil.ConcurrentMap[int64, *IndexedFieldInfo]
-	space              *milvus_storage.Space
+	lastDeltaTimestamp   *atomic.Uint64
+	firstInsertTimestamp *atomic.Uint64
+	lastInsertTimestamp  *atomic.Uint64
+	fields               *typeutil.ConcurrentMap[int64, *FieldInfo]
+	fieldIndexes         *typeutil.ConcurrentMap[int64, *IndexedFieldInfo]
+	space                *milvus_storage.Space
 }
 
 func NewSegment(ctx context.Context,
@@ -262,11 +274,13 @@ func NewSegment(ctx context.Context,
 	)
 
 	segment := &LocalSegment{
-		baseSegment:        newBaseSegment(collection, segmentType, version, loadInfo),
-		ptr:                newPtr,
-		lastDeltaTimestamp: atomic.NewUint64(0),
-		fields:             typeutil.NewConcurrentMap[int64, *FieldInfo](),
-		fieldIndexes:       typeutil.NewConcurrentMap[int64, *IndexedFieldInfo](),
+		baseSegment:          newBaseSegment(collection, segmentType, version, loadInfo),
+		ptr:                  newPtr,
+		lastDeltaTimestamp:   atomic.NewUint64(0),
+		firstInsertTimestamp: atomic.NewUint64(0),
+		lastInsertTimestamp:  atomic.NewUint64(0),
+		fields:               typeutil.NewConcurrentMap[int64, *FieldInfo](),
+		fieldIndexes:         typeutil.NewConcurrentMap[int64, *IndexedFieldInfo](),
 
 		memSize:     atomic.NewInt64(-1),
 		rowNum:      atomic.NewInt64(-1),
@@ -325,15 +339,17 @@ func NewSegmentV2(
 	}
 
 	segment := &LocalSegment{
-		baseSegment:        newBaseSegment(collection, segmentType, version, loadInfo),
-		ptr:                segmentPtr,
-		lastDeltaTimestamp: atomic.NewUint64(0),
-		fields:             typeutil.NewConcurrentMap[int64, *FieldInfo](),
-		fieldIndexes:       typeutil.NewConcurrentMap[int64, *IndexedFieldInfo](),
-		space:              space,
-		memSize:            atomic.NewInt64(-1),
-		rowNum:             atomic.NewInt64(-1),
-		insertCount:        atomic.NewInt64(0),
+		baseSegment:          newBaseSegment(collection, segmentType, version, loadInfo),
+		ptr:                  segmentPtr,
+		lastDeltaTimestamp:   atomic.NewUint64(0),
+		firstInsertTimestamp: atomic.NewUint64(0),
+		lastInsertTimestamp:  atomic.NewUint64(0),
+		fields:               typeutil.NewConcurrentMap[int64, *FieldInfo](),
+		fieldIndexes:         typeutil.NewConcurrentMap[int64, *IndexedFieldInfo](),
+		space:                space,
+		memSize:              atomic.NewInt64(-1),
+		rowNum:               atomic.NewInt64(-1),
+		insertCount:          atomic.NewInt64(0),
 	}
 
 	if segmentType != SegmentTypeSealed {
@@ -419,6 +435,22 @@ func (s *LocalSegment) LastDeltaTimestamp() uint64 {
 	return s.lastDeltaTimestamp.Load()
 }
 
+func (s *LocalSegment) FirstInsertTimestamp() uint64 {
+	return s.firstInsertTimestamp.Load()
+}
+
+func (s *LocalSegment) LastInsertTimestamp() uint64 {
+	return s.lastInsertTimestamp.Load()
+}
+
+func (s *LocalSegment) MaxAppliedTimestamp() uint64 {
+	insertTs, deltaTs := s.LastInsertTimestamp(), s.LastDeltaTimestamp()
+	if deltaTs > insertTs {
+		return deltaTs
+	}
+	return insertTs
+}
+
 func (s *LocalSegment) addIndex(fieldID int64, info *IndexedFieldInfo) {
 	s.fieldIndexes.Insert(fieldID, info)
 }
@@ -648,6 +680,19 @@ func (s *LocalSegment) Insert(ctx context.Context, rowIDs []int64, timestamps []
 	s.insertCount.Add(int64(numOfRow))
 	s.rowNum.Store(-1)
 	s.memSize.Store(-1)
+
+	minTs := lo.Min(timestamps)
+	for firstTs := s.firstInsertTimestamp.Load(); firstTs == 0 || firstTs > minTs; firstTs = s.firstInsertTimestamp.Load() {
+		if s.firstInsertTimestamp.CompareAndSwap(firstTs, minTs) {
+			break
+		}
+	}
+	maxTs := lo.Max(timestamps)
+	for lastTs := s.lastInsertTimestamp.Load(); lastTs < maxTs; lastTs = s.lastInsertTimestamp.Load() {
+		if s.lastInsertTimestamp.CompareAndSwap(lastTs, maxTs) {
+			break
+		}
+	}
 	return nil
 }
 
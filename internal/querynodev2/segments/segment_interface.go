@@ -53,6 +53,8 @@ type Segment interface {
 	Collection() int64
 	Partition() int64
 	Shard() string
+	// SetShard relabels the channel of the segment, e.g. after the vchannel migration
+	SetShard(channel string)
 	Version() int64
 	CASVersion(int64, int64) bool
 	StartPosition() *msgpb.MsgPosition
@@ -83,6 +85,12 @@ type Segment interface {
 	Delete(ctx context.Context, primaryKeys []storage.PrimaryKey, timestamps []typeutil.Timestamp) error
 	LoadDeltaData(ctx context.Context, deltaData *storage.DeleteData) error
 	LastDeltaTimestamp() uint64
+	// FirstInsertTimestamp returns the min timestamp of the inserted rows, 0 if nothing inserted
+	FirstInsertTimestamp() uint64
+	// LastInsertTimestamp returns the max timestamp of the inserted rows, 0 if nothing inserted
+	LastInsertTimestamp() uint64
+	// MaxAppliedTimestamp returns the max timestamp of the inserts and deletes applied to the segment, 0 if none
+	MaxAppliedTimestamp() uint64
 	Release(opts ...releaseOption)
 
 	// Bloom filter related
@@ -99,6 +99,19 @@ func (s *L0Segment) LastDeltaTimestamp() uint64 {
 	return last
 }
 
+func (s *L0Segment) FirstInsertTimestamp() uint64 {
+	return 0
+}
+
+func (s *L0Segment) LastInsertTimestamp() uint64 {
+	return 0
+}
+
+// MaxAppliedTimestamp returns the last delta timestamp, nothing is inserted into the L0 segment.
+func (s *L0Segment) MaxAppliedTimestamp() uint64 {
+	return s.LastDeltaTimestamp()
+}
+
 func (s *L0Segment) GetIndex(fieldID int64) *IndexedFieldInfo {
 	return nil
 }
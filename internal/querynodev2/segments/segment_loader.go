@@ -78,6 +78,11 @@ type Loader interface {
 
 	// LoadIndex append index for segment and remove vector binlogs.
 	LoadIndex(ctx context.Context, segment *LocalSegment, info *querypb.SegmentLoadInfo, version int64) error
+
+	// EstimateLoadDuration estimates how long loading the sealed segment takes,
+	// by its estimated resource usage and the recent load throughput,
+	// 0 if the segment not found or no segment loaded yet.
+	EstimateLoadDuration(segmentID int64) time.Duration
 }
 
 type LoadResource struct {
@@ -218,7 +223,11 @@ func (loader *segmentLoaderV2) Load(ctx context.Context,
 			)
 			return err
 		}
-		loader.manager.Segment.Put(segmentType, segment)
+		if segmentType == SegmentTypeSealed {
+			usage := segment.ResourceUsageEstimate()
+			loader.throughput.observe(usage.MemorySize+usage.DiskSize, tr.ElapseSpan())
+		}
+		loader.manager.Segment.PutCtx(ctx, segmentType, segment)
 		newSegments.GetAndRemove(segmentID)
 		loaded.Insert(segmentID, segment)
 		log.Info("load segment done", zap.Int64("segmentID", segmentID))
@@ -458,6 +467,9 @@ func (loader *segmentLoaderV2) loadSegment(ctx context.Context,
 }
 
 func (loader *segmentLoaderV2) loadSealedSegmentFields(ctx context.Context, segment *LocalSegment, fields *typeutil.ConcurrentMap[int64, *schemapb.FieldSchema], rowCount int64) error {
+	segment.loadMu.Lock()
+	defer segment.loadMu.Unlock()
+
 	runningGroup, _ := errgroup.WithContext(ctx)
 	fields.Range(func(fieldID int64, field *schemapb.FieldSchema) bool {
 		runningGroup.Go(func() error {
@@ -534,10 +546,55 @@ func (r *loadResult) SetResult(status loadStatus) {
 	r.cond.Broadcast()
 }
 
+// loadThroughputWindow is the number of the recent segment loads averaged for the load throughput
+const loadThroughputWindow = 16
+
+// loadThroughput tracks the rolling average throughput of the recent segment loads.
+type loadThroughput struct {
+	mu        sync.Mutex
+	sizes     [loadThroughputWindow]uint64
+	durations [loadThroughputWindow]time.Duration
+	next      int
+	count     int
+}
+
+// observe records a segment load of the size taking the duration.
+func (t *loadThroughput) observe(size uint64, duration time.Duration) {
+	if duration <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sizes[t.next] = size
+	t.durations[t.next] = duration
+	t.next = (t.next + 1) % loadThroughputWindow
+	if t.count < loadThroughputWindow {
+		t.count++
+	}
+}
+
+// bytesPerSecond returns the average throughput of the recent loads, false if nothing observed.
+func (t *loadThroughput) bytesPerSecond() (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var size uint64
+	var duration time.Duration
+	for i := 0; i < t.count; i++ {
+		size += t.sizes[i]
+		duration += t.durations[i]
+	}
+	if duration <= 0 {
+		return 0, false
+	}
+	return float64(size) / duration.Seconds(), true
+}
+
 // segmentLoader is only responsible for loading the field data from binlog
 type segmentLoader struct {
 	manager *Manager
 	cm      storage.ChunkManager
+	// throughput is the throughput of the recent sealed segment loads
+	throughput loadThroughput
 
 	mut sync.Mutex
 	// The channel will be closed as the segment loaded
@@ -650,7 +707,11 @@ func (loader *segmentLoader) Load(ctx context.Context,
 			)
 			return err
 		}
-		loader.manager.Segment.Put(segmentType, segment)
+		if segmentType == SegmentTypeSealed {
+			usage := segment.ResourceUsageEstimate()
+			loader.throughput.observe(usage.MemorySize+usage.DiskSize, tr.ElapseSpan())
+		}
+		loader.manager.Segment.PutCtx(ctx, segmentType, segment)
 		newSegments.GetAndRemove(segmentID)
 		loaded.Insert(segmentID, segment)
 		log.Info("load segment done", zap.Int64("segmentID", segmentID))
@@ -1059,7 +1120,12 @@ func (loader *segmentLoader) filterPKStatsBinlogs(fieldBinlogs []*datapb.FieldBi
 	return result, storage.DefaultStatsType
 }
 
+// loadSealedSegmentFields loads the fields of the sealed segment,
+// the loading of the same segment is serialized, e.g. the loader and the disk cache may load it at the same time.
 func loadSealedSegmentFields(ctx context.Context, collection *Collection, segment *LocalSegment, fields []*datapb.FieldBinlog, rowCount int64, opts ...loadOption) error {
+	segment.loadMu.Lock()
+	defer segment.loadMu.Unlock()
+
 	options := newLoadOptions()
 	for _, opt := range opts {
 		opt(options)
@@ -1574,10 +1640,25 @@ func (loader *segmentLoader) LoadIndex(ctx context.Context, segment *LocalSegmen
 		}
 		loader.notifyLoadFinish(loadInfo)
 	}
+	// the segment is put before, re-index it by the build IDs of the loaded indexes
+	loader.manager.Segment.RefreshIndexes()
 
 	return loader.waitSegmentLoadDone(ctx, commonpb.SegmentState_SegmentStateNone, []int64{loadInfo.GetSegmentID()}, version)
 }
 
+func (loader *segmentLoader) EstimateLoadDuration(segmentID int64) time.Duration {
+	segment := loader.manager.Segment.GetSealed(segmentID)
+	if segment == nil {
+		return 0
+	}
+	bytesPerSecond, ok := loader.throughput.bytesPerSecond()
+	if !ok || bytesPerSecond <= 0 {
+		return 0
+	}
+	usage := segment.ResourceUsageEstimate()
+	return time.Duration(float64(usage.MemorySize+usage.DiskSize) / bytesPerSecond * float64(time.Second))
+}
+
 func getBinlogDataSize(fieldBinlog *datapb.FieldBinlog) int64 {
 	fieldSize := int64(0)
 	for _, binlog := range fieldBinlog.Binlogs {
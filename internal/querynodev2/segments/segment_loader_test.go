@@ -25,7 +25,9 @@ import (
 	"github.com/apache/arrow/go/v12/arrow"
 	"github.com/apache/arrow/go/v12/arrow/array"
 	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
@@ -37,12 +39,13 @@ import (
 	"github.com/milvus-io/milvus/internal/proto/querypb"
 	"github.com/milvus-io/milvus/internal/storage"
 	"github.com/milvus-io/milvus/internal/util/initcore"
-	"github.com/milvus-io/milvus/internal/util/typeutil"
+	typeutil_internal "github.com/milvus-io/milvus/internal/util/typeutil"
 	"github.com/milvus-io/milvus/pkg/common"
 	"github.com/milvus-io/milvus/pkg/util/funcutil"
 	"github.com/milvus-io/milvus/pkg/util/merr"
 	"github.com/milvus-io/milvus/pkg/util/metric"
 	"github.com/milvus-io/milvus/pkg/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
 type SegmentLoaderSuite struct {
@@ -808,6 +811,28 @@ func (suite *SegmentLoaderDetailSuite) TestRequestResource() {
 	})
 }
 
+func (suite *SegmentLoaderDetailSuite) TestEstimateLoadDuration() {
+	segment := NewMockSegment(suite.T())
+	segment.EXPECT().ResourceUsageEstimate().Return(ResourceUsage{MemorySize: 300 * 1024 * 1024, DiskSize: 100 * 1024 * 1024}).Maybe()
+	suite.segmentManager.EXPECT().GetSealed(suite.segmentID).Return(segment).Maybe()
+	suite.segmentManager.EXPECT().GetSealed(mock.Anything).Return(nil).Maybe()
+
+	// nothing loaded yet
+	suite.Zero(suite.loader.EstimateLoadDuration(suite.segmentID))
+
+	// 100MB/s on average
+	suite.loader.throughput.observe(100*1024*1024, 2*time.Second)
+	suite.loader.throughput.observe(300*1024*1024, 2*time.Second)
+	suite.Equal(4*time.Second, suite.loader.EstimateLoadDuration(suite.segmentID))
+	suite.Zero(suite.loader.EstimateLoadDuration(suite.segmentID + 1))
+
+	// the samples out of the window are rolled out
+	for i := 0; i < loadThroughputWindow; i++ {
+		suite.loader.throughput.observe(200*1024*1024, time.Second)
+	}
+	suite.Equal(2*time.Second, suite.loader.EstimateLoadDuration(suite.segmentID))
+}
+
 func TestSegmentLoader(t *testing.T) {
 	suite.Run(t, &SegmentLoaderSuite{})
 	suite.Run(t, &SegmentLoaderDetailSuite{})
@@ -880,7 +905,7 @@ func (suite *SegmentLoaderV2Suite) TestLoad() {
 
 	msgLength := 4
 
-	arrowSchema, err := typeutil.ConvertToArrowSchema(suite.schema.Fields)
+	arrowSchema, err := typeutil_internal.ConvertToArrowSchema(suite.schema.Fields)
 	suite.NoError(err)
 	opt := options.NewSpaceOptionBuilder().
 		SetSchema(schema.NewSchema(
@@ -891,7 +916,7 @@ func (suite *SegmentLoaderV2Suite) TestLoad() {
 				VersionColumn: "Timestamp",
 			})).
 		Build()
-	uri, err := typeutil.GetStorageURI("file", tmpDir, suite.segmentID)
+	uri, err := typeutil_internal.GetStorageURI("file", tmpDir, suite.segmentID)
 	suite.NoError(err)
 	space, err := milvus_storage.Open(uri, opt)
 	suite.NoError(err)
@@ -901,7 +926,7 @@ func (suite *SegmentLoaderV2Suite) TestLoad() {
 	insertData, err := genInsertData(msgLength, suite.schema)
 	suite.NoError(err)
 
-	err = typeutil.BuildRecord(b, insertData, suite.schema.Fields)
+	err = typeutil_internal.BuildRecord(b, insertData, suite.schema.Fields)
 	suite.NoError(err)
 	rec := b.NewRecord()
 	defer rec.Release()
@@ -959,3 +984,45 @@ func (suite *SegmentLoaderV2Suite) TestLoad() {
 func TestSegmentLoaderV2(t *testing.T) {
 	suite.Run(t, &SegmentLoaderV2Suite{})
 }
+
+func TestLoadSealedSegmentFieldsSerialized(t *testing.T) {
+	paramtable.Init()
+	ctx := context.Background()
+	manager := NewManager()
+	schema := GenTestCollectionSchema("load-serialized", schemapb.DataType_Int64, true)
+	manager.Collection.PutOrRef(100, schema, GenTestIndexMeta(100, schema), &querypb.LoadMetaInfo{
+		LoadType: querypb.LoadType_LoadCollection,
+	})
+	segment, err := NewSegment(ctx, manager.Collection.Get(100), SegmentTypeSealed, 0, &querypb.SegmentLoadInfo{
+		SegmentID:     1,
+		PartitionID:   10,
+		CollectionID:  100,
+		InsertChannel: "dml",
+	})
+	require.NoError(t, err)
+	manager.Segment.Put(SegmentTypeSealed, segment)
+	defer manager.Segment.Clear()
+	localSegment := segment.(*LocalSegment)
+
+	// hold the guard as an in-flight load
+	localSegment.loadMu.Lock()
+	done := make(chan error, 2)
+	// the loader and the disk cache load the same segment simultaneously
+	go func() {
+		loader := &segmentLoaderV2{}
+		done <- loader.loadSealedSegmentFields(ctx, localSegment, typeutil.NewConcurrentMap[int64, *schemapb.FieldSchema](), 0)
+	}()
+	go func() {
+		done <- manager.DiskCache.Do(ctx, segment.ID(), func(Segment) error { return nil })
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("the field loading shall wait for the in-flight one")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	localSegment.loadMu.Unlock()
+	assert.NoError(t, <-done)
+	assert.NoError(t, <-done)
+}
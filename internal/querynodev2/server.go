@@ -483,7 +483,11 @@ func (node *QueryNode) Stop() error {
 			node.dispClient.Close()
 		}
 		if node.manager != nil {
-			node.manager.Segment.Clear()
+			ctx, cancel := context.WithTimeout(context.Background(), paramtable.Get().QueryNodeCfg.GracefulStopTimeout.GetAsDuration(time.Second))
+			if err := node.manager.Segment.ClearWaitPins(ctx); err != nil {
+				log.Warn("failed to clear segments", zap.Error(err))
+			}
+			cancel()
 		}
 
 		node.CloseSegcore()
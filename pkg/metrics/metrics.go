@@ -70,6 +70,10 @@ const (
 	Executing = "executing"
 	Done      = "done"
 
+	// the singleflight call executed the load, or shared the result of the executing one
+	SingleflightLeaderLabel = "leader"
+	SingleflightSharedLabel = "shared"
+
 	compactionTypeLabelName  = "compaction_type"
 	nodeIDLabelName          = "node_id"
 	statusLabelName          = "status"
@@ -99,6 +103,8 @@ const (
 	lockType                 = "lock_type"
 	lockOp                   = "lock_op"
 	loadTypeName             = "load_type"
+	failureCategoryLabelName = "failure_category"
+	singleflightRole         = "singleflight_role"
 
 	// entities label
 	LoadedLabel         = "loaded"
@@ -56,6 +56,15 @@ var (
 			Name:      "op_count",
 			Help:      "count of stream message operation",
 		}, []string{msgStreamOpType, statusLabelName})
+
+	MsgStreamMarshalSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "marshal_size",
+			Help:      "size in bytes of marshaled stream messages",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 10), // 64B ~ 16MB
+		}, []string{msgTypeLabelName})
 )
 
 // RegisterMsgStreamMetrics registers msg stream metrics
@@ -63,4 +72,5 @@ func RegisterMsgStreamMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(NumConsumers)
 	registry.MustRegister(MsgStreamRequestLatency)
 	registry.MustRegister(MsgStreamOpCounter)
+	registry.MustRegister(MsgStreamMarshalSize)
 }
@@ -501,6 +501,85 @@ var (
 		}, []string{
 			nodeIDLabelName,
 		})
+
+	QueryNodeSegmentPinWaitLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryNodeRole,
+			Name:      "segment_pin_wait_latency",
+			Help:      "latency of waiting for the segment read lock when pinning segments, in milliseconds",
+			Buckets:   buckets,
+		}, []string{
+			nodeIDLabelName,
+			segmentStateLabelName,
+		})
+
+	QueryNodeSegmentReleaseLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryNodeRole,
+			Name:      "segment_release_latency",
+			Help:      "latency of releasing segment, in milliseconds",
+			Buckets:   buckets,
+		}, []string{
+			nodeIDLabelName,
+			segmentStateLabelName,
+			segmentLevelLabelName,
+		})
+
+	QueryNodeSegmentMaxVersionLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryNodeRole,
+			Name:      "segment_max_version_lag",
+			Help:      "max lag of the loaded segment version behind the latest known version",
+		}, []string{
+			nodeIDLabelName,
+		})
+
+	QueryNodeSegmentCacheLoadFailedCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryNodeRole,
+			Name:      "segment_cache_load_failed_count",
+			Help:      "count of the sealed segments failed to load into the disk cache, clustered by the failure category",
+		}, []string{
+			nodeIDLabelName,
+			failureCategoryLabelName,
+		})
+
+	QueryNodeSegmentCacheLoadSingleflightCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryNodeRole,
+			Name:      "segment_cache_load_singleflight_count",
+			Help:      "count of the disk cache loads executed as the singleflight leader or deduplicated by sharing the leader's result",
+		}, []string{
+			nodeIDLabelName,
+			singleflightRole,
+		})
+
+	QueryNodeChannelSegmentNum = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryNodeRole,
+			Name:      "channel_segment_num",
+			Help:      "number of segments loaded, clustered by the channel",
+		}, []string{
+			nodeIDLabelName,
+			channelNameLabelName,
+		})
+
+	QueryNodeSegmentPinFailedCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryNodeRole,
+			Name:      "segment_pin_failed_count",
+			Help:      "count of the segments failed to pin, due to the segment released or pin timeout",
+		}, []string{
+			nodeIDLabelName,
+			segmentStateLabelName,
+		})
 )
 
 // RegisterQueryNode registers QueryNode metrics
@@ -548,6 +627,13 @@ func RegisterQueryNode(registry *prometheus.Registry) {
 	registry.MustRegister(StoppingBalanceSegmentNum)
 	registry.MustRegister(QueryNodeLoadSegmentConcurrency)
 	registry.MustRegister(QueryNodeLoadIndexLatency)
+	registry.MustRegister(QueryNodeSegmentPinWaitLatency)
+	registry.MustRegister(QueryNodeSegmentPinFailedCount)
+	registry.MustRegister(QueryNodeSegmentReleaseLatency)
+	registry.MustRegister(QueryNodeSegmentMaxVersionLag)
+	registry.MustRegister(QueryNodeChannelSegmentNum)
+	registry.MustRegister(QueryNodeSegmentCacheLoadFailedCount)
+	registry.MustRegister(QueryNodeSegmentCacheLoadSingleflightCount)
 }
 
 func CleanupQueryNodeCollectionMetrics(nodeID int64, collectionID int64) {
@@ -0,0 +1,53 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strconv"
+
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+// checksumPropertyKey is the message property holding the CRC32 checksum of the payload.
+const checksumPropertyKey = "payload_crc32"
+
+// checksumEnabled returns whether the produced messages shall carry the checksum.
+func checksumEnabled() bool {
+	return paramtable.Get().MQCfg.EnableChecksum.GetAsBool()
+}
+
+// InjectChecksum attaches the CRC32 checksum of the payload to the message properties.
+func InjectChecksum(payload []byte, properties map[string]string) {
+	properties[checksumPropertyKey] = strconv.FormatUint(uint64(crc32.ChecksumIEEE(payload)), 10)
+}
+
+// VerifyChecksum verifies the payload against the checksum in the message properties,
+// the messages without checksum are always accepted.
+func VerifyChecksum(topic string, payload []byte, properties map[string]string) error {
+	expected, ok := properties[checksumPropertyKey]
+	if !ok {
+		return nil
+	}
+	actual := strconv.FormatUint(uint64(crc32.ChecksumIEEE(payload)), 10)
+	if actual != expected {
+		return merr.WrapErrMqMsgCorrupted(topic, fmt.Sprintf("checksum mismatch, expected %s, actual %s", expected, actual))
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus/pkg/mq/msgstream/mqwrapper"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+)
+
+type fakeMqMessage struct {
+	topic      string
+	payload    []byte
+	properties map[string]string
+	id         mqwrapper.MessageID
+}
+
+func (m *fakeMqMessage) Topic() string                 { return m.topic }
+func (m *fakeMqMessage) Properties() map[string]string { return m.properties }
+func (m *fakeMqMessage) Payload() []byte               { return m.payload }
+func (m *fakeMqMessage) ID() mqwrapper.MessageID       { return m.id }
+
+func TestChecksum(t *testing.T) {
+	tsMsg := getTsMsg(commonpb.MsgType_Insert, 1)
+	bytes, err := tsMsg.Marshal(tsMsg)
+	assert.NoError(t, err)
+	payload, err := convertToByteArray(bytes)
+	assert.NoError(t, err)
+
+	t.Run("matching payload", func(t *testing.T) {
+		properties := map[string]string{}
+		InjectChecksum(payload, properties)
+		assert.NoError(t, VerifyChecksum("topic", payload, properties))
+	})
+
+	t.Run("corrupted payload", func(t *testing.T) {
+		properties := map[string]string{}
+		InjectChecksum(payload, properties)
+		corrupted := append([]byte{}, payload...)
+		corrupted[len(corrupted)-1] ^= 0xff
+		err := VerifyChecksum("topic", corrupted, properties)
+		assert.ErrorIs(t, err, merr.ErrMqMsgCorrupted)
+	})
+
+	t.Run("without checksum", func(t *testing.T) {
+		assert.NoError(t, VerifyChecksum("topic", payload, map[string]string{}))
+	})
+
+	t.Run("consume corrupted message", func(t *testing.T) {
+		ms := &mqMsgStream{unmarshal: (&ProtoUDFactory{}).NewUnmarshalDispatcher()}
+		properties := map[string]string{}
+		InjectChecksum(payload, properties)
+		corrupted := append([]byte{}, payload...)
+		corrupted[len(corrupted)-1] ^= 0xff
+
+		_, err := ms.getTsMsgFromConsumerMsg(&fakeMqMessage{topic: "topic", payload: corrupted, properties: properties})
+		assert.ErrorIs(t, err, merr.ErrMqMsgCorrupted)
+	})
+}
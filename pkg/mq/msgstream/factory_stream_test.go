@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -15,6 +16,8 @@ import (
 	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
 	"github.com/milvus-io/milvus/pkg/mq/msgstream/mqwrapper"
 	"github.com/milvus-io/milvus/pkg/util/funcutil"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
 )
 
 type streamNewer func(ctx context.Context) (MsgStream, error)
@@ -43,6 +46,8 @@ func testMsgStreamOperation(t *testing.T, factories []Factory, pg positionGenera
 		},
 		testMqMsgStreamSeekLatest,
 		testBroadcastMark,
+		testInsertCompression,
+		testTargetedDelete,
 	}
 
 	for _, testFunc := range testFuncs {
@@ -707,6 +712,93 @@ func testBroadcastMark(t *testing.T, f []Factory) {
 	assert.Error(t, err)
 }
 
+// testInsertCompression produces the compressed inserts and consumes them by the streams of the default codec,
+// through the consuming and seeking paths which parse the message header before dispatching.
+func testInsertCompression(t *testing.T, f []Factory) {
+	ctx := context.Background()
+	channels := getChannel(1)
+
+	params := paramtable.Get()
+	params.Save(params.MQCfg.InsertCompression.Key, InsertCompressionZstd.String())
+	params.Save(params.MQCfg.InsertCompressionThreshold.Key, "1")
+	producer, err := f[0].NewMsgStream(ctx)
+	params.Reset(params.MQCfg.InsertCompression.Key)
+	params.Reset(params.MQCfg.InsertCompressionThreshold.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, InsertCompressionZstd, producer.(*mqMsgStream).insertCompression)
+	producer.AsProducer(channels)
+	defer producer.Close()
+	consumer := createConsumer(ctx, t, f[1].NewTtMsgStream, channels)
+
+	inserts := []TsMsg{
+		getTsMsg(commonpb.MsgType_Insert, 1),
+		getTsMsg(commonpb.MsgType_Insert, 3),
+		getTsMsg(commonpb.MsgType_Insert, 7),
+		getTsMsg(commonpb.MsgType_Insert, 9),
+	}
+	_, err = producer.Broadcast(&MsgPack{Msgs: []TsMsg{getTimeTickMsg(0)}})
+	assert.NoError(t, err)
+	err = producer.Produce(&MsgPack{Msgs: inserts[:2]})
+	assert.NoError(t, err)
+	_, err = producer.Broadcast(&MsgPack{Msgs: []TsMsg{getTimeTickMsg(5)}})
+	assert.NoError(t, err)
+	err = producer.Produce(&MsgPack{Msgs: inserts[2:]})
+	assert.NoError(t, err)
+	_, err = producer.Broadcast(&MsgPack{Msgs: []TsMsg{getTimeTickMsg(11)}})
+	assert.NoError(t, err)
+	_, err = producer.Broadcast(&MsgPack{Msgs: []TsMsg{getTimeTickMsg(15)}})
+	assert.NoError(t, err)
+
+	assertInserts := func(expected []TsMsg, received *MsgPack) {
+		require.Len(t, received.Msgs, len(expected))
+		for i, msg := range received.Msgs {
+			insertMsg, ok := msg.(*InsertMsg)
+			require.True(t, ok)
+			assert.Equal(t, expected[i].ID(), insertMsg.ID())
+			assert.Equal(t, expected[i].(*InsertMsg).GetRowIDs(), insertMsg.GetRowIDs())
+		}
+	}
+	first := consume(ctx, consumer)
+	assertInserts(inserts[:2], first)
+	assertInserts(inserts[2:], consume(ctx, consumer))
+	consumer.Close()
+
+	// the insert before the timestamp is skipped and the one after is buffered while seeking
+	seekPosition := proto.Clone(first.EndPositions[0]).(*msgpb.MsgPosition)
+	seekPosition.Timestamp = 8
+	consumer = createAndSeekConsumer(ctx, t, f[1].NewTtMsgStream, channels, []*msgpb.MsgPosition{seekPosition})
+	defer consumer.Close()
+	assertInserts(inserts[3:], consume(ctx, consumer))
+
+	// the invalid codec fails the stream rather than producing uncompressed
+	params.Save(params.MQCfg.InsertCompression.Key, "lz4")
+	defer params.Reset(params.MQCfg.InsertCompression.Key)
+	_, err = f[0].NewMsgStream(ctx)
+	assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+}
+
+func testTargetedDelete(t *testing.T, f []Factory) {
+	ctx := context.Background()
+	targeted := getTsMsg(commonpb.MsgType_Delete, 1).(*DeleteMsg)
+	targeted.SetTargetSegmentID(1001)
+	untargeted := getTsMsg(commonpb.MsgType_Delete, 3).(*DeleteMsg)
+
+	producer, consumer := createStream(ctx, t, []streamNewer{f[0].NewMsgStream, f[1].NewMsgStream}, getChannel(1))
+	defer producer.Close()
+	defer consumer.Close()
+	err := producer.Produce(&MsgPack{Msgs: []TsMsg{targeted, untargeted}})
+	assert.NoError(t, err)
+
+	for _, expected := range []*DeleteMsg{targeted, untargeted} {
+		received := consume(ctx, consumer)
+		require.Len(t, received.Msgs, 1)
+		deleteMsg, ok := received.Msgs[0].(*DeleteMsg)
+		require.True(t, ok)
+		assert.Equal(t, expected.ID(), deleteMsg.ID())
+		assert.Equal(t, expected.TargetSegmentID(), deleteMsg.TargetSegmentID())
+	}
+}
+
 func applyBroadCastAndConsume(t *testing.T, msgPack *MsgPack, newer []streamNewer, channelNum int) {
 	producer, consumer := createStream(context.Background(), t, newer, getChannel(channelNum))
 	defer producer.Close()
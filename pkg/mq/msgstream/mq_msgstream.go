@@ -68,6 +68,9 @@ type mqMsgStream struct {
 	onceChan      sync.Once
 	enableProduce atomic.Value
 	configEvent   config.EventHandler
+
+	insertCompression          InsertCompression
+	insertCompressionThreshold int
 }
 
 // NewMqMsgStream is used to generate a new mqMsgStream object
@@ -76,7 +79,15 @@ func NewMqMsgStream(ctx context.Context,
 	bufSize int64,
 	client mqwrapper.Client,
 	unmarshal UnmarshalDispatcher,
+	opts ...ProducerOption,
 ) (*mqMsgStream, error) {
+	defaultCompression, err := defaultInsertCompression()
+	if err != nil {
+		if client != nil {
+			client.Close()
+		}
+		return nil, err
+	}
 	streamCtx, streamCancel := context.WithCancel(ctx)
 	producers := make(map[string]mqwrapper.Producer)
 	consumers := make(map[string]mqwrapper.Consumer)
@@ -101,6 +112,10 @@ func NewMqMsgStream(ctx context.Context,
 		closeRWMutex: &sync.RWMutex{},
 		closed:       0,
 	}
+	defaultCompression(stream)
+	for _, opt := range opts {
+		opt(stream)
+	}
 	ctxLog := log.Ctx(ctx)
 	stream.enableProduce.Store(paramtable.Get().CommonCfg.TTMsgEnabled.GetAsBool())
 	stream.configEvent = config.NewHandler("enable send tt msg "+fmt.Sprint(streamCounter.Inc()), func(event *config.Event) {
@@ -309,18 +324,18 @@ func (ms *mqMsgStream) Produce(msgPack *MsgPack) error {
 			spanCtx, sp := MsgSpanFromCtx(v.Msgs[i].TraceCtx(), v.Msgs[i])
 			defer sp.End()
 
-			mb, err := v.Msgs[i].Marshal(v.Msgs[i])
-			if err != nil {
-				return err
-			}
-
-			m, err := convertToByteArray(mb)
+			m, err := ms.marshal(v.Msgs[i])
 			if err != nil {
 				return err
 			}
 
 			msg := &mqwrapper.ProducerMessage{Payload: m, Properties: map[string]string{}}
 			InjectCtx(spanCtx, msg.Properties)
+			InjectVChannel(v.Msgs[i], msg.Properties)
+			InjectTargetSegment(v.Msgs[i], msg.Properties)
+			if checksumEnabled() {
+				InjectChecksum(m, msg.Properties)
+			}
 
 			ms.producerLock.RLock()
 			if _, err := ms.producers[channel].Send(spanCtx, msg); err != nil {
@@ -352,18 +367,18 @@ func (ms *mqMsgStream) Broadcast(msgPack *MsgPack) (map[string][]MessageID, erro
 	for _, v := range msgPack.Msgs {
 		spanCtx, sp := MsgSpanFromCtx(v.TraceCtx(), v)
 
-		mb, err := v.Marshal(v)
-		if err != nil {
-			return ids, err
-		}
-
-		m, err := convertToByteArray(mb)
+		m, err := ms.marshal(v)
 		if err != nil {
 			return ids, err
 		}
 
 		msg := &mqwrapper.ProducerMessage{Payload: m, Properties: map[string]string{}}
 		InjectCtx(spanCtx, msg.Properties)
+		InjectVChannel(v, msg.Properties)
+		InjectTargetSegment(v, msg.Properties)
+		if checksumEnabled() {
+			InjectChecksum(m, msg.Properties)
+		}
 
 		ms.producerLock.Lock()
 		for channel, producer := range ms.producers {
@@ -382,12 +397,32 @@ func (ms *mqMsgStream) Broadcast(msgPack *MsgPack) (map[string][]MessageID, erro
 	return ids, nil
 }
 
+// marshal serializes the message into the payload produced,
+// the insert payloads are compressed by the codec of the stream.
+func (ms *mqMsgStream) marshal(msg TsMsg) ([]byte, error) {
+	mb, err := msg.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	m, err := convertToByteArray(mb)
+	if err != nil {
+		return nil, err
+	}
+	if insertMsg, ok := msg.(*InsertMsg); ok {
+		return compressPayload(ms.insertCompression, ms.insertCompressionThreshold, insertMsg.GetBase(), m)
+	}
+	return m, nil
+}
+
 func (ms *mqMsgStream) getTsMsgFromConsumerMsg(msg mqwrapper.Message) (TsMsg, error) {
 	header := commonpb.MsgHeader{}
 	if msg.Payload() == nil {
 		return nil, fmt.Errorf("failed to unmarshal message header, payload is empty")
 	}
-	err := proto.Unmarshal(msg.Payload(), &header)
+	if err := VerifyChecksum(msg.Topic(), msg.Payload(), msg.Properties()); err != nil {
+		return nil, err
+	}
+	err := unmarshalProto(msg.Payload(), &header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal message header, err %s", err.Error())
 	}
@@ -398,6 +433,10 @@ func (ms *mqMsgStream) getTsMsgFromConsumerMsg(msg mqwrapper.Message) (TsMsg, er
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal tsMsg, err %s", err.Error())
 	}
+	ExtractVChannel(tsMsg, msg.Properties())
+	if err := ExtractTargetSegment(tsMsg, msg.Properties()); err != nil {
+		return nil, err
+	}
 
 	tsMsg.SetPosition(&MsgPosition{
 		ChannelName: filepath.Base(msg.Topic()),
@@ -517,8 +556,9 @@ func NewMqTtMsgStream(ctx context.Context,
 	bufSize int64,
 	client mqwrapper.Client,
 	unmarshal UnmarshalDispatcher,
+	opts ...ProducerOption,
 ) (*MqTtMsgStream, error) {
-	msgStream, err := NewMqMsgStream(ctx, receiveBufSize, bufSize, client, unmarshal)
+	msgStream, err := NewMqMsgStream(ctx, receiveBufSize, bufSize, client, unmarshal, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -894,8 +934,11 @@ func (ms *MqTtMsgStream) Seek(ctx context.Context, msgPositions []*msgpb.MsgPosi
 				}
 				consumer.Ack(msg)
 
+				if err := VerifyChecksum(msg.Topic(), msg.Payload(), msg.Properties()); err != nil {
+					return err
+				}
 				headerMsg := commonpb.MsgHeader{}
-				err := proto.Unmarshal(msg.Payload(), &headerMsg)
+				err := unmarshalProto(msg.Payload(), &headerMsg)
 				if err != nil {
 					return fmt.Errorf("failed to unmarshal message header, err %s", err.Error())
 				}
@@ -903,6 +946,10 @@ func (ms *MqTtMsgStream) Seek(ctx context.Context, msgPositions []*msgpb.MsgPosi
 				if err != nil {
 					return fmt.Errorf("failed to unmarshal tsMsg, err %s", err.Error())
 				}
+				ExtractVChannel(tsMsg, msg.Properties())
+				if err := ExtractTargetSegment(tsMsg, msg.Properties()); err != nil {
+					return err
+				}
 				if tsMsg.Type() == commonpb.MsgType_TimeTick && tsMsg.BeginTs() >= mp.Timestamp {
 					runLoop = false
 				} else if tsMsg.BeginTs() > mp.Timestamp {
@@ -917,7 +964,7 @@ func (ms *MqTtMsgStream) Seek(ctx context.Context, msgPositions []*msgpb.MsgPosi
 				} else {
 					log.Info("skip msg",
 						zap.Int64("source", tsMsg.SourceID()),
-						zap.String("type", tsMsg.Type().String()),
+						zap.String("type", MsgTypeName(tsMsg.Type())),
 						zap.Int("size", tsMsg.Size()),
 						zap.Any("position", tsMsg.Position()),
 					)
@@ -27,8 +27,10 @@ import (
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/metrics"
 	"github.com/milvus-io/milvus/pkg/util/commonpbutil"
 	"github.com/milvus-io/milvus/pkg/util/funcutil"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
 	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
@@ -64,6 +66,7 @@ type BaseMsg struct {
 	EndTimestamp   Timestamp
 	HashValues     []uint32
 	MsgPosition    *MsgPosition
+	vchannel       string
 }
 
 // TraceCtx returns the context of opentracing
@@ -111,6 +114,41 @@ func (bm *BaseMsg) SetPosition(position *MsgPosition) {
 	bm.MsgPosition = position
 }
 
+// VChannel returns the target virtual channel of this message, empty if not set
+func (bm *BaseMsg) VChannel() string {
+	return bm.vchannel
+}
+
+// SetVChannel is used to set the target virtual channel of this message,
+// which is carried in the message properties when produced
+func (bm *BaseMsg) SetVChannel(vchannel string) {
+	bm.vchannel = vchannel
+}
+
+// vchannelPropertyKey is the message property holding the target virtual channel.
+const vchannelPropertyKey = "vchannel"
+
+type vchannelCarrier interface {
+	VChannel() string
+	SetVChannel(vchannel string)
+}
+
+// InjectVChannel puts the virtual channel of the message in the properties if set.
+func InjectVChannel(msg TsMsg, properties map[string]string) {
+	if carrier, ok := msg.(vchannelCarrier); ok && carrier.VChannel() != "" {
+		properties[vchannelPropertyKey] = carrier.VChannel()
+	}
+}
+
+// ExtractVChannel restores the virtual channel of the message from the properties.
+func ExtractVChannel(msg TsMsg, properties map[string]string) {
+	if vchannel, ok := properties[vchannelPropertyKey]; ok {
+		if carrier, ok := msg.(vchannelCarrier); ok {
+			carrier.SetVChannel(vchannel)
+		}
+	}
+}
+
 func convertToByteArray(input interface{}) ([]byte, error) {
 	switch output := input.(type) {
 	case []byte:
@@ -120,6 +158,20 @@ func convertToByteArray(input interface{}) ([]byte, error) {
 	}
 }
 
+// MsgTypeName returns the readable name of the message type, e.g. Insert, TimeTick,
+// or Unknown(n) for the value not defined.
+func MsgTypeName(t MsgType) string {
+	if name, ok := commonpb.MsgType_name[int32(t)]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown(%d)", int32(t))
+}
+
+// recordMarshalSize observes the marshaled size of a message, labeled by its type
+func recordMarshalSize(typ MsgType, n int) {
+	metrics.MsgStreamMarshalSize.WithLabelValues(MsgTypeName(typ)).Observe(float64(n))
+}
+
 /////////////////////////////////////////Insert//////////////////////////////////////////
 
 // InsertMsg is a message pack that contains insert request
@@ -155,10 +207,11 @@ func (it *InsertMsg) SourceID() int64 {
 func (it *InsertMsg) Marshal(input TsMsg) (MarshalType, error) {
 	insertMsg := input.(*InsertMsg)
 	insertRequest := &insertMsg.InsertRequest
-	mb, err := proto.Marshal(insertRequest)
+	mb, err := marshalProto(insertRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(insertRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -169,11 +222,20 @@ func (it *InsertMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &insertRequest)
+	in, err = decompressPayload(in)
+	if err != nil {
+		return nil, err
+	}
+	err = unmarshalProto(in, &insertRequest)
 	if err != nil {
 		return nil, err
 	}
 	insertMsg := &InsertMsg{InsertRequest: insertRequest}
+	if paramtable.Get().MQCfg.StrictInsertValidation.GetAsBool() {
+		if err := insertMsg.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	for _, timestamp := range insertMsg.Timestamps {
 		insertMsg.BeginTimestamp = timestamp
 		insertMsg.EndTimestamp = timestamp
@@ -191,6 +253,69 @@ func (it *InsertMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	return insertMsg, nil
 }
 
+// HashStrategy computes the hash values of the rows in InsertMsg, which decides the routing of the rows.
+type HashStrategy interface {
+	// HashValues returns the hash values of the rows, nil if the rows can't be hashed by the strategy
+	HashValues(msg *InsertMsg) []uint32
+}
+
+// PrimaryKeyHashStrategy hashes the rows by the primary keys, the default HashStrategy.
+type PrimaryKeyHashStrategy struct {
+	PrimaryFieldID int64
+	ShardNum       uint32
+}
+
+// HashValues implements HashStrategy, returns nil if the primary key field is not found.
+func (s PrimaryKeyHashStrategy) HashValues(msg *InsertMsg) []uint32 {
+	for _, field := range msg.GetFieldsData() {
+		if field.GetFieldId() != s.PrimaryFieldID {
+			continue
+		}
+		switch field.GetType() {
+		case schemapb.DataType_Int64:
+			return typeutil.HashPK2Shards(&schemapb.IDs{
+				IdField: &schemapb.IDs_IntId{IntId: &schemapb.LongArray{Data: field.GetScalars().GetLongData().GetData()}},
+			}, s.ShardNum)
+		case schemapb.DataType_VarChar:
+			return typeutil.HashPK2Shards(&schemapb.IDs{
+				IdField: &schemapb.IDs_StrId{StrId: &schemapb.StringArray{Data: field.GetScalars().GetStringData().GetData()}},
+			}, s.ShardNum)
+		}
+		return nil
+	}
+	return nil
+}
+
+// InsertHashOption is the option to populate the empty hash values of InsertMsg,
+// by primary keys unless Strategy is set.
+type InsertHashOption struct {
+	PrimaryFieldID int64
+	ShardNum       uint32
+	// Strategy overrides the hashing by primary keys if set
+	Strategy HashStrategy
+}
+
+// fillHashValues populates the hash values by the strategy of the option if they are empty.
+func (it *InsertMsg) fillHashValues(opt InsertHashOption) {
+	strategy := opt.Strategy
+	if strategy == nil {
+		if opt.ShardNum == 0 {
+			return
+		}
+		strategy = PrimaryKeyHashStrategy{PrimaryFieldID: opt.PrimaryFieldID, ShardNum: opt.ShardNum}
+	}
+	it.FillHashValues(strategy)
+}
+
+// FillHashValues populates the hash values by the strategy,
+// does nothing if the hash values exist or the strategy can't hash the rows.
+func (it *InsertMsg) FillHashValues(strategy HashStrategy) {
+	if len(it.HashValues) > 0 {
+		return
+	}
+	it.HashValues = strategy.HashValues(it)
+}
+
 func (it *InsertMsg) IsRowBased() bool {
 	return it.GetVersion() == msgpb.InsertDataVersion_RowBased
 }
@@ -234,6 +359,30 @@ func (it *InsertMsg) CheckAligned() error {
 	return nil
 }
 
+// Validate checks all the columns have the same number of rows as the timestamps and the row IDs,
+// the misaligned message would corrupt the segments it's applied to.
+func (it *InsertMsg) Validate() error {
+	numRows := len(it.GetTimestamps())
+	if len(it.GetRowIDs()) != numRows {
+		return newValidationError(commonpb.MsgType_Insert, "row_ids", "the num_rows(%d) of rowIDs is not equal to the num_rows(%d) of timestamps", len(it.GetRowIDs()), numRows)
+	}
+	if it.NRows() != uint64(numRows) {
+		return newValidationError(commonpb.MsgType_Insert, "num_rows", "the passed NumRows(%d) is not equal to the num_rows(%d) of timestamps", it.NRows(), numRows)
+	}
+	if it.IsColumnBased() {
+		for _, field := range it.GetFieldsData() {
+			fieldNumRows, err := funcutil.GetNumRowOfFieldData(field)
+			if err != nil {
+				return newValidationError(commonpb.MsgType_Insert, "fields_data."+field.GetFieldName(), "%s", err.Error())
+			}
+			if fieldNumRows != uint64(numRows) {
+				return newValidationError(commonpb.MsgType_Insert, "fields_data."+field.GetFieldName(), "the num_rows(%d) of field %s is not equal to the num_rows(%d) of timestamps", fieldNumRows, field.GetFieldName(), numRows)
+			}
+		}
+	}
+	return nil
+}
+
 func (it *InsertMsg) rowBasedIndexRequest(index int) msgpb.InsertRequest {
 	return msgpb.InsertRequest{
 		Base: commonpbutil.NewMsgBase(
@@ -302,6 +451,98 @@ func (it *InsertMsg) IndexMsg(index int) *InsertMsg {
 	}
 }
 
+// SplitByPartition splits the InsertMsg into one InsertMsg per partition,
+// partitionOf returns the partition of the row at the given index.
+// The rows keep their original order within each partition,
+// partitions without any row are not present in the result.
+func (it *InsertMsg) SplitByPartition(partitionOf func(row int) int64) map[int64]*InsertMsg {
+	result := make(map[int64]*InsertMsg)
+	hashAligned := len(it.HashValues) == int(it.NRows())
+	for row := 0; row < int(it.NRows()); row++ {
+		partitionID := partitionOf(row)
+		msg, ok := result[partitionID]
+		if !ok {
+			msg = it.emptySplit(partitionID)
+			result[partitionID] = msg
+		}
+		it.appendRowTo(msg, row, hashAligned)
+	}
+
+	for partitionID, msg := range result {
+		if partitionID == it.PartitionID {
+			msg.PartitionName = it.PartitionName
+		}
+		msg.BeginTimestamp, msg.EndTimestamp = timestampRange(msg.Timestamps)
+	}
+	return result
+}
+
+// SplitByTimestamp splits the InsertMsg at the boundary,
+// the rows with timestamps less than the boundary go to before, the others go to after.
+// The rows keep their original order, the side without any row is nil.
+func (it *InsertMsg) SplitByTimestamp(boundary Timestamp) (before, after *InsertMsg) {
+	hashAligned := len(it.HashValues) == int(it.NRows())
+	for row := 0; row < int(it.NRows()); row++ {
+		side := &after
+		if it.Timestamps[row] < boundary {
+			side = &before
+		}
+		if *side == nil {
+			*side = it.emptySplit(it.PartitionID)
+			(*side).PartitionName = it.PartitionName
+		}
+		it.appendRowTo(*side, row, hashAligned)
+	}
+
+	for _, msg := range []*InsertMsg{before, after} {
+		if msg != nil {
+			msg.BeginTimestamp, msg.EndTimestamp = timestampRange(msg.Timestamps)
+		}
+	}
+	return before, after
+}
+
+// emptySplit returns the InsertMsg of the partition without any row, sharing the meta of it.
+func (it *InsertMsg) emptySplit(partitionID int64) *InsertMsg {
+	msg := &InsertMsg{
+		BaseMsg: BaseMsg{
+			Ctx:         it.TraceCtx(),
+			MsgPosition: it.MsgPosition,
+			vchannel:    it.vchannel,
+		},
+		InsertRequest: msgpb.InsertRequest{
+			Base:           it.Base,
+			DbID:           it.DbID,
+			CollectionID:   it.CollectionID,
+			PartitionID:    partitionID,
+			CollectionName: it.CollectionName,
+			DbName:         it.DbName,
+			SegmentID:      it.SegmentID,
+			ShardName:      it.ShardName,
+			Version:        it.Version,
+		},
+	}
+	if it.IsColumnBased() {
+		msg.FieldsData = make([]*schemapb.FieldData, len(it.GetFieldsData()))
+	}
+	return msg
+}
+
+// appendRowTo appends the row at the given index to msg, along with its hash value if hashAligned.
+func (it *InsertMsg) appendRowTo(msg *InsertMsg, row int, hashAligned bool) {
+	msg.Timestamps = append(msg.Timestamps, it.Timestamps[row])
+	msg.RowIDs = append(msg.RowIDs, it.RowIDs[row])
+	if hashAligned {
+		msg.HashValues = append(msg.HashValues, it.HashValues[row])
+	}
+	if it.IsRowBased() {
+		msg.RowData = append(msg.RowData, it.RowData[row])
+	} else {
+		typeutil.AppendFieldData(msg.FieldsData, it.GetFieldsData(), int64(row))
+		msg.NumRows++
+	}
+}
+
 func (it *InsertMsg) Size() int {
 	return proto.Size(&it.InsertRequest)
 }
@@ -312,6 +553,9 @@ func (it *InsertMsg) Size() int {
 type DeleteMsg struct {
 	BaseMsg
 	msgpb.DeleteRequest
+
+	// the segment the deletes are applied to, 0 if not targeted
+	targetSegmentID int64
 }
 
 // interface implementation validation
@@ -341,11 +585,11 @@ func (dt *DeleteMsg) SourceID() int64 {
 func (dt *DeleteMsg) Marshal(input TsMsg) (MarshalType, error) {
 	deleteMsg := input.(*DeleteMsg)
 	deleteRequest := &deleteMsg.DeleteRequest
-	mb, err := proto.Marshal(deleteRequest)
+	mb, err := marshalProto(deleteRequest)
 	if err != nil {
 		return nil, err
 	}
-
+	recordMarshalSize(deleteRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -356,7 +600,7 @@ func (dt *DeleteMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &deleteRequest)
+	err = unmarshalProto(in, &deleteRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -409,6 +653,114 @@ func (dt *DeleteMsg) Size() int {
 	return proto.Size(&dt.DeleteRequest)
 }
 
+// primaryKeys returns the primary keys of the deletes,
+// the legacy message carrying only Int64PrimaryKeys is compatible like Unmarshal.
+func (dt *DeleteMsg) primaryKeys() *schemapb.IDs {
+	if dt.GetPrimaryKeys() == nil && len(dt.GetInt64PrimaryKeys()) > 0 {
+		return &schemapb.IDs{
+			IdField: &schemapb.IDs_IntId{
+				IntId: &schemapb.LongArray{
+					Data: dt.GetInt64PrimaryKeys(),
+				},
+			},
+		}
+	}
+	return dt.GetPrimaryKeys()
+}
+
+// Dedup returns a new DeleteMsg with the duplicate primary keys collapsed,
+// each primary key keeps the max timestamp among its duplicates,
+// and the primary keys are in the order of their first occurrences.
+// The begin and end timestamps of the message are kept to not change its position in the stream.
+func (dt *DeleteMsg) Dedup() *DeleteMsg {
+	primaryKeys := dt.primaryKeys()
+	numRows := typeutil.GetSizeOfIDs(primaryKeys)
+	hashAligned := len(dt.HashValues) == numRows
+
+	positions := make(map[interface{}]int, numRows)
+	pks := &schemapb.IDs{}
+	timestamps := make([]uint64, 0, numRows)
+	var hashValues []uint32
+	for i := 0; i < numRows; i++ {
+		pk := typeutil.GetPK(primaryKeys, int64(i))
+		if pos, ok := positions[pk]; ok {
+			if dt.Timestamps[i] > timestamps[pos] {
+				timestamps[pos] = dt.Timestamps[i]
+			}
+			continue
+		}
+		positions[pk] = len(timestamps)
+		typeutil.AppendPKs(pks, pk)
+		timestamps = append(timestamps, dt.Timestamps[i])
+		if hashAligned {
+			hashValues = append(hashValues, dt.HashValues[i])
+		}
+	}
+
+	req := dt.DeleteRequest
+	req.PrimaryKeys = pks
+	req.Timestamps = timestamps
+	req.NumRows = int64(len(timestamps))
+	if len(req.Int64PrimaryKeys) > 0 {
+		req.Int64PrimaryKeys = pks.GetIntId().GetData()
+	}
+	return &DeleteMsg{
+		BaseMsg: BaseMsg{
+			Ctx:            dt.TraceCtx(),
+			BeginTimestamp: dt.BeginTimestamp,
+			EndTimestamp:   dt.EndTimestamp,
+			HashValues:     hashValues,
+			MsgPosition:    dt.MsgPosition,
+			vchannel:       dt.vchannel,
+		},
+		DeleteRequest:   req,
+		targetSegmentID: dt.targetSegmentID,
+	}
+}
+
+// FilterByTsRange returns a new DeleteMsg with only the deletes whose timestamps are in [min, max],
+// the primary keys, timestamps and hash values stay aligned.
+// The begin and end timestamps of the message are kept like Dedup.
+func (dt *DeleteMsg) FilterByTsRange(min, max Timestamp) *DeleteMsg {
+	primaryKeys := dt.primaryKeys()
+	numRows := typeutil.GetSizeOfIDs(primaryKeys)
+	hashAligned := len(dt.HashValues) == numRows
+
+	pks := &schemapb.IDs{}
+	timestamps := make([]uint64, 0, numRows)
+	var hashValues []uint32
+	for i := 0; i < numRows; i++ {
+		if dt.Timestamps[i] < min || dt.Timestamps[i] > max {
+			continue
+		}
+		typeutil.AppendPKs(pks, typeutil.GetPK(primaryKeys, int64(i)))
+		timestamps = append(timestamps, dt.Timestamps[i])
+		if hashAligned {
+			hashValues = append(hashValues, dt.HashValues[i])
+		}
+	}
+
+	req := dt.DeleteRequest
+	req.PrimaryKeys = pks
+	req.Timestamps = timestamps
+	req.NumRows = int64(len(timestamps))
+	if len(req.Int64PrimaryKeys) > 0 {
+		req.Int64PrimaryKeys = pks.GetIntId().GetData()
+	}
+	return &DeleteMsg{
+		BaseMsg: BaseMsg{
+			Ctx:            dt.TraceCtx(),
+			BeginTimestamp: dt.BeginTimestamp,
+			EndTimestamp:   dt.EndTimestamp,
+			HashValues:     hashValues,
+			MsgPosition:    dt.MsgPosition,
+			vchannel:       dt.vchannel,
+		},
+		DeleteRequest:   req,
+		targetSegmentID: dt.targetSegmentID,
+	}
+}
+
 // ///////////////////////////////////////Upsert//////////////////////////////////////////
 type UpsertMsg struct {
 	InsertMsg *InsertMsg
@@ -450,10 +802,11 @@ func (tst *TimeTickMsg) SourceID() int64 {
 func (tst *TimeTickMsg) Marshal(input TsMsg) (MarshalType, error) {
 	timeTickTask := input.(*TimeTickMsg)
 	timeTick := &timeTickTask.TimeTickMsg
-	mb, err := proto.Marshal(timeTick)
+	mb, err := marshalProto(timeTick)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(timeTick.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -464,7 +817,7 @@ func (tst *TimeTickMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &timeTickMsg)
+	err = unmarshalProto(in, &timeTickMsg)
 	if err != nil {
 		return nil, err
 	}
@@ -514,10 +867,11 @@ func (cc *CreateCollectionMsg) SourceID() int64 {
 func (cc *CreateCollectionMsg) Marshal(input TsMsg) (MarshalType, error) {
 	createCollectionMsg := input.(*CreateCollectionMsg)
 	createCollectionRequest := &createCollectionMsg.CreateCollectionRequest
-	mb, err := proto.Marshal(createCollectionRequest)
+	mb, err := marshalProto(createCollectionRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(createCollectionRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -528,7 +882,7 @@ func (cc *CreateCollectionMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &createCollectionRequest)
+	err = unmarshalProto(in, &createCollectionRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -578,10 +932,11 @@ func (dc *DropCollectionMsg) SourceID() int64 {
 func (dc *DropCollectionMsg) Marshal(input TsMsg) (MarshalType, error) {
 	dropCollectionMsg := input.(*DropCollectionMsg)
 	dropCollectionRequest := &dropCollectionMsg.DropCollectionRequest
-	mb, err := proto.Marshal(dropCollectionRequest)
+	mb, err := marshalProto(dropCollectionRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(dropCollectionRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -592,7 +947,7 @@ func (dc *DropCollectionMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &dropCollectionRequest)
+	err = unmarshalProto(in, &dropCollectionRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -642,10 +997,11 @@ func (cp *CreatePartitionMsg) SourceID() int64 {
 func (cp *CreatePartitionMsg) Marshal(input TsMsg) (MarshalType, error) {
 	createPartitionMsg := input.(*CreatePartitionMsg)
 	createPartitionRequest := &createPartitionMsg.CreatePartitionRequest
-	mb, err := proto.Marshal(createPartitionRequest)
+	mb, err := marshalProto(createPartitionRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(createPartitionRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -656,7 +1012,7 @@ func (cp *CreatePartitionMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &createPartitionRequest)
+	err = unmarshalProto(in, &createPartitionRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -706,10 +1062,11 @@ func (dp *DropPartitionMsg) SourceID() int64 {
 func (dp *DropPartitionMsg) Marshal(input TsMsg) (MarshalType, error) {
 	dropPartitionMsg := input.(*DropPartitionMsg)
 	dropPartitionRequest := &dropPartitionMsg.DropPartitionRequest
-	mb, err := proto.Marshal(dropPartitionRequest)
+	mb, err := marshalProto(dropPartitionRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(dropPartitionRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -720,7 +1077,7 @@ func (dp *DropPartitionMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &dropPartitionRequest)
+	err = unmarshalProto(in, &dropPartitionRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -769,10 +1126,11 @@ func (m *DataNodeTtMsg) SourceID() int64 {
 // Marshal is used to serializing a message pack to byte array
 func (m *DataNodeTtMsg) Marshal(input TsMsg) (MarshalType, error) {
 	msg := input.(*DataNodeTtMsg)
-	t, err := proto.Marshal(&msg.DataNodeTtMsg)
+	t, err := marshalProto(&msg.DataNodeTtMsg)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(msg.GetBase().GetMsgType(), len(t))
 	return t, nil
 }
 
@@ -783,7 +1141,7 @@ func (m *DataNodeTtMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &msg)
+	err = unmarshalProto(in, &msg)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,169 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+// InsertCompression is the codec compressing the payload of InsertMsg.
+type InsertCompression byte
+
+const (
+	InsertCompressionNone InsertCompression = iota
+	InsertCompressionZstd
+	InsertCompressionSnappy
+)
+
+func (c InsertCompression) String() string {
+	switch c {
+	case InsertCompressionNone:
+		return "none"
+	case InsertCompressionZstd:
+		return "zstd"
+	case InsertCompressionSnappy:
+		return "snappy"
+	}
+	return "unknown"
+}
+
+// ParseInsertCompression parses the codec name, none/zstd/snappy.
+func ParseInsertCompression(name string) (InsertCompression, error) {
+	for _, c := range []InsertCompression{InsertCompressionNone, InsertCompressionZstd, InsertCompressionSnappy} {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return InsertCompressionNone, merr.WrapErrParameterInvalidMsg("unknown insert compression %s", name)
+}
+
+// ProducerOption configures how the stream produces the messages.
+type ProducerOption func(*mqMsgStream)
+
+// WithInsertCompression compresses the insert payloads produced not smaller than threshold with the codec,
+// the stream takes the codec and the threshold of mq.insertCompression and mq.insertCompressionThreshold by default.
+func WithInsertCompression(codec InsertCompression, threshold int) ProducerOption {
+	return func(ms *mqMsgStream) {
+		ms.insertCompression = codec
+		ms.insertCompressionThreshold = threshold
+	}
+}
+
+// defaultInsertCompression returns the option of the configured codec and threshold,
+// the invalid codec is reported rather than producing uncompressed.
+func defaultInsertCompression() (ProducerOption, error) {
+	params := paramtable.Get()
+	codec, err := ParseInsertCompression(params.MQCfg.InsertCompression.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	return WithInsertCompression(codec, params.MQCfg.InsertCompressionThreshold.GetAsInt()), nil
+}
+
+// The compressed payload is the protobuf message of two fields,
+// the MsgBase of the insert message as field 1, which is the base field of commonpb.MsgHeader,
+// so the consumers parse the header before dispatching the payload as usual,
+// and the compressed InsertRequest prefixed with the header [compressedVersion, codec] as compressedPayloadField,
+// which is not a field of msgpb.InsertRequest, so the uncompressed payloads are told apart.
+const (
+	msgBaseField           protowire.Number = 1
+	compressedPayloadField protowire.Number = 1000
+	compressedVersion      byte             = 1
+	compressedHeaderSize                    = 2
+)
+
+var (
+	insertZstdEncoder, _ = zstd.NewWriter(nil)
+	insertZstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// compressPayload compresses the payload of the insert message with the codec,
+// the payload smaller than threshold or not compressed is returned as is.
+func compressPayload(codec InsertCompression, threshold int, base *commonpb.MsgBase, payload []byte) ([]byte, error) {
+	if codec == InsertCompressionNone || len(payload) < threshold {
+		return payload, nil
+	}
+	compressed := []byte{compressedVersion, byte(codec)}
+	switch codec {
+	case InsertCompressionZstd:
+		compressed = insertZstdEncoder.EncodeAll(payload, compressed)
+	case InsertCompressionSnappy:
+		compressed = append(compressed, snappy.Encode(nil, payload)...)
+	default:
+		return nil, merr.WrapErrParameterInvalidMsg("unknown insert compression %d", codec)
+	}
+	mb, err := marshalProto(base)
+	if err != nil {
+		return nil, err
+	}
+	out := protowire.AppendTag(nil, msgBaseField, protowire.BytesType)
+	out = protowire.AppendBytes(out, mb)
+	out = protowire.AppendTag(out, compressedPayloadField, protowire.BytesType)
+	return protowire.AppendBytes(out, compressed), nil
+}
+
+// decompressPayload reverses compressPayload by the header, the uncompressed payload is returned as is.
+func decompressPayload(payload []byte) ([]byte, error) {
+	compressed, ok := compressedPayloadOf(payload)
+	if !ok {
+		return payload, nil
+	}
+	if len(compressed) < compressedHeaderSize {
+		return nil, merr.WrapErrParameterInvalidMsg("truncated compressed payload header")
+	}
+	if compressed[0] != compressedVersion {
+		return nil, merr.WrapErrParameterInvalidMsg("unsupported compressed payload version %d", compressed[0])
+	}
+	body := compressed[compressedHeaderSize:]
+	switch codec := InsertCompression(compressed[1]); codec {
+	case InsertCompressionZstd:
+		return insertZstdDecoder.DecodeAll(body, nil)
+	case InsertCompressionSnappy:
+		return snappy.Decode(nil, body)
+	default:
+		return nil, merr.WrapErrParameterInvalidMsg("unknown compressed payload codec %d", codec)
+	}
+}
+
+// compressedPayloadOf returns the compressedPayloadField of the payload, only the top level fields are scanned,
+// the malformed payload is left to the protobuf decoding to report.
+func compressedPayloadOf(payload []byte) ([]byte, bool) {
+	for len(payload) > 0 {
+		num, typ, n := protowire.ConsumeTag(payload)
+		if n < 0 {
+			return nil, false
+		}
+		payload = payload[n:]
+		if num == compressedPayloadField && typ == protowire.BytesType {
+			compressed, n := protowire.ConsumeBytes(payload)
+			return compressed, n >= 0
+		}
+		n = protowire.ConsumeFieldValue(num, typ, payload)
+		if n < 0 {
+			return nil, false
+		}
+		payload = payload[n:]
+	}
+	return nil, false
+}
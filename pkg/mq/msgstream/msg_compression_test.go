@@ -0,0 +1,134 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
+)
+
+func TestInsertMsgCompression(t *testing.T) {
+	paramtable.Init()
+
+	const numRows = 4096
+	newInsertMsg := func() *InsertMsg {
+		msg := &InsertMsg{
+			InsertRequest: msgpb.InsertRequest{
+				Base:         &commonpb.MsgBase{MsgType: commonpb.MsgType_Insert, MsgID: 1},
+				CollectionID: 1,
+				PartitionID:  10,
+				NumRows:      numRows,
+				Version:      msgpb.InsertDataVersion_ColumnBased,
+			},
+		}
+		values := make([]int64, numRows)
+		for i := range values {
+			msg.Timestamps = append(msg.Timestamps, 1000)
+			msg.RowIDs = append(msg.RowIDs, int64(i))
+			values[i] = int64(i % 16)
+		}
+		msg.FieldsData = []*schemapb.FieldData{{
+			Type:    schemapb.DataType_Int64,
+			FieldId: 100,
+			Field: &schemapb.FieldData_Scalars{
+				Scalars: &schemapb.ScalarField{
+					Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: values}},
+				},
+			},
+		}}
+		return msg
+	}
+
+	msg := newInsertMsg()
+	raw, err := marshalProto(&msg.InsertRequest)
+	assert.NoError(t, err)
+
+	for _, codec := range []InsertCompression{InsertCompressionNone, InsertCompressionZstd, InsertCompressionSnappy} {
+		t.Run(codec.String(), func(t *testing.T) {
+			stream := &mqMsgStream{}
+			WithInsertCompression(codec, 1024)(stream)
+			payload, err := stream.marshal(msg)
+			assert.NoError(t, err)
+			if codec == InsertCompressionNone {
+				assert.Equal(t, raw, payload)
+			} else {
+				compressed, ok := compressedPayloadOf(payload)
+				assert.True(t, ok)
+				assert.Equal(t, []byte{compressedVersion, byte(codec)}, compressed[:compressedHeaderSize])
+				assert.Less(t, len(payload), len(raw)/2)
+			}
+
+			// the header is parsed before dispatching
+			header := commonpb.MsgHeader{}
+			assert.NoError(t, unmarshalProto(payload, &header))
+			assert.Equal(t, commonpb.MsgType_Insert, header.GetBase().GetMsgType())
+			assert.EqualValues(t, 1, header.GetBase().GetMsgID())
+
+			// decoded without knowing the codec
+			decoded, err := (&InsertMsg{}).Unmarshal(payload)
+			assert.NoError(t, err)
+			insertMsg := decoded.(*InsertMsg)
+			assert.EqualValues(t, 1, insertMsg.ID())
+			assert.Equal(t, msg.GetRowIDs(), insertMsg.GetRowIDs())
+			assert.Equal(t, msg.GetFieldsData()[0].GetScalars().GetLongData().GetData(),
+				insertMsg.GetFieldsData()[0].GetScalars().GetLongData().GetData())
+			assert.NoError(t, insertMsg.CheckAligned())
+		})
+	}
+
+	t.Run("below threshold", func(t *testing.T) {
+		stream := &mqMsgStream{}
+		WithInsertCompression(InsertCompressionZstd, 1<<30)(stream)
+		payload, err := stream.marshal(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, raw, payload)
+	})
+
+	t.Run("invalid header", func(t *testing.T) {
+		envelope := func(compressed []byte) []byte {
+			payload := protowire.AppendTag(nil, compressedPayloadField, protowire.BytesType)
+			return protowire.AppendBytes(payload, compressed)
+		}
+		for _, payload := range [][]byte{
+			envelope([]byte{compressedVersion}),
+			envelope([]byte{compressedVersion + 1, byte(InsertCompressionZstd)}),
+			envelope([]byte{compressedVersion, 100}),
+			envelope([]byte{compressedVersion, byte(InsertCompressionSnappy), 0xff, 0xff}),
+		} {
+			_, err := (&InsertMsg{}).Unmarshal(payload)
+			assert.Error(t, err)
+		}
+		_, err := decompressPayload(envelope([]byte{compressedVersion}))
+		assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		codec, err := ParseInsertCompression("snappy")
+		assert.NoError(t, err)
+		assert.Equal(t, InsertCompressionSnappy, codec)
+		_, err = ParseInsertCompression("lz4")
+		assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+	})
+}
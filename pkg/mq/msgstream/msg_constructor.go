@@ -0,0 +1,143 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+)
+
+// timestampRange returns the min and max of the timestamps.
+func timestampRange(timestamps []Timestamp) (begin, end Timestamp) {
+	for i, timestamp := range timestamps {
+		if i == 0 || timestamp < begin {
+			begin = timestamp
+		}
+		if timestamp > end {
+			end = timestamp
+		}
+	}
+	return begin, end
+}
+
+// baseMsgOf returns the BaseMsg whose begin and end timestamps are both the given timestamp.
+func baseMsgOf(timestamp Timestamp) BaseMsg {
+	return BaseMsg{
+		BeginTimestamp: timestamp,
+		EndTimestamp:   timestamp,
+	}
+}
+
+// NewInsertMsg creates an InsertMsg from the request,
+// the begin and end timestamps are the min and max of the row timestamps.
+func NewInsertMsg(req *msgpb.InsertRequest) (*InsertMsg, error) {
+	if req.GetBase() == nil {
+		return nil, merr.WrapErrParameterMissing("base", "insert request without base")
+	}
+	if len(req.GetTimestamps()) == 0 {
+		return nil, merr.WrapErrParameterMissing("timestamps", "insert request without timestamps")
+	}
+	msg := &InsertMsg{InsertRequest: *req}
+	if err := msg.CheckAligned(); err != nil {
+		return nil, merr.WrapErrParameterInvalidMsg("insert request not aligned: %s", err.Error())
+	}
+	msg.BeginTimestamp, msg.EndTimestamp = timestampRange(req.GetTimestamps())
+	return msg, nil
+}
+
+// NewDeleteMsg creates a DeleteMsg from the request,
+// the begin and end timestamps are the min and max of the row timestamps.
+func NewDeleteMsg(req *msgpb.DeleteRequest) (*DeleteMsg, error) {
+	if req.GetBase() == nil {
+		return nil, merr.WrapErrParameterMissing("base", "delete request without base")
+	}
+	if len(req.GetTimestamps()) == 0 {
+		return nil, merr.WrapErrParameterMissing("timestamps", "delete request without timestamps")
+	}
+	msg := &DeleteMsg{DeleteRequest: *req}
+	if err := msg.CheckAligned(); err != nil {
+		return nil, merr.WrapErrParameterInvalidMsg("delete request not aligned: %s", err.Error())
+	}
+	msg.BeginTimestamp, msg.EndTimestamp = timestampRange(req.GetTimestamps())
+	return msg, nil
+}
+
+// NewTimeTickMsg creates a TimeTickMsg from the request, the timestamps are the timestamp of the base.
+func NewTimeTickMsg(req *msgpb.TimeTickMsg) (*TimeTickMsg, error) {
+	if req.GetBase() == nil {
+		return nil, merr.WrapErrParameterMissing("base", "time tick without base")
+	}
+	return &TimeTickMsg{
+		BaseMsg:     baseMsgOf(req.GetBase().GetTimestamp()),
+		TimeTickMsg: *req,
+	}, nil
+}
+
+// NewCreateCollectionMsg creates a CreateCollectionMsg from the request, the timestamps are the timestamp of the base.
+func NewCreateCollectionMsg(req *msgpb.CreateCollectionRequest) (*CreateCollectionMsg, error) {
+	if req.GetBase() == nil {
+		return nil, merr.WrapErrParameterMissing("base", "create collection request without base")
+	}
+	return &CreateCollectionMsg{
+		BaseMsg:                 baseMsgOf(req.GetBase().GetTimestamp()),
+		CreateCollectionRequest: *req,
+	}, nil
+}
+
+// NewDropCollectionMsg creates a DropCollectionMsg from the request, the timestamps are the timestamp of the base.
+func NewDropCollectionMsg(req *msgpb.DropCollectionRequest) (*DropCollectionMsg, error) {
+	if req.GetBase() == nil {
+		return nil, merr.WrapErrParameterMissing("base", "drop collection request without base")
+	}
+	return &DropCollectionMsg{
+		BaseMsg:               baseMsgOf(req.GetBase().GetTimestamp()),
+		DropCollectionRequest: *req,
+	}, nil
+}
+
+// NewCreatePartitionMsg creates a CreatePartitionMsg from the request, the timestamps are the timestamp of the base.
+func NewCreatePartitionMsg(req *msgpb.CreatePartitionRequest) (*CreatePartitionMsg, error) {
+	if req.GetBase() == nil {
+		return nil, merr.WrapErrParameterMissing("base", "create partition request without base")
+	}
+	return &CreatePartitionMsg{
+		BaseMsg:                baseMsgOf(req.GetBase().GetTimestamp()),
+		CreatePartitionRequest: *req,
+	}, nil
+}
+
+// NewDropPartitionMsg creates a DropPartitionMsg from the request, the timestamps are the timestamp of the base.
+func NewDropPartitionMsg(req *msgpb.DropPartitionRequest) (*DropPartitionMsg, error) {
+	if req.GetBase() == nil {
+		return nil, merr.WrapErrParameterMissing("base", "drop partition request without base")
+	}
+	return &DropPartitionMsg{
+		BaseMsg:              baseMsgOf(req.GetBase().GetTimestamp()),
+		DropPartitionRequest: *req,
+	}, nil
+}
+
+// NewDataNodeTtMsg creates a DataNodeTtMsg from the request, the timestamps are the time tick of the request.
+func NewDataNodeTtMsg(req *msgpb.DataNodeTtMsg) (*DataNodeTtMsg, error) {
+	if req.GetBase() == nil {
+		return nil, merr.WrapErrParameterMissing("base", "datanode time tick without base")
+	}
+	return &DataNodeTtMsg{
+		BaseMsg:       baseMsgOf(req.GetTimestamp()),
+		DataNodeTtMsg: *req,
+	}, nil
+}
@@ -0,0 +1,110 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+)
+
+func TestNewInsertMsg(t *testing.T) {
+	req := &msgpb.InsertRequest{
+		Base:       &commonpb.MsgBase{MsgType: commonpb.MsgType_Insert, Timestamp: 10},
+		RowIDs:     []int64{1, 2, 3},
+		Timestamps: []uint64{20, 10, 30},
+		Version:    msgpb.InsertDataVersion_ColumnBased,
+		NumRows:    3,
+	}
+	msg, err := NewInsertMsg(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 10, msg.BeginTs())
+	assert.EqualValues(t, 30, msg.EndTs())
+	assert.Equal(t, req.GetRowIDs(), msg.GetRowIDs())
+
+	_, err = NewInsertMsg(&msgpb.InsertRequest{Timestamps: []uint64{10}})
+	assert.ErrorIs(t, err, merr.ErrParameterMissing)
+
+	_, err = NewInsertMsg(&msgpb.InsertRequest{Base: &commonpb.MsgBase{}})
+	assert.ErrorIs(t, err, merr.ErrParameterMissing)
+
+	req.RowIDs = []int64{1, 2}
+	_, err = NewInsertMsg(req)
+	assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+}
+
+func TestNewDeleteMsg(t *testing.T) {
+	req := &msgpb.DeleteRequest{
+		Base:       &commonpb.MsgBase{MsgType: commonpb.MsgType_Delete},
+		Timestamps: []uint64{5, 3},
+		NumRows:    2,
+		PrimaryKeys: &schemapb.IDs{
+			IdField: &schemapb.IDs_IntId{IntId: &schemapb.LongArray{Data: []int64{1, 2}}},
+		},
+	}
+	msg, err := NewDeleteMsg(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, msg.BeginTs())
+	assert.EqualValues(t, 5, msg.EndTs())
+
+	_, err = NewDeleteMsg(&msgpb.DeleteRequest{Base: &commonpb.MsgBase{}})
+	assert.ErrorIs(t, err, merr.ErrParameterMissing)
+
+	req.NumRows = 3
+	_, err = NewDeleteMsg(req)
+	assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+}
+
+func TestNewMsgWithBaseTimestamp(t *testing.T) {
+	base := &commonpb.MsgBase{Timestamp: 100}
+
+	timeTick, err := NewTimeTickMsg(&msgpb.TimeTickMsg{Base: base})
+	assert.NoError(t, err)
+	createCollection, err := NewCreateCollectionMsg(&msgpb.CreateCollectionRequest{Base: base})
+	assert.NoError(t, err)
+	dropCollection, err := NewDropCollectionMsg(&msgpb.DropCollectionRequest{Base: base})
+	assert.NoError(t, err)
+	createPartition, err := NewCreatePartitionMsg(&msgpb.CreatePartitionRequest{Base: base})
+	assert.NoError(t, err)
+	dropPartition, err := NewDropPartitionMsg(&msgpb.DropPartitionRequest{Base: base})
+	assert.NoError(t, err)
+	dataNodeTt, err := NewDataNodeTtMsg(&msgpb.DataNodeTtMsg{Base: &commonpb.MsgBase{}, Timestamp: 100})
+	assert.NoError(t, err)
+
+	for _, msg := range []TsMsg{timeTick, createCollection, dropCollection, createPartition, dropPartition, dataNodeTt} {
+		assert.EqualValues(t, 100, msg.BeginTs())
+		assert.EqualValues(t, 100, msg.EndTs())
+	}
+
+	_, err = NewTimeTickMsg(&msgpb.TimeTickMsg{})
+	assert.ErrorIs(t, err, merr.ErrParameterMissing)
+	_, err = NewCreateCollectionMsg(&msgpb.CreateCollectionRequest{})
+	assert.ErrorIs(t, err, merr.ErrParameterMissing)
+	_, err = NewDropCollectionMsg(&msgpb.DropCollectionRequest{})
+	assert.ErrorIs(t, err, merr.ErrParameterMissing)
+	_, err = NewCreatePartitionMsg(&msgpb.CreatePartitionRequest{})
+	assert.ErrorIs(t, err, merr.ErrParameterMissing)
+	_, err = NewDropPartitionMsg(&msgpb.DropPartitionRequest{})
+	assert.ErrorIs(t, err, merr.ErrParameterMissing)
+	_, err = NewDataNodeTtMsg(&msgpb.DataNodeTtMsg{})
+	assert.ErrorIs(t, err, merr.ErrParameterMissing)
+}
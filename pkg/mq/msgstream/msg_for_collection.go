@@ -52,10 +52,11 @@ func (l *LoadCollectionMsg) SourceID() int64 {
 func (l *LoadCollectionMsg) Marshal(input TsMsg) (MarshalType, error) {
 	loadCollectionMsg := input.(*LoadCollectionMsg)
 	loadCollectionRequest := &loadCollectionMsg.LoadCollectionRequest
-	mb, err := proto.Marshal(loadCollectionRequest)
+	mb, err := marshalProto(loadCollectionRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(loadCollectionRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -65,7 +66,7 @@ func (l *LoadCollectionMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &loadCollectionRequest)
+	err = unmarshalProto(in, &loadCollectionRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -107,10 +108,11 @@ func (r *ReleaseCollectionMsg) SourceID() int64 {
 func (r *ReleaseCollectionMsg) Marshal(input TsMsg) (MarshalType, error) {
 	releaseCollectionMsg := input.(*ReleaseCollectionMsg)
 	releaseCollectionRequest := &releaseCollectionMsg.ReleaseCollectionRequest
-	mb, err := proto.Marshal(releaseCollectionRequest)
+	mb, err := marshalProto(releaseCollectionRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(releaseCollectionRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -120,7 +122,7 @@ func (r *ReleaseCollectionMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &releaseCollectionRequest)
+	err = unmarshalProto(in, &releaseCollectionRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -161,10 +163,11 @@ func (f *FlushMsg) SourceID() int64 {
 func (f *FlushMsg) Marshal(input TsMsg) (MarshalType, error) {
 	flushMsg := input.(*FlushMsg)
 	flushRequest := &flushMsg.FlushRequest
-	mb, err := proto.Marshal(flushRequest)
+	mb, err := marshalProto(flushRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(flushRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -174,7 +177,7 @@ func (f *FlushMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &flushRequest)
+	err = unmarshalProto(in, &flushRequest)
 	if err != nil {
 		return nil, err
 	}
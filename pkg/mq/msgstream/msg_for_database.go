@@ -50,10 +50,11 @@ func (c *CreateDatabaseMsg) SourceID() int64 {
 func (c *CreateDatabaseMsg) Marshal(input TsMsg) (MarshalType, error) {
 	createDataBaseMsg := input.(*CreateDatabaseMsg)
 	createDatabaseRequest := &createDataBaseMsg.CreateDatabaseRequest
-	mb, err := proto.Marshal(createDatabaseRequest)
+	mb, err := marshalProto(createDatabaseRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(createDatabaseRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -63,7 +64,7 @@ func (c *CreateDatabaseMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &createDatabaseRequest)
+	err = unmarshalProto(in, &createDatabaseRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -104,10 +105,11 @@ func (d *DropDatabaseMsg) SourceID() int64 {
 func (d *DropDatabaseMsg) Marshal(input TsMsg) (MarshalType, error) {
 	dropDataBaseMsg := input.(*DropDatabaseMsg)
 	dropDatabaseRequest := &dropDataBaseMsg.DropDatabaseRequest
-	mb, err := proto.Marshal(dropDatabaseRequest)
+	mb, err := marshalProto(dropDatabaseRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(dropDatabaseRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -117,7 +119,7 @@ func (d *DropDatabaseMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &dropDatabaseRequest)
+	err = unmarshalProto(in, &dropDatabaseRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,68 @@
+/*
+ * Licensed to the LF AI & Data foundation under one
+ * or more contributor license agreements. See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership. The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License. You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package msgstream
+
+import (
+	"strconv"
+
+	"github.com/milvus-io/milvus/pkg/util/merr"
+)
+
+// targetSegmentPropertyKey is the message property holding the segment the deletes are applied to.
+const targetSegmentPropertyKey = "target_segment_id"
+
+// TargetSegmentID returns the segment the deletes are applied to, 0 if not targeted.
+func (dt *DeleteMsg) TargetSegmentID() int64 {
+	if dt == nil {
+		return 0
+	}
+	return dt.targetSegmentID
+}
+
+// SetTargetSegmentID sets the segment the deletes are applied to,
+// which saves the lookup of the segments the deleted primary keys may exist in.
+// It's carried in the message properties when produced, like the virtual channel.
+func (dt *DeleteMsg) SetTargetSegmentID(segmentID int64) {
+	dt.targetSegmentID = segmentID
+}
+
+// InjectTargetSegment puts the target segment of the delete message in the properties if set.
+func InjectTargetSegment(msg TsMsg, properties map[string]string) {
+	if deleteMsg, ok := msg.(*DeleteMsg); ok && deleteMsg.TargetSegmentID() != 0 {
+		properties[targetSegmentPropertyKey] = strconv.FormatInt(deleteMsg.TargetSegmentID(), 10)
+	}
+}
+
+// ExtractTargetSegment restores the target segment of the delete message from the properties.
+func ExtractTargetSegment(msg TsMsg, properties map[string]string) error {
+	value, ok := properties[targetSegmentPropertyKey]
+	if !ok {
+		return nil
+	}
+	deleteMsg, ok := msg.(*DeleteMsg)
+	if !ok {
+		return nil
+	}
+	segmentID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return merr.WrapErrParameterInvalidMsg("invalid target segment %s", value)
+	}
+	deleteMsg.SetTargetSegmentID(segmentID)
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the LF AI & Data foundation under one
+ * or more contributor license agreements. See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership. The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License. You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package msgstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus/pkg/mq/msgstream/mqwrapper"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+)
+
+func TestDeleteMsgTargetSegment(t *testing.T) {
+	msg := getTsMsg(commonpb.MsgType_Delete, 1).(*DeleteMsg)
+	assert.Zero(t, msg.TargetSegmentID())
+	assert.Zero(t, (*DeleteMsg)(nil).TargetSegmentID())
+	msg.SetTargetSegmentID(1001)
+	assert.EqualValues(t, 1001, msg.TargetSegmentID())
+	// kept by the messages derived
+	assert.EqualValues(t, 1001, msg.Dedup().TargetSegmentID())
+	assert.EqualValues(t, 1001, msg.FilterByTsRange(0, msg.EndTs()).TargetSegmentID())
+
+	payload, err := msg.Marshal(msg)
+	assert.NoError(t, err)
+	bytes, err := convertToByteArray(payload)
+	assert.NoError(t, err)
+	properties := map[string]string{}
+	InjectTargetSegment(msg, properties)
+
+	id := mqwrapper.NewMockMessageID(t)
+	id.EXPECT().Serialize().Return([]byte{1})
+	ms := &mqMsgStream{unmarshal: (&ProtoUDFactory{}).NewUnmarshalDispatcher()}
+	consumed, err := ms.getTsMsgFromConsumerMsg(&fakeMqMessage{topic: "topic", payload: bytes, properties: properties, id: id})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1001, consumed.(*DeleteMsg).TargetSegmentID())
+	assert.Equal(t, msg.primaryKeys(), consumed.(*DeleteMsg).primaryKeys())
+
+	// not targeted
+	properties = map[string]string{}
+	InjectTargetSegment(getTsMsg(commonpb.MsgType_Delete, 1), properties)
+	InjectTargetSegment(getTsMsg(commonpb.MsgType_Insert, 1), properties)
+	assert.Empty(t, properties)
+	consumed, err = ms.getTsMsgFromConsumerMsg(&fakeMqMessage{topic: "topic", payload: bytes, properties: properties, id: id})
+	assert.NoError(t, err)
+	assert.Zero(t, consumed.(*DeleteMsg).TargetSegmentID())
+
+	// corrupted
+	properties = map[string]string{targetSegmentPropertyKey: "segment"}
+	_, err = ms.getTsMsgFromConsumerMsg(&fakeMqMessage{topic: "topic", payload: bytes, properties: properties, id: id})
+	assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+}
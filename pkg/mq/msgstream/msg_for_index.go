@@ -57,10 +57,11 @@ func (it *CreateIndexMsg) SourceID() int64 {
 func (it *CreateIndexMsg) Marshal(input TsMsg) (MarshalType, error) {
 	createIndexMsg := input.(*CreateIndexMsg)
 	createIndexRequest := &createIndexMsg.CreateIndexRequest
-	mb, err := proto.Marshal(createIndexRequest)
+	mb, err := marshalProto(createIndexRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(createIndexRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -71,7 +72,7 @@ func (it *CreateIndexMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &createIndexRequest)
+	err = unmarshalProto(in, &createIndexRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +87,12 @@ func (it *CreateIndexMsg) Size() int {
 	return proto.Size(&it.CreateIndexRequest)
 }
 
-// AlterIndexMsg is a message pack that contains create index request
+// AlterIndexMsg is a message pack that contains alter index request,
+// which carries the collection, the index and the new index params.
+//
+// The consumers shall apply the new params to the index of the collection
+// no earlier than the timestamp of the message, and reload the index
+// if the altered params take effect only on loading.
 type AlterIndexMsg struct {
 	BaseMsg
 	milvuspb.AlterIndexRequest
@@ -119,10 +125,11 @@ func (it *AlterIndexMsg) SourceID() int64 {
 func (it *AlterIndexMsg) Marshal(input TsMsg) (MarshalType, error) {
 	AlterIndexMsg := input.(*AlterIndexMsg)
 	AlterIndexRequest := &AlterIndexMsg.AlterIndexRequest
-	mb, err := proto.Marshal(AlterIndexRequest)
+	mb, err := marshalProto(AlterIndexRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(AlterIndexRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -133,7 +140,7 @@ func (it *AlterIndexMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &alterIndexRequest)
+	err = unmarshalProto(in, &alterIndexRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -175,10 +182,11 @@ func (d *DropIndexMsg) SourceID() int64 {
 func (d *DropIndexMsg) Marshal(input TsMsg) (MarshalType, error) {
 	dropIndexMsg := input.(*DropIndexMsg)
 	dropIndexRequest := &dropIndexMsg.DropIndexRequest
-	mb, err := proto.Marshal(dropIndexRequest)
+	mb, err := marshalProto(dropIndexRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(dropIndexRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -188,7 +196,7 @@ func (d *DropIndexMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &dropIndexRequest)
+	err = unmarshalProto(in, &dropIndexRequest)
 	if err != nil {
 		return nil, err
 	}
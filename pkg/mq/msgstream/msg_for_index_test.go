@@ -63,6 +63,51 @@ func TestCreateIndex(t *testing.T) {
 	assert.True(t, msg.Size() > 0)
 }
 
+func TestAlterIndex(t *testing.T) {
+	var msg TsMsg = &AlterIndexMsg{
+		AlterIndexRequest: milvuspb.AlterIndexRequest{
+			Base: &commonpb.MsgBase{
+				MsgType:       commonpb.MsgType_AlterIndex,
+				MsgID:         100,
+				Timestamp:     1000,
+				SourceID:      10000,
+				TargetID:      100000,
+				ReplicateInfo: nil,
+			},
+			DbName:         "unit_db",
+			CollectionName: "col1",
+			IndexName:      "unit_index",
+			ExtraParams: []*commonpb.KeyValuePair{
+				{Key: "mmap.enabled", Value: "true"},
+			},
+		},
+	}
+	assert.EqualValues(t, 100, msg.ID())
+	msg.SetID(200)
+	assert.EqualValues(t, 200, msg.ID())
+	assert.Equal(t, commonpb.MsgType_AlterIndex, msg.Type())
+	assert.EqualValues(t, 10000, msg.SourceID())
+
+	msgBytes, err := msg.Marshal(msg)
+	assert.NoError(t, err)
+
+	var newMsg TsMsg = &AlterIndexMsg{}
+	_, err = newMsg.Unmarshal("1")
+	assert.Error(t, err)
+
+	newMsg, err = (&ProtoUDFactory{}).NewUnmarshalDispatcher().Unmarshal(msgBytes, commonpb.MsgType_AlterIndex)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 200, newMsg.ID())
+	assert.EqualValues(t, 1000, newMsg.BeginTs())
+	assert.EqualValues(t, 1000, newMsg.EndTs())
+	alterIndexMsg := newMsg.(*AlterIndexMsg)
+	assert.Equal(t, "col1", alterIndexMsg.GetCollectionName())
+	assert.Equal(t, "unit_index", alterIndexMsg.GetIndexName())
+	assert.Equal(t, "true", alterIndexMsg.GetExtraParams()[0].GetValue())
+
+	assert.True(t, msg.Size() > 0)
+}
+
 func TestDropIndex(t *testing.T) {
 	var msg TsMsg = &DropIndexMsg{
 		DropIndexRequest: milvuspb.DropIndexRequest{
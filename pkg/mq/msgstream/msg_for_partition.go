@@ -50,10 +50,11 @@ func (l *LoadPartitionsMsg) SourceID() int64 {
 func (l *LoadPartitionsMsg) Marshal(input TsMsg) (MarshalType, error) {
 	loadPartitionsMsg := input.(*LoadPartitionsMsg)
 	loadPartitionsRequest := &loadPartitionsMsg.LoadPartitionsRequest
-	mb, err := proto.Marshal(loadPartitionsRequest)
+	mb, err := marshalProto(loadPartitionsRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(loadPartitionsRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -63,7 +64,7 @@ func (l *LoadPartitionsMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &loadPartitionsRequest)
+	err = unmarshalProto(in, &loadPartitionsRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -104,10 +105,11 @@ func (r *ReleasePartitionsMsg) SourceID() int64 {
 func (r *ReleasePartitionsMsg) Marshal(input TsMsg) (MarshalType, error) {
 	releasePartitionsMsg := input.(*ReleasePartitionsMsg)
 	releasePartitionsRequest := &releasePartitionsMsg.ReleasePartitionsRequest
-	mb, err := proto.Marshal(releasePartitionsRequest)
+	mb, err := marshalProto(releasePartitionsRequest)
 	if err != nil {
 		return nil, err
 	}
+	recordMarshalSize(releasePartitionsRequest.GetBase().GetMsgType(), len(mb))
 	return mb, nil
 }
 
@@ -117,7 +119,7 @@ func (r *ReleasePartitionsMsg) Unmarshal(input MarshalType) (TsMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(in, &releasePartitionsRequest)
+	err = unmarshalProto(in, &releasePartitionsRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,130 @@
+/*
+ * Licensed to the LF AI & Data foundation under one
+ * or more contributor license agreements. See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership. The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License. You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package msgstream
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+)
+
+// MultiChannelTimeTickMsg coalesces the time ticks of several channels produced at the same tick.
+// It isn't a TsMsg, as there is no MsgType of its own to dispatch it by,
+// the ticks are produced as the TimeTickMsg of each channel by TimeTickMsgs,
+// and coalesced back by CoalesceTimeTicks after consumed.
+type MultiChannelTimeTickMsg struct {
+	Base *commonpb.MsgBase
+	// the time tick of each channel
+	ChannelTimestamps map[string]Timestamp
+}
+
+func (m *MultiChannelTimeTickMsg) ID() UniqueID {
+	return m.Base.GetMsgID()
+}
+
+func (m *MultiChannelTimeTickMsg) SetID(id UniqueID) {
+	if m.Base == nil {
+		m.Base = &commonpb.MsgBase{MsgType: commonpb.MsgType_TimeTick}
+	}
+	m.Base.MsgID = id
+}
+
+func (m *MultiChannelTimeTickMsg) SourceID() int64 {
+	return m.Base.GetSourceID()
+}
+
+// BeginTs returns the minimum time tick across the channels.
+func (m *MultiChannelTimeTickMsg) BeginTs() Timestamp {
+	if len(m.ChannelTimestamps) == 0 {
+		return m.Base.GetTimestamp()
+	}
+	var begin Timestamp
+	first := true
+	for _, ts := range m.ChannelTimestamps {
+		if first || ts < begin {
+			begin = ts
+			first = false
+		}
+	}
+	return begin
+}
+
+// EndTs returns the maximum time tick across the channels.
+func (m *MultiChannelTimeTickMsg) EndTs() Timestamp {
+	if len(m.ChannelTimestamps) == 0 {
+		return m.Base.GetTimestamp()
+	}
+	var end Timestamp
+	for _, ts := range m.ChannelTimestamps {
+		if ts > end {
+			end = ts
+		}
+	}
+	return end
+}
+
+// Channels returns the channels sorted.
+func (m *MultiChannelTimeTickMsg) Channels() []string {
+	channels := make([]string, 0, len(m.ChannelTimestamps))
+	for channel := range m.ChannelTimestamps {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// TimeTickMsgs splits the message into the TimeTickMsg to produce into each channel,
+// which carries the base of the message with the time tick of the channel.
+func (m *MultiChannelTimeTickMsg) TimeTickMsgs() map[string]*TimeTickMsg {
+	msgs := make(map[string]*TimeTickMsg, len(m.ChannelTimestamps))
+	for channel, ts := range m.ChannelTimestamps {
+		base := &commonpb.MsgBase{}
+		if m.Base != nil {
+			base = proto.Clone(m.Base).(*commonpb.MsgBase)
+		}
+		base.MsgType = commonpb.MsgType_TimeTick
+		base.Timestamp = ts
+		msgs[channel] = &TimeTickMsg{
+			BaseMsg: BaseMsg{
+				BeginTimestamp: ts,
+				EndTimestamp:   ts,
+			},
+			TimeTickMsg: msgpb.TimeTickMsg{Base: base},
+		}
+	}
+	return msgs
+}
+
+// CoalesceTimeTicks coalesces the TimeTickMsg consumed from each channel,
+// the base is taken from the tick of the first channel sorted, with the time tick of its own.
+func CoalesceTimeTicks(ticks map[string]*TimeTickMsg) *MultiChannelTimeTickMsg {
+	msg := &MultiChannelTimeTickMsg{
+		ChannelTimestamps: make(map[string]Timestamp, len(ticks)),
+	}
+	for channel, tick := range ticks {
+		msg.ChannelTimestamps[channel] = tick.GetBase().GetTimestamp()
+	}
+	if channels := msg.Channels(); len(channels) > 0 && ticks[channels[0]].GetBase() != nil {
+		msg.Base = proto.Clone(ticks[channels[0]].GetBase()).(*commonpb.MsgBase)
+	}
+	return msg
+}
@@ -0,0 +1,91 @@
+/*
+ * Licensed to the LF AI & Data foundation under one
+ * or more contributor license agreements. See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership. The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License. You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package msgstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+)
+
+func TestMultiChannelTimeTickMsg(t *testing.T) {
+	msg := &MultiChannelTimeTickMsg{
+		Base: &commonpb.MsgBase{
+			MsgType:   commonpb.MsgType_TimeTick,
+			MsgID:     100,
+			Timestamp: 1000,
+			SourceID:  10000,
+		},
+		ChannelTimestamps: map[string]Timestamp{
+			"dml_0": 1003,
+			"dml_1": 1001,
+			"dml_2": 1005,
+			"dml_3": 1002,
+		},
+	}
+	assert.EqualValues(t, 100, msg.ID())
+	msg.SetID(200)
+	assert.EqualValues(t, 200, msg.ID())
+	assert.EqualValues(t, 10000, msg.SourceID())
+	assert.EqualValues(t, 1001, msg.BeginTs())
+	assert.EqualValues(t, 1005, msg.EndTs())
+	assert.Equal(t, []string{"dml_0", "dml_1", "dml_2", "dml_3"}, msg.Channels())
+
+	// produced and consumed as the time tick of each channel
+	dispatcher := (&ProtoUDFactory{}).NewUnmarshalDispatcher()
+	consumed := make(map[string]*TimeTickMsg)
+	for channel, tick := range msg.TimeTickMsgs() {
+		assert.EqualValues(t, msg.ChannelTimestamps[channel], tick.BeginTs())
+		assert.EqualValues(t, msg.ChannelTimestamps[channel], tick.EndTs())
+		msgBytes, err := tick.Marshal(tick)
+		require.NoError(t, err)
+		unmarshaled, err := dispatcher.Unmarshal(msgBytes, commonpb.MsgType_TimeTick)
+		require.NoError(t, err)
+		timeTick, ok := unmarshaled.(*TimeTickMsg)
+		require.True(t, ok)
+		consumed[channel] = timeTick
+	}
+	// the base of the message is left unchanged
+	assert.EqualValues(t, 1000, msg.Base.GetTimestamp())
+
+	coalesced := CoalesceTimeTicks(consumed)
+	assert.EqualValues(t, 200, coalesced.ID())
+	assert.Equal(t, commonpb.MsgType_TimeTick, coalesced.Base.GetMsgType())
+	assert.EqualValues(t, 10000, coalesced.SourceID())
+	assert.Equal(t, msg.ChannelTimestamps, coalesced.ChannelTimestamps)
+	assert.EqualValues(t, 1001, coalesced.BeginTs())
+	assert.EqualValues(t, 1005, coalesced.EndTs())
+
+	// no channel
+	emptyMsg := &MultiChannelTimeTickMsg{Base: &commonpb.MsgBase{Timestamp: 1000}}
+	assert.EqualValues(t, 1000, emptyMsg.BeginTs())
+	assert.EqualValues(t, 1000, emptyMsg.EndTs())
+	assert.Empty(t, emptyMsg.TimeTickMsgs())
+	assert.Empty(t, CoalesceTimeTicks(nil).ChannelTimestamps)
+
+	// no base
+	noBase := &MultiChannelTimeTickMsg{ChannelTimestamps: map[string]Timestamp{"dml_0": 1000}}
+	noBase.SetID(300)
+	assert.EqualValues(t, 300, noBase.ID())
+	assert.Equal(t, commonpb.MsgType_TimeTick, noBase.Base.GetMsgType())
+	assert.Equal(t, commonpb.MsgType_TimeTick, (&MultiChannelTimeTickMsg{ChannelTimestamps: map[string]Timestamp{"dml_0": 1000}}).TimeTickMsgs()["dml_0"].Type())
+}
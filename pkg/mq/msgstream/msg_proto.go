@@ -0,0 +1,34 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	protov1 "github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// marshalProto marshals the message with google.golang.org/protobuf,
+// the output is byte identical to the deprecated github.com/golang/protobuf.
+func marshalProto(m protov1.Message) ([]byte, error) {
+	return proto.Marshal(protov1.MessageV2(m))
+}
+
+// unmarshalProto unmarshals the message with google.golang.org/protobuf,
+// the message is reset before unmarshaling like the deprecated github.com/golang/protobuf.
+func unmarshalProto(b []byte, m protov1.Message) error {
+	return proto.Unmarshal(b, protov1.MessageV2(m))
+}
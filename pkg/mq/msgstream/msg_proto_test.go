@@ -0,0 +1,139 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"testing"
+
+	protov1 "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/milvuspb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+)
+
+func TestMarshalProtoCompatible(t *testing.T) {
+	base := func(msgType commonpb.MsgType) *commonpb.MsgBase {
+		return &commonpb.MsgBase{MsgType: msgType, MsgID: 1, Timestamp: 100, SourceID: 2}
+	}
+	insertMsg := &InsertMsg{InsertRequest: msgpb.InsertRequest{
+		Base:         base(commonpb.MsgType_Insert),
+		CollectionID: 1,
+		PartitionID:  2,
+		Timestamps:   []uint64{100, 101},
+		RowIDs:       []int64{1, 2},
+		FieldsData: []*schemapb.FieldData{{
+			Type:      schemapb.DataType_Int64,
+			FieldName: "pk",
+			Field: &schemapb.FieldData_Scalars{
+				Scalars: &schemapb.ScalarField{
+					Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: []int64{1, 2}}},
+				},
+			},
+		}},
+		NumRows: 2,
+		Version: msgpb.InsertDataVersion_ColumnBased,
+	}}
+	deleteMsg := &DeleteMsg{DeleteRequest: msgpb.DeleteRequest{
+		Base:         base(commonpb.MsgType_Delete),
+		CollectionID: 1,
+		PrimaryKeys: &schemapb.IDs{
+			IdField: &schemapb.IDs_StrId{StrId: &schemapb.StringArray{Data: []string{"a", "b"}}},
+		},
+		Timestamps: []uint64{100, 101},
+		NumRows:    2,
+	}}
+	timeTickMsg := &TimeTickMsg{TimeTickMsg: msgpb.TimeTickMsg{Base: base(commonpb.MsgType_TimeTick)}}
+	createCollectionMsg := &CreateCollectionMsg{CreateCollectionRequest: msgpb.CreateCollectionRequest{
+		Base:           base(commonpb.MsgType_CreateCollection),
+		CollectionName: "c",
+		PartitionIDs:   []int64{1, 2},
+		Schema:         []byte("schema"),
+	}}
+	dropCollectionMsg := &DropCollectionMsg{DropCollectionRequest: msgpb.DropCollectionRequest{Base: base(commonpb.MsgType_DropCollection), CollectionID: 1}}
+	createPartitionMsg := &CreatePartitionMsg{CreatePartitionRequest: msgpb.CreatePartitionRequest{Base: base(commonpb.MsgType_CreatePartition), PartitionName: "p"}}
+	dropPartitionMsg := &DropPartitionMsg{DropPartitionRequest: msgpb.DropPartitionRequest{Base: base(commonpb.MsgType_DropPartition), PartitionID: 2}}
+	dataNodeTtMsg := &DataNodeTtMsg{DataNodeTtMsg: msgpb.DataNodeTtMsg{
+		Base:        base(commonpb.MsgType_DataNodeTt),
+		ChannelName: "ch",
+		Timestamp:   100,
+		SegmentsStats: []*commonpb.SegmentStats{
+			{SegmentID: 1, NumRows: 10},
+		},
+	}}
+	loadCollectionMsg := &LoadCollectionMsg{LoadCollectionRequest: milvuspb.LoadCollectionRequest{Base: base(commonpb.MsgType_LoadCollection), CollectionName: "c", ReplicaNumber: 2}}
+	releaseCollectionMsg := &ReleaseCollectionMsg{ReleaseCollectionRequest: milvuspb.ReleaseCollectionRequest{Base: base(commonpb.MsgType_ReleaseCollection), CollectionName: "c"}}
+	flushMsg := &FlushMsg{FlushRequest: milvuspb.FlushRequest{Base: base(commonpb.MsgType_Flush), CollectionNames: []string{"c1", "c2"}}}
+	createDatabaseMsg := &CreateDatabaseMsg{CreateDatabaseRequest: milvuspb.CreateDatabaseRequest{Base: base(commonpb.MsgType_CreateDatabase), DbName: "db"}}
+	dropDatabaseMsg := &DropDatabaseMsg{DropDatabaseRequest: milvuspb.DropDatabaseRequest{Base: base(commonpb.MsgType_DropDatabase), DbName: "db"}}
+	createIndexMsg := &CreateIndexMsg{CreateIndexRequest: milvuspb.CreateIndexRequest{
+		Base:        base(commonpb.MsgType_CreateIndex),
+		FieldName:   "vec",
+		ExtraParams: []*commonpb.KeyValuePair{{Key: "index_type", Value: "HNSW"}},
+	}}
+	alterIndexMsg := &AlterIndexMsg{AlterIndexRequest: milvuspb.AlterIndexRequest{
+		Base:        base(commonpb.MsgType_AlterIndex),
+		IndexName:   "idx",
+		ExtraParams: []*commonpb.KeyValuePair{{Key: "mmap.enabled", Value: "true"}},
+	}}
+	dropIndexMsg := &DropIndexMsg{DropIndexRequest: milvuspb.DropIndexRequest{Base: base(commonpb.MsgType_DropIndex), IndexName: "idx"}}
+	loadPartitionsMsg := &LoadPartitionsMsg{LoadPartitionsRequest: milvuspb.LoadPartitionsRequest{Base: base(commonpb.MsgType_LoadPartitions), PartitionNames: []string{"p"}}}
+	releasePartitionsMsg := &ReleasePartitionsMsg{ReleasePartitionsRequest: milvuspb.ReleasePartitionsRequest{Base: base(commonpb.MsgType_ReleasePartitions), PartitionNames: []string{"p"}}}
+
+	cases := []struct {
+		msg     TsMsg
+		request protov1.Message
+	}{
+		{insertMsg, &insertMsg.InsertRequest},
+		{deleteMsg, &deleteMsg.DeleteRequest},
+		{timeTickMsg, &timeTickMsg.TimeTickMsg},
+		{createCollectionMsg, &createCollectionMsg.CreateCollectionRequest},
+		{dropCollectionMsg, &dropCollectionMsg.DropCollectionRequest},
+		{createPartitionMsg, &createPartitionMsg.CreatePartitionRequest},
+		{dropPartitionMsg, &dropPartitionMsg.DropPartitionRequest},
+		{dataNodeTtMsg, &dataNodeTtMsg.DataNodeTtMsg},
+		{loadCollectionMsg, &loadCollectionMsg.LoadCollectionRequest},
+		{releaseCollectionMsg, &releaseCollectionMsg.ReleaseCollectionRequest},
+		{flushMsg, &flushMsg.FlushRequest},
+		{createDatabaseMsg, &createDatabaseMsg.CreateDatabaseRequest},
+		{dropDatabaseMsg, &dropDatabaseMsg.DropDatabaseRequest},
+		{createIndexMsg, &createIndexMsg.CreateIndexRequest},
+		{alterIndexMsg, &alterIndexMsg.AlterIndexRequest},
+		{dropIndexMsg, &dropIndexMsg.DropIndexRequest},
+		{loadPartitionsMsg, &loadPartitionsMsg.LoadPartitionsRequest},
+		{releasePartitionsMsg, &releasePartitionsMsg.ReleasePartitionsRequest},
+	}
+	for _, c := range cases {
+		t.Run(MsgTypeName(c.msg.Type()), func(t *testing.T) {
+			expected, err := protov1.Marshal(c.request)
+			assert.NoError(t, err)
+
+			bytes, err := c.msg.Marshal(c.msg)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, bytes)
+
+			unmarshaled, err := c.msg.Unmarshal(bytes)
+			assert.NoError(t, err)
+			assert.Equal(t, c.msg.Type(), unmarshaled.Type())
+			roundTrip, err := unmarshaled.Marshal(unmarshaled)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, roundTrip)
+		})
+	}
+}
@@ -20,11 +20,17 @@ import (
 	"context"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/metrics"
+	"github.com/milvus-io/milvus/pkg/mq/msgstream/mqwrapper"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/paramtable"
 )
 
 func TestBaseMsg(t *testing.T) {
@@ -53,6 +59,33 @@ func TestBaseMsg(t *testing.T) {
 	assert.Equal(t, position, baseMsg.Position())
 }
 
+func TestMsgVChannel(t *testing.T) {
+	msg := getTsMsg(commonpb.MsgType_Insert, 1)
+	msg.(*InsertMsg).SetVChannel("by-dev-rootcoord-dml_0_100v0")
+
+	payload, err := msg.Marshal(msg)
+	assert.NoError(t, err)
+	bytes, err := convertToByteArray(payload)
+	assert.NoError(t, err)
+	properties := map[string]string{}
+	InjectVChannel(msg, properties)
+
+	id := mqwrapper.NewMockMessageID(t)
+	id.EXPECT().Serialize().Return([]byte{1})
+	ms := &mqMsgStream{unmarshal: (&ProtoUDFactory{}).NewUnmarshalDispatcher()}
+	consumed, err := ms.getTsMsgFromConsumerMsg(&fakeMqMessage{topic: "topic", payload: bytes, properties: properties, id: id})
+	assert.NoError(t, err)
+	assert.Equal(t, "by-dev-rootcoord-dml_0_100v0", consumed.(*InsertMsg).VChannel())
+
+	// not set
+	properties = map[string]string{}
+	InjectVChannel(getTsMsg(commonpb.MsgType_Insert, 1), properties)
+	assert.Empty(t, properties)
+	consumed, err = ms.getTsMsgFromConsumerMsg(&fakeMqMessage{topic: "topic", payload: bytes, properties: properties, id: id})
+	assert.NoError(t, err)
+	assert.Empty(t, consumed.(*InsertMsg).VChannel())
+}
+
 func Test_convertToByteArray(t *testing.T) {
 	{
 		bytes := []byte{1, 2, 3}
@@ -211,6 +244,69 @@ func TestInsertMsg_CheckAligned(t *testing.T) {
 	assert.NoError(t, msg1.CheckAligned())
 }
 
+func TestInsertMsg_Validate(t *testing.T) {
+	longField := func(data ...int64) *schemapb.FieldData {
+		return &schemapb.FieldData{
+			Type:      schemapb.DataType_Int64,
+			FieldName: "pk",
+			Field: &schemapb.FieldData_Scalars{
+				Scalars: &schemapb.ScalarField{
+					Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: data}},
+				},
+			},
+		}
+	}
+	newMsg := func() *InsertMsg {
+		return &InsertMsg{
+			InsertRequest: msgpb.InsertRequest{
+				Base:       &commonpb.MsgBase{MsgType: commonpb.MsgType_Insert},
+				Timestamps: []uint64{1, 2},
+				RowIDs:     []int64{1, 2},
+				FieldsData: []*schemapb.FieldData{longField(1, 2), longField(3, 4)},
+				NumRows:    2,
+				Version:    msgpb.InsertDataVersion_ColumnBased,
+			},
+		}
+	}
+
+	assert.NoError(t, newMsg().Validate())
+
+	msg := newMsg()
+	msg.FieldsData[1] = longField(3)
+	assert.ErrorIs(t, msg.Validate(), merr.ErrParameterInvalid)
+
+	// the columns agree with NumRows but not the timestamps
+	msg = newMsg()
+	msg.FieldsData = []*schemapb.FieldData{longField(1, 2, 3)}
+	msg.Timestamps = []uint64{1, 2, 3}
+	assert.ErrorIs(t, msg.Validate(), merr.ErrParameterInvalid)
+
+	msg = newMsg()
+	msg.RowIDs = []int64{1}
+	assert.ErrorIs(t, msg.Validate(), merr.ErrParameterInvalid)
+
+	t.Run("strict unmarshal", func(t *testing.T) {
+		msg := newMsg()
+		msg.FieldsData[1] = longField(3)
+		bytes, err := msg.Marshal(msg)
+		assert.NoError(t, err)
+
+		_, err = msg.Unmarshal(bytes)
+		assert.NoError(t, err)
+
+		paramtable.Get().Save(paramtable.Get().MQCfg.StrictInsertValidation.Key, "true")
+		defer paramtable.Get().Reset(paramtable.Get().MQCfg.StrictInsertValidation.Key)
+		_, err = msg.Unmarshal(bytes)
+		assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+
+		aligned := newMsg()
+		bytes, err = aligned.Marshal(aligned)
+		assert.NoError(t, err)
+		_, err = aligned.Unmarshal(bytes)
+		assert.NoError(t, err)
+	})
+}
+
 func TestInsertMsg_IndexMsg(t *testing.T) {
 	msg := &InsertMsg{
 		BaseMsg: BaseMsg{
@@ -269,6 +365,187 @@ func TestInsertMsg_IndexMsg(t *testing.T) {
 	assert.Equal(t, int64(1), indexMsg.FieldsData[0].Field.(*schemapb.FieldData_Scalars).Scalars.Data.(*schemapb.ScalarField_LongData).LongData.Data[0])
 }
 
+func TestInsertMsg_SplitByPartition(t *testing.T) {
+	partitions := []int64{100, 200, 100, 300, 200}
+	partitionOf := func(row int) int64 { return partitions[row] }
+
+	t.Run("column based", func(t *testing.T) {
+		msg := &InsertMsg{
+			BaseMsg: BaseMsg{HashValues: []uint32{0, 1, 0, 1, 0}},
+			InsertRequest: msgpb.InsertRequest{
+				Base:          &commonpb.MsgBase{MsgType: commonpb.MsgType_Insert},
+				CollectionID:  1,
+				PartitionID:   100,
+				PartitionName: "p100",
+				Timestamps:    []uint64{10, 20, 30, 40, 50},
+				RowIDs:        []int64{1, 2, 3, 4, 5},
+				FieldsData: []*schemapb.FieldData{
+					{
+						Type:    schemapb.DataType_Int64,
+						FieldId: 100,
+						Field: &schemapb.FieldData_Scalars{
+							Scalars: &schemapb.ScalarField{
+								Data: &schemapb.ScalarField_LongData{
+									LongData: &schemapb.LongArray{Data: []int64{11, 12, 13, 14, 15}},
+								},
+							},
+						},
+					},
+				},
+				NumRows: 5,
+				Version: msgpb.InsertDataVersion_ColumnBased,
+			},
+		}
+
+		result := msg.SplitByPartition(partitionOf)
+		assert.Len(t, result, 3)
+
+		p100 := result[100]
+		assert.EqualValues(t, 100, p100.GetPartitionID())
+		assert.Equal(t, "p100", p100.GetPartitionName())
+		assert.EqualValues(t, 1, p100.GetCollectionID())
+		assert.Equal(t, []uint64{10, 30}, p100.GetTimestamps())
+		assert.Equal(t, []int64{1, 3}, p100.GetRowIDs())
+		assert.Equal(t, []int64{11, 13}, p100.GetFieldsData()[0].GetScalars().GetLongData().GetData())
+		assert.Equal(t, []uint32{0, 0}, p100.HashValues)
+		assert.EqualValues(t, 2, p100.NRows())
+		assert.EqualValues(t, 10, p100.BeginTs())
+		assert.EqualValues(t, 30, p100.EndTs())
+		assert.NoError(t, p100.CheckAligned())
+
+		p200 := result[200]
+		assert.Empty(t, p200.GetPartitionName())
+		assert.Equal(t, []int64{2, 5}, p200.GetRowIDs())
+		assert.Equal(t, []int64{12, 15}, p200.GetFieldsData()[0].GetScalars().GetLongData().GetData())
+		assert.EqualValues(t, 20, p200.BeginTs())
+		assert.EqualValues(t, 50, p200.EndTs())
+		assert.NoError(t, p200.CheckAligned())
+
+		p300 := result[300]
+		assert.Equal(t, []int64{4}, p300.GetRowIDs())
+		assert.Equal(t, []int64{14}, p300.GetFieldsData()[0].GetScalars().GetLongData().GetData())
+		assert.NoError(t, p300.CheckAligned())
+	})
+
+	t.Run("row based", func(t *testing.T) {
+		msg := &InsertMsg{
+			InsertRequest: msgpb.InsertRequest{
+				Timestamps: []uint64{10, 20, 30, 40, 50},
+				RowIDs:     []int64{1, 2, 3, 4, 5},
+				RowData:    []*commonpb.Blob{{Value: []byte{1}}, {Value: []byte{2}}, {Value: []byte{3}}, {Value: []byte{4}}, {Value: []byte{5}}},
+				Version:    msgpb.InsertDataVersion_RowBased,
+			},
+		}
+
+		result := msg.SplitByPartition(partitionOf)
+		assert.Len(t, result, 3)
+		assert.Equal(t, []int64{1, 3}, result[100].GetRowIDs())
+		assert.Equal(t, []byte{3}, result[100].GetRowData()[1].GetValue())
+		assert.Equal(t, []byte{4}, result[300].GetRowData()[0].GetValue())
+		assert.Empty(t, result[100].HashValues)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		msg := &InsertMsg{InsertRequest: msgpb.InsertRequest{Version: msgpb.InsertDataVersion_ColumnBased}}
+		assert.Empty(t, msg.SplitByPartition(partitionOf))
+	})
+}
+
+func TestInsertMsg_SplitByTimestamp(t *testing.T) {
+	newMsg := func() *InsertMsg {
+		return &InsertMsg{
+			BaseMsg: BaseMsg{HashValues: []uint32{0, 1, 2, 3, 4}},
+			InsertRequest: msgpb.InsertRequest{
+				Base:          &commonpb.MsgBase{MsgType: commonpb.MsgType_Insert},
+				CollectionID:  1,
+				PartitionID:   100,
+				PartitionName: "p100",
+				SegmentID:     1000,
+				Timestamps:    []uint64{10, 40, 20, 50, 30},
+				RowIDs:        []int64{1, 2, 3, 4, 5},
+				FieldsData: []*schemapb.FieldData{
+					{
+						Type:    schemapb.DataType_Int64,
+						FieldId: 100,
+						Field: &schemapb.FieldData_Scalars{
+							Scalars: &schemapb.ScalarField{
+								Data: &schemapb.ScalarField_LongData{
+									LongData: &schemapb.LongArray{Data: []int64{11, 12, 13, 14, 15}},
+								},
+							},
+						},
+					},
+				},
+				NumRows: 5,
+				Version: msgpb.InsertDataVersion_ColumnBased,
+			},
+		}
+	}
+
+	t.Run("straddling", func(t *testing.T) {
+		before, after := newMsg().SplitByTimestamp(30)
+		assert.NotNil(t, before)
+		assert.NotNil(t, after)
+
+		assert.EqualValues(t, 100, before.GetPartitionID())
+		assert.Equal(t, "p100", before.GetPartitionName())
+		assert.EqualValues(t, 1000, before.GetSegmentID())
+		assert.Equal(t, []uint64{10, 20}, before.GetTimestamps())
+		assert.Equal(t, []int64{1, 3}, before.GetRowIDs())
+		assert.Equal(t, []int64{11, 13}, before.GetFieldsData()[0].GetScalars().GetLongData().GetData())
+		assert.Equal(t, []uint32{0, 2}, before.HashValues)
+		assert.EqualValues(t, 10, before.BeginTs())
+		assert.EqualValues(t, 20, before.EndTs())
+		assert.NoError(t, before.CheckAligned())
+
+		// the rows at the boundary go after
+		assert.Equal(t, []uint64{40, 50, 30}, after.GetTimestamps())
+		assert.Equal(t, []int64{2, 4, 5}, after.GetRowIDs())
+		assert.Equal(t, []int64{12, 14, 15}, after.GetFieldsData()[0].GetScalars().GetLongData().GetData())
+		assert.Equal(t, []uint32{1, 3, 4}, after.HashValues)
+		assert.EqualValues(t, 30, after.BeginTs())
+		assert.EqualValues(t, 50, after.EndTs())
+		assert.NoError(t, after.CheckAligned())
+	})
+
+	t.Run("all before", func(t *testing.T) {
+		before, after := newMsg().SplitByTimestamp(100)
+		assert.Nil(t, after)
+		assert.Equal(t, []int64{1, 2, 3, 4, 5}, before.GetRowIDs())
+		assert.NoError(t, before.CheckAligned())
+	})
+
+	t.Run("all after", func(t *testing.T) {
+		before, after := newMsg().SplitByTimestamp(10)
+		assert.Nil(t, before)
+		assert.Equal(t, []int64{1, 2, 3, 4, 5}, after.GetRowIDs())
+		assert.NoError(t, after.CheckAligned())
+	})
+
+	t.Run("row based", func(t *testing.T) {
+		msg := &InsertMsg{
+			InsertRequest: msgpb.InsertRequest{
+				Timestamps: []uint64{10, 40, 20},
+				RowIDs:     []int64{1, 2, 3},
+				RowData:    []*commonpb.Blob{{Value: []byte{1}}, {Value: []byte{2}}, {Value: []byte{3}}},
+				Version:    msgpb.InsertDataVersion_RowBased,
+			},
+		}
+		before, after := msg.SplitByTimestamp(30)
+		assert.Equal(t, []int64{1, 3}, before.GetRowIDs())
+		assert.Equal(t, []byte{3}, before.GetRowData()[1].GetValue())
+		assert.Equal(t, []byte{2}, after.GetRowData()[0].GetValue())
+		assert.Empty(t, before.HashValues)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		msg := &InsertMsg{InsertRequest: msgpb.InsertRequest{Version: msgpb.InsertDataVersion_ColumnBased}}
+		before, after := msg.SplitByTimestamp(30)
+		assert.Nil(t, before)
+		assert.Nil(t, after)
+	})
+}
+
 func TestDeleteMsg(t *testing.T) {
 	deleteMsg := &DeleteMsg{
 		BaseMsg: generateBaseMsg(),
@@ -318,6 +595,141 @@ func TestDeleteMsg_Unmarshal_IllegalParameter(t *testing.T) {
 	assert.Nil(t, tsMsg)
 }
 
+func TestDeleteMsg_Dedup(t *testing.T) {
+	t.Run("int64 pks", func(t *testing.T) {
+		msg := &DeleteMsg{
+			BaseMsg: BaseMsg{BeginTimestamp: 10, EndTimestamp: 50, HashValues: []uint32{1, 2, 1, 3, 2}},
+			DeleteRequest: msgpb.DeleteRequest{
+				Base:         &commonpb.MsgBase{MsgType: commonpb.MsgType_Delete},
+				CollectionID: 1,
+				PrimaryKeys: &schemapb.IDs{
+					IdField: &schemapb.IDs_IntId{IntId: &schemapb.LongArray{Data: []int64{1, 2, 1, 3, 2}}},
+				},
+				Timestamps: []uint64{30, 20, 50, 10, 15},
+				NumRows:    5,
+			},
+		}
+
+		deduped := msg.Dedup()
+		assert.Equal(t, []int64{1, 2, 3}, deduped.GetPrimaryKeys().GetIntId().GetData())
+		assert.Equal(t, []uint64{50, 20, 10}, deduped.GetTimestamps())
+		assert.Equal(t, []uint32{1, 2, 3}, deduped.HashValues)
+		assert.EqualValues(t, 3, deduped.GetNumRows())
+		assert.EqualValues(t, 1, deduped.GetCollectionID())
+		assert.EqualValues(t, 10, deduped.BeginTs())
+		assert.EqualValues(t, 50, deduped.EndTs())
+		assert.NoError(t, deduped.CheckAligned())
+
+		// the original message is untouched
+		assert.Equal(t, []int64{1, 2, 1, 3, 2}, msg.GetPrimaryKeys().GetIntId().GetData())
+		assert.EqualValues(t, 5, msg.GetNumRows())
+	})
+
+	t.Run("unique string pks", func(t *testing.T) {
+		msg := &DeleteMsg{
+			DeleteRequest: msgpb.DeleteRequest{
+				PrimaryKeys: &schemapb.IDs{
+					IdField: &schemapb.IDs_StrId{StrId: &schemapb.StringArray{Data: []string{"b", "a", "c"}}},
+				},
+				Timestamps: []uint64{3, 2, 1},
+				NumRows:    3,
+			},
+		}
+
+		deduped := msg.Dedup()
+		assert.Equal(t, []string{"b", "a", "c"}, deduped.GetPrimaryKeys().GetStrId().GetData())
+		assert.Equal(t, []uint64{3, 2, 1}, deduped.GetTimestamps())
+		assert.Empty(t, deduped.HashValues)
+		assert.NoError(t, deduped.CheckAligned())
+	})
+
+	t.Run("legacy int64 pks", func(t *testing.T) {
+		msg := &DeleteMsg{
+			DeleteRequest: msgpb.DeleteRequest{
+				Int64PrimaryKeys: []int64{1, 2, 1},
+				Timestamps:       []uint64{10, 20, 30},
+				NumRows:          3,
+			},
+		}
+
+		deduped := msg.Dedup()
+		assert.Equal(t, []int64{1, 2}, deduped.GetInt64PrimaryKeys())
+		assert.Equal(t, []int64{1, 2}, deduped.GetPrimaryKeys().GetIntId().GetData())
+		assert.Equal(t, []uint64{30, 20}, deduped.GetTimestamps())
+		assert.EqualValues(t, 2, deduped.GetNumRows())
+		assert.NoError(t, deduped.CheckAligned())
+	})
+}
+
+func TestDeleteMsg_FilterByTsRange(t *testing.T) {
+	t.Run("int64 pks", func(t *testing.T) {
+		msg := &DeleteMsg{
+			BaseMsg: BaseMsg{BeginTimestamp: 5, EndTimestamp: 40, HashValues: []uint32{1, 2, 3, 4, 5}},
+			DeleteRequest: msgpb.DeleteRequest{
+				Base:         &commonpb.MsgBase{MsgType: commonpb.MsgType_Delete},
+				CollectionID: 1,
+				PrimaryKeys: &schemapb.IDs{
+					IdField: &schemapb.IDs_IntId{IntId: &schemapb.LongArray{Data: []int64{1, 2, 3, 4, 5}}},
+				},
+				Timestamps: []uint64{5, 10, 20, 30, 40},
+				NumRows:    5,
+			},
+		}
+
+		filtered := msg.FilterByTsRange(10, 30)
+		assert.Equal(t, []int64{2, 3, 4}, filtered.GetPrimaryKeys().GetIntId().GetData())
+		assert.Equal(t, []uint64{10, 20, 30}, filtered.GetTimestamps())
+		assert.Equal(t, []uint32{2, 3, 4}, filtered.HashValues)
+		assert.EqualValues(t, 3, filtered.GetNumRows())
+		assert.EqualValues(t, 1, filtered.GetCollectionID())
+		assert.NoError(t, filtered.CheckAligned())
+
+		// the original message is untouched
+		assert.Equal(t, []int64{1, 2, 3, 4, 5}, msg.GetPrimaryKeys().GetIntId().GetData())
+		assert.EqualValues(t, 5, msg.GetNumRows())
+
+		filtered = msg.FilterByTsRange(50, 60)
+		assert.Empty(t, filtered.GetPrimaryKeys().GetIntId().GetData())
+		assert.Empty(t, filtered.GetTimestamps())
+		assert.EqualValues(t, 0, filtered.GetNumRows())
+	})
+
+	t.Run("string pks", func(t *testing.T) {
+		msg := &DeleteMsg{
+			DeleteRequest: msgpb.DeleteRequest{
+				PrimaryKeys: &schemapb.IDs{
+					IdField: &schemapb.IDs_StrId{StrId: &schemapb.StringArray{Data: []string{"a", "b", "c"}}},
+				},
+				Timestamps: []uint64{3, 1, 2},
+				NumRows:    3,
+			},
+		}
+
+		filtered := msg.FilterByTsRange(2, 3)
+		assert.Equal(t, []string{"a", "c"}, filtered.GetPrimaryKeys().GetStrId().GetData())
+		assert.Equal(t, []uint64{3, 2}, filtered.GetTimestamps())
+		assert.Empty(t, filtered.HashValues)
+		assert.NoError(t, filtered.CheckAligned())
+	})
+
+	t.Run("legacy int64 pks", func(t *testing.T) {
+		msg := &DeleteMsg{
+			DeleteRequest: msgpb.DeleteRequest{
+				Int64PrimaryKeys: []int64{1, 2, 3},
+				Timestamps:       []uint64{10, 20, 30},
+				NumRows:          3,
+			},
+		}
+
+		filtered := msg.FilterByTsRange(15, 30)
+		assert.Equal(t, []int64{2, 3}, filtered.GetInt64PrimaryKeys())
+		assert.Equal(t, []int64{2, 3}, filtered.GetPrimaryKeys().GetIntId().GetData())
+		assert.Equal(t, []uint64{20, 30}, filtered.GetTimestamps())
+		assert.EqualValues(t, 2, filtered.GetNumRows())
+		assert.NoError(t, filtered.CheckAligned())
+	})
+}
+
 func TestTimeTickMsg(t *testing.T) {
 	timeTickMsg := &TimeTickMsg{
 		BaseMsg: generateBaseMsg(),
@@ -602,3 +1014,68 @@ func TestDataNodeTtMsg_Unmarshal_IllegalParameter(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, tsMsg)
 }
+
+func TestMsgTypeName(t *testing.T) {
+	assert.Equal(t, "Insert", MsgTypeName(commonpb.MsgType_Insert))
+	assert.Equal(t, "Delete", MsgTypeName(commonpb.MsgType_Delete))
+	assert.Equal(t, "Search", MsgTypeName(commonpb.MsgType_Search))
+	assert.Equal(t, "TimeTick", MsgTypeName(commonpb.MsgType_TimeTick))
+	for value, name := range commonpb.MsgType_name {
+		assert.Equal(t, name, MsgTypeName(MsgType(value)))
+	}
+
+	assert.Equal(t, "Unknown(-1)", MsgTypeName(MsgType(-1)))
+
+	_, err := (&ProtoUDFactory{}).NewUnmarshalDispatcher().Unmarshal([]byte{}, MsgType(-1))
+	assert.ErrorContains(t, err, "Unknown(-1)")
+}
+
+func TestMarshalSizeMetric(t *testing.T) {
+	observed := func(typ commonpb.MsgType) (uint64, float64) {
+		m := &dto.Metric{}
+		err := metrics.MsgStreamMarshalSize.WithLabelValues(typ.String()).(prometheus.Histogram).Write(m)
+		assert.NoError(t, err)
+		return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+	}
+
+	insertCount, insertSum := observed(commonpb.MsgType_Insert)
+	deleteCount, deleteSum := observed(commonpb.MsgType_Delete)
+
+	var insertBytes int
+	for i := 0; i < 3; i++ {
+		insertMsg := &InsertMsg{
+			BaseMsg: generateBaseMsg(),
+			InsertRequest: msgpb.InsertRequest{
+				Base: &commonpb.MsgBase{
+					MsgType: commonpb.MsgType_Insert,
+					MsgID:   int64(i),
+				},
+				CollectionName: "test_collection",
+				RowIDs:         make([]int64, i+1),
+			},
+		}
+		bytes, err := insertMsg.Marshal(insertMsg)
+		assert.NoError(t, err)
+		insertBytes += len(bytes.([]byte))
+	}
+
+	deleteMsg := &DeleteMsg{
+		BaseMsg: generateBaseMsg(),
+		DeleteRequest: msgpb.DeleteRequest{
+			Base: &commonpb.MsgBase{
+				MsgType: commonpb.MsgType_Delete,
+			},
+			Int64PrimaryKeys: []int64{1, 2, 3},
+		},
+	}
+	bytes, err := deleteMsg.Marshal(deleteMsg)
+	assert.NoError(t, err)
+
+	count, sum := observed(commonpb.MsgType_Insert)
+	assert.Equal(t, insertCount+3, count)
+	assert.Equal(t, insertSum+float64(insertBytes), sum)
+
+	count, sum = observed(commonpb.MsgType_Delete)
+	assert.Equal(t, deleteCount+1, count)
+	assert.Equal(t, deleteSum+float64(len(bytes.([]byte))), sum)
+}
@@ -0,0 +1,89 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
+)
+
+// ValidationError is the error of the message failing the validation,
+// it wraps ErrParameterInvalid so that errors.Is works with it.
+type ValidationError struct {
+	MsgType MsgType
+	// Field is the field at fault
+	Field string
+	// Reason is the cause wrapping ErrParameterInvalid
+	Reason error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s message, field %s: %s", e.MsgType.String(), e.Field, e.Reason.Error())
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Reason
+}
+
+func newValidationError(msgType MsgType, field string, format string, args ...any) *ValidationError {
+	return &ValidationError{
+		MsgType: msgType,
+		Field:   field,
+		Reason:  merr.WrapErrParameterInvalidMsg(format, args...),
+	}
+}
+
+// validator is implemented by the messages with the validation.
+type validator interface {
+	Validate() error
+}
+
+// Validate validates the message before publishing by the validation of its type,
+// returns a ValidationError naming the field at fault, nil if the message type has no validation.
+func Validate(msg TsMsg) error {
+	if msg == nil {
+		return &ValidationError{
+			MsgType: commonpb.MsgType_Undefined,
+			Field:   "msg",
+			Reason:  merr.WrapErrParameterMissing("msg", "nil message"),
+		}
+	}
+	v, ok := msg.(validator)
+	if !ok {
+		return nil
+	}
+	return v.Validate()
+}
+
+// Validate checks the primary keys and the timestamps have the same number of rows as NumRows,
+// and the target segment ID isn't negative.
+func (dt *DeleteMsg) Validate() error {
+	numRows := dt.GetNumRows()
+	if int64(len(dt.GetTimestamps())) != numRows {
+		return newValidationError(commonpb.MsgType_Delete, "timestamps", "the num_rows(%d) of timestamps is not equal to the passed NumRows(%d)", len(dt.GetTimestamps()), numRows)
+	}
+	if numPks := int64(typeutil.GetSizeOfIDs(dt.primaryKeys())); numPks != numRows {
+		return newValidationError(commonpb.MsgType_Delete, "primary_keys", "the num_rows(%d) of pks is not equal to the passed NumRows(%d)", numPks, numRows)
+	}
+	if dt.TargetSegmentID() < 0 {
+		return newValidationError(commonpb.MsgType_Delete, "segment_id", "invalid target segment ID %d", dt.TargetSegmentID())
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/util/merr"
+)
+
+func TestValidate(t *testing.T) {
+	assertInvalid := func(t *testing.T, err error, msgType MsgType, field string) {
+		var validationErr *ValidationError
+		assert.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, msgType, validationErr.MsgType)
+		assert.Equal(t, field, validationErr.Field)
+		assert.Contains(t, err.Error(), field)
+	}
+
+	t.Run("insert", func(t *testing.T) {
+		newMsg := func() *InsertMsg {
+			return &InsertMsg{
+				InsertRequest: msgpb.InsertRequest{
+					Base:       &commonpb.MsgBase{MsgType: commonpb.MsgType_Insert},
+					Timestamps: []uint64{1, 2},
+					RowIDs:     []int64{1, 2},
+					FieldsData: []*schemapb.FieldData{{
+						Type:      schemapb.DataType_Int64,
+						FieldName: "pk",
+						Field: &schemapb.FieldData_Scalars{
+							Scalars: &schemapb.ScalarField{
+								Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: []int64{1, 2}}},
+							},
+						},
+					}},
+					NumRows: 2,
+					Version: msgpb.InsertDataVersion_ColumnBased,
+				},
+			}
+		}
+		assert.NoError(t, Validate(newMsg()))
+
+		msg := newMsg()
+		msg.RowIDs = []int64{1}
+		err := Validate(msg)
+		assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+		assertInvalid(t, err, commonpb.MsgType_Insert, "row_ids")
+
+		msg = newMsg()
+		msg.NumRows = 3
+		assertInvalid(t, Validate(msg), commonpb.MsgType_Insert, "num_rows")
+
+		msg = newMsg()
+		msg.FieldsData[0].GetScalars().GetLongData().Data = []int64{1}
+		assertInvalid(t, Validate(msg), commonpb.MsgType_Insert, "fields_data.pk")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		newMsg := func() *DeleteMsg {
+			return &DeleteMsg{
+				DeleteRequest: msgpb.DeleteRequest{
+					Base:       &commonpb.MsgBase{MsgType: commonpb.MsgType_Delete},
+					Timestamps: []uint64{1, 2},
+					PrimaryKeys: &schemapb.IDs{
+						IdField: &schemapb.IDs_IntId{IntId: &schemapb.LongArray{Data: []int64{1, 2}}},
+					},
+					NumRows: 2,
+				},
+			}
+		}
+		assert.NoError(t, Validate(newMsg()))
+
+		msg := newMsg()
+		msg.Timestamps = []uint64{1}
+		assertInvalid(t, Validate(msg), commonpb.MsgType_Delete, "timestamps")
+
+		msg = newMsg()
+		msg.PrimaryKeys.GetIntId().Data = []int64{1, 2, 3}
+		assertInvalid(t, Validate(msg), commonpb.MsgType_Delete, "primary_keys")
+
+		// the legacy deletes carry the int64 primary keys only
+		msg = newMsg()
+		msg.PrimaryKeys = nil
+		msg.Int64PrimaryKeys = []int64{1, 2}
+		assert.NoError(t, Validate(msg))
+		msg.Int64PrimaryKeys = []int64{1}
+		assertInvalid(t, Validate(msg), commonpb.MsgType_Delete, "primary_keys")
+
+		// the target segment is optional
+		msg = newMsg()
+		msg.SetTargetSegmentID(1)
+		assert.NoError(t, Validate(msg))
+		msg.SetTargetSegmentID(-1)
+		assertInvalid(t, Validate(msg), commonpb.MsgType_Delete, "segment_id")
+	})
+
+	t.Run("without validation", func(t *testing.T) {
+		assert.NoError(t, Validate(&TimeTickMsg{}))
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		err := Validate(nil)
+		assert.ErrorIs(t, err, merr.ErrParameterMissing)
+		assertInvalid(t, err, commonpb.MsgType_Undefined, "msg")
+	})
+}
@@ -44,13 +44,17 @@ type ProtoUnmarshalDispatcher struct {
 func (p *ProtoUnmarshalDispatcher) Unmarshal(input interface{}, msgType commonpb.MsgType) (TsMsg, error) {
 	unmarshalFunc, ok := p.TempMap[msgType]
 	if !ok {
-		return nil, errors.New("not set unmarshalFunc for this messageType")
+		return nil, errors.Newf("not set unmarshalFunc for message type %s", MsgTypeName(msgType))
 	}
 	return unmarshalFunc(input)
 }
 
 // ProtoUDFactory is a factory to generate ProtoUnmarshalDispatcher object
-type ProtoUDFactory struct{}
+type ProtoUDFactory struct {
+	// InsertHashOption populates the empty hash values of InsertMsg if set,
+	// disabled by default
+	InsertHashOption *InsertHashOption
+}
 
 // NewUnmarshalDispatcher returns a new UnmarshalDispatcher
 func (pudf *ProtoUDFactory) NewUnmarshalDispatcher() *ProtoUnmarshalDispatcher {
@@ -64,6 +68,7 @@ func (pudf *ProtoUDFactory) NewUnmarshalDispatcher() *ProtoUnmarshalDispatcher {
 	dataNodeTtMsg := DataNodeTtMsg{}
 
 	createIndexMsg := CreateIndexMsg{}
+	alterIndexMsg := AlterIndexMsg{}
 	dropIndexMsg := DropIndexMsg{}
 
 	loadCollectionMsg := LoadCollectionMsg{}
@@ -86,6 +91,7 @@ func (pudf *ProtoUDFactory) NewUnmarshalDispatcher() *ProtoUnmarshalDispatcher {
 	p.TempMap[commonpb.MsgType_DropPartition] = dropPartitionMsg.Unmarshal
 	p.TempMap[commonpb.MsgType_DataNodeTt] = dataNodeTtMsg.Unmarshal
 	p.TempMap[commonpb.MsgType_CreateIndex] = createIndexMsg.Unmarshal
+	p.TempMap[commonpb.MsgType_AlterIndex] = alterIndexMsg.Unmarshal
 	p.TempMap[commonpb.MsgType_DropIndex] = dropIndexMsg.Unmarshal
 	p.TempMap[commonpb.MsgType_LoadCollection] = loadCollectionMsg.Unmarshal
 	p.TempMap[commonpb.MsgType_ReleaseCollection] = releaseCollectionMsg.Unmarshal
@@ -95,6 +101,18 @@ func (pudf *ProtoUDFactory) NewUnmarshalDispatcher() *ProtoUnmarshalDispatcher {
 	p.TempMap[commonpb.MsgType_CreateDatabase] = createDatabaseMsg.Unmarshal
 	p.TempMap[commonpb.MsgType_DropDatabase] = dropDatabaseMsg.Unmarshal
 
+	if pudf.InsertHashOption != nil {
+		opt := *pudf.InsertHashOption
+		p.TempMap[commonpb.MsgType_Insert] = func(input interface{}) (TsMsg, error) {
+			msg, err := insertMsg.Unmarshal(input)
+			if err != nil {
+				return nil, err
+			}
+			msg.(*InsertMsg).fillHashValues(opt)
+			return msg, nil
+		}
+	}
+
 	return p
 }
 
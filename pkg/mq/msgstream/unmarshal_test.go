@@ -20,10 +20,13 @@ import (
 	"testing"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus/pkg/util/typeutil"
 )
 
 func Test_ProtoUnmarshalDispatcher(t *testing.T) {
@@ -68,3 +71,135 @@ func Test_ProtoUnmarshalDispatcher(t *testing.T) {
 		t.Log("msg type: ", msg.Type(), ", msg value: ", msg, "msg tag: ")
 	}
 }
+
+func Test_ProtoUnmarshalDispatcherInsertHash(t *testing.T) {
+	pks := []int64{1, 2, 3, 4}
+	insertMsg := &InsertMsg{
+		BaseMsg: BaseMsg{},
+		InsertRequest: msgpb.InsertRequest{
+			Base: &commonpb.MsgBase{
+				MsgType: commonpb.MsgType_Insert,
+				MsgID:   1,
+			},
+			Timestamps: []Timestamp{1, 1, 1, 1},
+			RowIDs:     []int64{1, 2, 3, 4},
+			NumRows:    4,
+			Version:    msgpb.InsertDataVersion_ColumnBased,
+			FieldsData: []*schemapb.FieldData{
+				{
+					FieldId: 100,
+					Type:    schemapb.DataType_Int64,
+					Field: &schemapb.FieldData_Scalars{
+						Scalars: &schemapb.ScalarField{
+							Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: pks}},
+						},
+					},
+				},
+			},
+		},
+	}
+	payload, err := insertMsg.Marshal(insertMsg)
+	assert.NoError(t, err)
+
+	// disabled by default
+	msg, err := (&ProtoUDFactory{}).NewUnmarshalDispatcher().Unmarshal(payload, commonpb.MsgType_Insert)
+	assert.NoError(t, err)
+	assert.Empty(t, msg.HashKeys())
+
+	dispatcher := (&ProtoUDFactory{InsertHashOption: &InsertHashOption{PrimaryFieldID: 100, ShardNum: 2}}).NewUnmarshalDispatcher()
+	msg, err = dispatcher.Unmarshal(payload, commonpb.MsgType_Insert)
+	assert.NoError(t, err)
+	expected := typeutil.HashPK2Shards(&schemapb.IDs{IdField: &schemapb.IDs_IntId{IntId: &schemapb.LongArray{Data: pks}}}, 2)
+	assert.Equal(t, expected, msg.HashKeys())
+
+	// existing hash values are kept
+	insertMsg.HashValues = []uint32{1, 1, 1, 1}
+	insertMsg.fillHashValues(InsertHashOption{PrimaryFieldID: 100, ShardNum: 2})
+	assert.Equal(t, []uint32{1, 1, 1, 1}, insertMsg.HashKeys())
+}
+
+// fieldHashStrategy hashes the rows by the value of an int64 field
+type fieldHashStrategy struct {
+	fieldID  int64
+	shardNum uint32
+}
+
+func (s fieldHashStrategy) HashValues(msg *InsertMsg) []uint32 {
+	for _, field := range msg.GetFieldsData() {
+		if field.GetFieldId() != s.fieldID {
+			continue
+		}
+		return lo.Map(field.GetScalars().GetLongData().GetData(), func(v int64, _ int) uint32 {
+			return uint32(v) % s.shardNum
+		})
+	}
+	return nil
+}
+
+func Test_InsertMsgHashStrategy(t *testing.T) {
+	pks := []int64{1, 2, 3, 4}
+	newInsertMsg := func() *InsertMsg {
+		return &InsertMsg{
+			InsertRequest: msgpb.InsertRequest{
+				Base: &commonpb.MsgBase{
+					MsgType: commonpb.MsgType_Insert,
+					MsgID:   1,
+				},
+				Timestamps: []Timestamp{1, 1, 1, 1},
+				RowIDs:     []int64{1, 2, 3, 4},
+				NumRows:    4,
+				Version:    msgpb.InsertDataVersion_ColumnBased,
+				FieldsData: []*schemapb.FieldData{
+					{
+						FieldId: 100,
+						Type:    schemapb.DataType_Int64,
+						Field: &schemapb.FieldData_Scalars{
+							Scalars: &schemapb.ScalarField{
+								Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: pks}},
+							},
+						},
+					},
+					{
+						FieldId: 101,
+						Type:    schemapb.DataType_Int64,
+						Field: &schemapb.FieldData_Scalars{
+							Scalars: &schemapb.ScalarField{
+								Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: []int64{10, 11, 12, 13}}},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		msg := newInsertMsg()
+		msg.FillHashValues(PrimaryKeyHashStrategy{PrimaryFieldID: 100, ShardNum: 2})
+		expected := typeutil.HashPK2Shards(&schemapb.IDs{IdField: &schemapb.IDs_IntId{IntId: &schemapb.LongArray{Data: pks}}}, 2)
+		assert.Equal(t, expected, msg.HashKeys())
+
+		// primary key field not found
+		msg = newInsertMsg()
+		msg.FillHashValues(PrimaryKeyHashStrategy{PrimaryFieldID: 102, ShardNum: 2})
+		assert.Empty(t, msg.HashKeys())
+	})
+
+	t.Run("custom field", func(t *testing.T) {
+		insertMsg := newInsertMsg()
+		payload, err := insertMsg.Marshal(insertMsg)
+		assert.NoError(t, err)
+
+		dispatcher := (&ProtoUDFactory{InsertHashOption: &InsertHashOption{
+			Strategy: fieldHashStrategy{fieldID: 101, shardNum: 3},
+		}}).NewUnmarshalDispatcher()
+		msg, err := dispatcher.Unmarshal(payload, commonpb.MsgType_Insert)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint32{1, 2, 0, 1}, msg.HashKeys())
+
+		// existing hash values are kept
+		insertMsg.HashValues = []uint32{1, 1, 1, 1}
+		insertMsg.FillHashValues(fieldHashStrategy{fieldID: 101, shardNum: 3})
+		assert.Equal(t, []uint32{1, 1, 1, 1}, insertMsg.HashKeys())
+	})
+}
@@ -2,8 +2,11 @@ package cache
 
 import (
 	"container/list"
+	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"go.uber.org/atomic"
@@ -19,12 +22,16 @@ type cacheItem[K comparable, V any] struct {
 	key      K
 	value    V
 	pinCount atomic.Int32
+	// access stats, protected by the rwlock of the cache
+	lastAccess time.Time
+	hits       int64
 }
 
 func newCacheItem[K comparable, V any](key K, value V) *cacheItem[K, V] {
 	return &cacheItem[K, V]{
-		key:   key,
-		value: value,
+		key:        key,
+		value:      value,
+		lastAccess: time.Now(),
 	}
 }
 
@@ -37,8 +44,11 @@ func (i *cacheItem[K, V]) Value() V {
 }
 
 type (
-	Loader[K comparable, V any]    func(key K) (V, bool)
+	Loader[K comparable, V any]    func(ctx context.Context, key K) (V, bool)
 	Finalizer[K comparable, V any] func(key K, value V) error
+	// Evictable tells whether an unpinned item could be evicted,
+	// e.g. the value may be still in use outside the cache.
+	Evictable[K comparable, V any] func(key K, value V) bool
 )
 
 // Scavenger records occupation of cache and decide whether to evict if necessary.
@@ -52,6 +62,10 @@ type Scavenger[K comparable] interface {
 	Collect(key K) (bool, func(K) bool)
 	// Throw records entry removals.
 	Throw(key K)
+	// Overcommit records entry additions beyond the capacity, returns false without recording if it exceeds the limit.
+	Overcommit(key K, limit int64) bool
+	// Size returns the occupation recorded.
+	Size() int64
 }
 
 type LazyScavenger[K comparable] struct {
@@ -84,8 +98,158 @@ func (s *LazyScavenger[K]) Throw(key K) {
 	s.size -= s.weight(key)
 }
 
+func (s *LazyScavenger[K]) Size() int64 {
+	return s.size
+}
+
+func (s *LazyScavenger[K]) Overcommit(key K, limit int64) bool {
+	w := s.weight(key)
+	if s.size+w > limit {
+		return false
+	}
+	s.size += w
+	return true
+}
+
+// compositeScavenger combines several scavengers, there is room only if all of them have room.
+type compositeScavenger[K comparable] struct {
+	scavengers []Scavenger[K]
+}
+
+func newCompositeScavenger[K comparable](scavengers ...Scavenger[K]) *compositeScavenger[K] {
+	return &compositeScavenger[K]{
+		scavengers: scavengers,
+	}
+}
+
+func (s *compositeScavenger[K]) Collect(key K) (bool, func(K) bool) {
+	collected := make([]Scavenger[K], 0, len(s.scavengers))
+	collectors := make([]func(K) bool, 0)
+	for _, scavenger := range s.scavengers {
+		ok, collector := scavenger.Collect(key)
+		if ok {
+			collected = append(collected, scavenger)
+		} else {
+			collectors = append(collectors, collector)
+		}
+	}
+	if len(collectors) == 0 {
+		return true, nil
+	}
+
+	// the entry is not added if any of the scavengers has no room
+	for _, scavenger := range collected {
+		scavenger.Throw(key)
+	}
+	done := make([]bool, len(collectors))
+	return false, func(key K) bool {
+		allDone := true
+		for i, collector := range collectors {
+			if !done[i] {
+				done[i] = collector(key)
+			}
+			allDone = allDone && done[i]
+		}
+		return allDone
+	}
+}
+
+func (s *compositeScavenger[K]) Throw(key K) {
+	for _, scavenger := range s.scavengers {
+		scavenger.Throw(key)
+	}
+}
+
+// Size returns the occupation recorded by the first scavenger.
+func (s *compositeScavenger[K]) Size() int64 {
+	return s.scavengers[0].Size()
+}
+
+// Overcommit applies the limit to the first scavenger only, the others never go beyond their capacity,
+// so the eviction resumes once any of them is full even if it's paused.
+func (s *compositeScavenger[K]) Overcommit(key K, limit int64) bool {
+	for i, scavenger := range s.scavengers {
+		var ok bool
+		if i == 0 {
+			ok = scavenger.Overcommit(key, limit)
+		} else {
+			ok, _ = scavenger.Collect(key)
+		}
+		if !ok {
+			for _, recorded := range s.scavengers[:i] {
+				recorded.Throw(key)
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// ItemStats is the access stats of a cached item.
+type ItemStats[K comparable] struct {
+	Key        K
+	LastAccess time.Time
+	Hits       int64
+}
+
+// EvictionPolicy picks the items to evict.
+type EvictionPolicy[K comparable] interface {
+	// Candidates orders the evictable items to evict first to last,
+	// the items are given from the least recently used to the most.
+	Candidates(items []ItemStats[K]) []K
+}
+
+// LRUPolicy evicts the least recently used items first, it's the default policy.
+type LRUPolicy[K comparable] struct{}
+
+func (LRUPolicy[K]) Candidates(items []ItemStats[K]) []K {
+	keys := make([]K, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	return keys
+}
+
+// SizeWeightedLRUPolicy weights the recency rank of the items by their size,
+// so that a large item is evicted before the small ones used a little less recently.
+type SizeWeightedLRUPolicy[K comparable] struct {
+	weight func(K) int64
+}
+
+func NewSizeWeightedLRUPolicy[K comparable](weight func(K) int64) *SizeWeightedLRUPolicy[K] {
+	return &SizeWeightedLRUPolicy[K]{
+		weight: weight,
+	}
+}
+
+func (p *SizeWeightedLRUPolicy[K]) Candidates(items []ItemStats[K]) []K {
+	scores := make(map[K]int64, len(items))
+	keys := make([]K, 0, len(items))
+	for i, item := range items {
+		// the least recently used one ranks the highest
+		scores[item.Key] = int64(len(items)-i) * p.weight(item.Key)
+		keys = append(keys, item.Key)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return scores[keys[i]] > scores[keys[j]]
+	})
+	return keys
+}
+
 type Cache[K comparable, V any] interface {
-	Do(key K, doer func(V) error) error
+	Do(ctx context.Context, key K, doer func(V) error) error
+	// Touch marks the item as the most recently used without pinning or loading it,
+	// returns false if the item is not in the cache.
+	Touch(key K) bool
+	// Keys returns the keys of the cached items, in no particular order.
+	Keys() []K
+	// Shrink evicts the least recently used items until the occupation is below the target,
+	// returns false if the pinned or not evictable items keep it from dropping below.
+	Shrink(target int64) bool
+	// PauseEviction stops evicting items for the new ones until ResumeEviction called or timeout,
+	// the cache overcommits the room up to hardLimit meanwhile, and resumes the eviction beyond it.
+	PauseEviction(timeout time.Duration, hardLimit int64)
+	ResumeEviction()
 }
 
 // lruCache extends the ccache library to provide pinning and unpinning of items.
@@ -95,16 +259,29 @@ type lruCache[K comparable, V any] struct {
 	items              map[K]*list.Element
 	accessList         *list.List
 	loaderSingleFlight singleflight.Group
+	// loads in flight, the load is cancelled once all its requesters gave up
+	loadingMu sync.Mutex
+	loadings  map[K]*loading[K, V]
 
 	loader    Loader[K, V]
 	finalizer Finalizer[K, V]
+	evictable Evictable[K, V]
 	scavenger Scavenger[K]
+	policy    EvictionPolicy[K]
+
+	// the eviction is paused until pausedUntil, protected by rwlock
+	pausedUntil time.Time
+	hardLimit   int64
 }
 
 type CacheBuilder[K comparable, V any] struct {
 	loader    Loader[K, V]
 	finalizer Finalizer[K, V]
+	evictable Evictable[K, V]
 	scavenger Scavenger[K]
+	policy    EvictionPolicy[K]
+	// limit of the item number alongside the scavenger, no limit if not positive
+	countLimit int64
 }
 
 func NewCacheBuilder[K comparable, V any]() *CacheBuilder[K, V] {
@@ -130,6 +307,13 @@ func (b *CacheBuilder[K, V]) WithFinalizer(finalizer Finalizer[K, V]) *CacheBuil
 	return b
 }
 
+// WithEvictable sets the check for eviction candidates,
+// the items not evictable are skipped and the next candidates are picked instead.
+func (b *CacheBuilder[K, V]) WithEvictable(evictable Evictable[K, V]) *CacheBuilder[K, V] {
+	b.evictable = evictable
+	return b
+}
+
 func (b *CacheBuilder[K, V]) WithLazyScavenger(weight func(K) int64, capacity int64) *CacheBuilder[K, V] {
 	b.scavenger = NewLazyScavenger(weight, capacity)
 	return b
@@ -145,28 +329,67 @@ func (b *CacheBuilder[K, V]) WithCapacity(capacity int64) *CacheBuilder[K, V] {
 	return b
 }
 
+// WithEvictionPolicy sets the policy picking the items to evict, LRUPolicy if not set.
+func (b *CacheBuilder[K, V]) WithEvictionPolicy(policy EvictionPolicy[K]) *CacheBuilder[K, V] {
+	b.policy = policy
+	return b
+}
+
+// WithCountLimit limits the number of items alongside the scavenger,
+// the items are evicted if there are too many of them, even if the scavenger has room.
+func (b *CacheBuilder[K, V]) WithCountLimit(limit int64) *CacheBuilder[K, V] {
+	b.countLimit = limit
+	return b
+}
+
 func (b *CacheBuilder[K, V]) Build() Cache[K, V] {
-	return newLRUCache(b.loader, b.finalizer, b.scavenger)
+	scavenger := b.scavenger
+	if b.countLimit > 0 {
+		scavenger = newCompositeScavenger[K](scavenger, NewLazyScavenger(
+			func(key K) int64 {
+				return 1
+			},
+			b.countLimit,
+		))
+	}
+	return newLRUCache(b.loader, b.finalizer, b.evictable, scavenger, b.policy)
 }
 
 func newLRUCache[K comparable, V any](
 	loader Loader[K, V],
 	finalizer Finalizer[K, V],
+	evictable Evictable[K, V],
 	scavenger Scavenger[K],
+	policy EvictionPolicy[K],
 ) Cache[K, V] {
 	return &lruCache[K, V]{
 		items:              make(map[K]*list.Element),
 		accessList:         list.New(),
 		loaderSingleFlight: singleflight.Group{},
+		loadings:           make(map[K]*loading[K, V]),
 		loader:             loader,
 		finalizer:          finalizer,
+		evictable:          evictable,
 		scavenger:          scavenger,
+		policy:             policy,
 	}
 }
 
+// loading is the context shared by the requesters of a load in flight.
+type loading[K comparable, V any] struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+	// item is the loaded item, pinned until all the requesters leave,
+	// each requester receiving it pins its own meanwhile
+	item *cacheItem[K, V]
+}
+
 // Do picks up an item from cache and executes doer. The entry of interest is garented in the cache when doer is executing.
-func (c *lruCache[K, V]) Do(key K, doer func(V) error) error {
-	item, err := c.getAndPin(key)
+// If ctx is done while the item is loading, Do returns the ctx error,
+// and the load is cancelled if no other requester is waiting for it.
+func (c *lruCache[K, V]) Do(ctx context.Context, key K, doer func(V) error) error {
+	item, err := c.getAndPin(ctx, key)
 	if err != nil {
 		return err
 	}
@@ -174,12 +397,72 @@ func (c *lruCache[K, V]) Do(key K, doer func(V) error) error {
 	return doer(item.Value())
 }
 
+func (c *lruCache[K, V]) Touch(key K) bool {
+	return c.peek(key) != nil
+}
+
+func (c *lruCache[K, V]) Keys() []K {
+	c.rwlock.RLock()
+	defer c.rwlock.RUnlock()
+
+	keys := make([]K, 0, len(c.items))
+	for key := range c.items {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (c *lruCache[K, V]) Shrink(target int64) bool {
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+
+	toEvict := make([]K, 0)
+	size := c.scavenger.Size()
+	c.rangeCandidates(func(item *cacheItem[K, V]) bool {
+		if size < target {
+			return false
+		}
+		toEvict = append(toEvict, item.key)
+		c.scavenger.Throw(item.key)
+		size = c.scavenger.Size()
+		return true
+	})
+	for _, key := range toEvict {
+		e := c.items[key]
+		delete(c.items, key)
+		c.accessList.Remove(e)
+		if c.finalizer != nil {
+			c.finalizer(key, e.Value.(*cacheItem[K, V]).value)
+		}
+	}
+	return size < target
+}
+
+func (c *lruCache[K, V]) PauseEviction(timeout time.Duration, hardLimit int64) {
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+	c.pausedUntil = time.Now().Add(timeout)
+	c.hardLimit = hardLimit
+}
+
+func (c *lruCache[K, V]) ResumeEviction() {
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+	c.pausedUntil = time.Time{}
+}
+
+func (c *lruCache[K, V]) evictionPaused() bool {
+	return time.Now().Before(c.pausedUntil)
+}
+
 func (c *lruCache[K, V]) peek(key K) *cacheItem[K, V] {
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
 	e, ok := c.items[key]
 	if ok {
 		item := e.Value.(*cacheItem[K, V])
+		item.lastAccess = time.Now()
+		item.hits++
 		c.accessList.MoveToFront(e)
 		return item
 	}
@@ -187,7 +470,7 @@ func (c *lruCache[K, V]) peek(key K) *cacheItem[K, V] {
 }
 
 // GetAndPin gets and pins the given key if it exists
-func (c *lruCache[K, V]) getAndPin(key K) (*cacheItem[K, V], error) {
+func (c *lruCache[K, V]) getAndPin(ctx context.Context, key K) (*cacheItem[K, V], error) {
 	if item := c.peek(key); item != nil {
 		item.pinCount.Inc()
 		return item, nil
@@ -200,14 +483,17 @@ func (c *lruCache[K, V]) getAndPin(key K) (*cacheItem[K, V], error) {
 			return nil, ErrNotEnoughSpace
 		}
 
+		load := c.joinLoading(key)
+		defer c.leaveLoading(key, load)
+
 		strKey := fmt.Sprint(key)
-		item, err, _ := c.loaderSingleFlight.Do(strKey, func() (interface{}, error) {
+		ch := c.loaderSingleFlight.DoChan(strKey, func() (interface{}, error) {
 			if item := c.peek(key); item != nil {
 				item.pinCount.Inc()
-				return item, nil
+				return c.holdLoaded(load, item), nil
 			}
 
-			value, ok := c.loader(key)
+			value, ok := c.loader(load.ctx, key)
 			if !ok {
 				return nil, ErrNoSuchItem
 			}
@@ -216,17 +502,73 @@ func (c *lruCache[K, V]) getAndPin(key K) (*cacheItem[K, V], error) {
 			if err != nil {
 				return nil, err
 			}
-			return item, nil
+			return c.holdLoaded(load, item), nil
 		})
 
-		if err == nil {
-			return item.(*cacheItem[K, V]), nil
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case result := <-ch:
+			if result.Err == nil {
+				// the item is kept pinned by the load until this requester leaves it
+				item := result.Val.(*cacheItem[K, V])
+				item.pinCount.Inc()
+				return item, nil
+			}
 		}
 	}
 
 	return nil, ErrNoSuchItem
 }
 
+// joinLoading registers the requester to the load of the key, starts a new one if there is none.
+func (c *lruCache[K, V]) joinLoading(key K) *loading[K, V] {
+	c.loadingMu.Lock()
+	defer c.loadingMu.Unlock()
+
+	load, ok := c.loadings[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		load = &loading[K, V]{ctx: ctx, cancel: cancel}
+		c.loadings[key] = load
+	}
+	load.waiters++
+	return load
+}
+
+// holdLoaded hands the pin of the loaded item over to the load,
+// the item is unpinned at once if all the requesters have left.
+func (c *lruCache[K, V]) holdLoaded(load *loading[K, V], item *cacheItem[K, V]) *cacheItem[K, V] {
+	c.loadingMu.Lock()
+	defer c.loadingMu.Unlock()
+
+	if load.waiters == 0 || load.item != nil {
+		// no one is waiting, or the load holds the item already
+		item.Unpin()
+	} else {
+		load.item = item
+	}
+	return item
+}
+
+// leaveLoading unregisters the requester, cancels the load and releases the loaded item if it's the last one.
+func (c *lruCache[K, V]) leaveLoading(key K, load *loading[K, V]) {
+	c.loadingMu.Lock()
+	defer c.loadingMu.Unlock()
+
+	load.waiters--
+	if load.waiters == 0 {
+		load.cancel()
+		if load.item != nil {
+			load.item.Unpin()
+			load.item = nil
+		}
+		delete(c.loadings, key)
+		// the cancelled load shall not be shared with the following requesters
+		c.loaderSingleFlight.Forget(fmt.Sprint(key))
+	}
+}
+
 func (c *lruCache[K, V]) tryScavenge(key K) ([]K, bool) {
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
@@ -236,16 +578,22 @@ func (c *lruCache[K, V]) tryScavenge(key K) ([]K, bool) {
 func (c *lruCache[K, V]) lockfreeTryScavenge(key K) ([]K, bool) {
 	ok, collector := c.scavenger.Collect(key)
 	toEvict := make([]K, 0)
+	if !ok && c.evictionPaused() {
+		if c.scavenger.Overcommit(key, c.hardLimit) {
+			// give back the space like the no collection case
+			c.scavenger.Throw(key)
+			return toEvict, true
+		}
+		// beyond the hard limit, resume the eviction to avoid running out of the resource
+		c.pausedUntil = time.Time{}
+	}
 	if !ok {
 		done := false
-		for p := c.accessList.Back(); p != nil && !done; p = p.Prev() {
-			evictItem := p.Value.(*cacheItem[K, V])
-			if evictItem.pinCount.Load() > 0 {
-				continue
-			}
-			toEvict = append(toEvict, evictItem.key)
-			done = collector(evictItem.key)
-		}
+		c.rangeCandidates(func(item *cacheItem[K, V]) bool {
+			toEvict = append(toEvict, item.key)
+			done = collector(item.key)
+			return !done
+		})
 		if !done {
 			return nil, false
 		}
@@ -256,6 +604,41 @@ func (c *lruCache[K, V]) lockfreeTryScavenge(key K) ([]K, bool) {
 	return toEvict, true
 }
 
+// rangeCandidates iterates the unpinned evictable items in the order to evict until fn returns false,
+// must be called with the rwlock held.
+func (c *lruCache[K, V]) rangeCandidates(fn func(item *cacheItem[K, V]) bool) {
+	isCandidate := func(item *cacheItem[K, V]) bool {
+		return item.pinCount.Load() <= 0 && (c.evictable == nil || c.evictable(item.key, item.value))
+	}
+
+	if c.policy == nil {
+		for p := c.accessList.Back(); p != nil; p = p.Prev() {
+			item := p.Value.(*cacheItem[K, V])
+			if isCandidate(item) && !fn(item) {
+				return
+			}
+		}
+		return
+	}
+
+	stats := make([]ItemStats[K], 0, len(c.items))
+	for p := c.accessList.Back(); p != nil; p = p.Prev() {
+		item := p.Value.(*cacheItem[K, V])
+		if isCandidate(item) {
+			stats = append(stats, ItemStats[K]{Key: item.key, LastAccess: item.lastAccess, Hits: item.hits})
+		}
+	}
+	for _, key := range c.policy.Candidates(stats) {
+		e, ok := c.items[key]
+		if !ok {
+			continue
+		}
+		if !fn(e.Value.(*cacheItem[K, V])) {
+			return
+		}
+	}
+}
+
 // for cache miss
 func (c *lruCache[K, V]) setAndPin(key K, value V) (*cacheItem[K, V], error) {
 	c.rwlock.Lock()
@@ -286,7 +669,10 @@ func (c *lruCache[K, V]) setAndPin(key K, value V) (*cacheItem[K, V], error) {
 		}
 	}
 
-	c.scavenger.Collect(key)
+	if ok, _ := c.scavenger.Collect(key); !ok {
+		// no room since the eviction paused
+		c.scavenger.Overcommit(key, c.hardLimit)
+	}
 	e := c.accessList.PushFront(item)
 	c.items[item.key] = e
 
@@ -1,16 +1,18 @@
 package cache
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestLRUCache(t *testing.T) {
-	cacheBuilder := NewCacheBuilder[int, int]().WithLoader(func(key int) (int, bool) {
+	cacheBuilder := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
 		return key, true
 	})
 
@@ -19,7 +21,7 @@ func TestLRUCache(t *testing.T) {
 		cache := cacheBuilder.WithCapacity(int64(size)).Build()
 
 		for i := 0; i < size; i++ {
-			err := cache.Do(i, func(v int) error {
+			err := cache.Do(context.Background(), i, func(v int) error {
 				assert.Equal(t, i, v)
 				return nil
 			})
@@ -36,7 +38,7 @@ func TestLRUCache(t *testing.T) {
 		}).Build()
 
 		for i := 0; i < size*2; i++ {
-			err := cache.Do(i, func(v int) error {
+			err := cache.Do(context.Background(), i, func(v int) error {
 				assert.Equal(t, i, v)
 				return nil
 			})
@@ -46,7 +48,7 @@ func TestLRUCache(t *testing.T) {
 
 		// Hit the cache again, there should be no swap-out
 		for i := size; i < size*2; i++ {
-			err := cache.Do(i, func(v int) error {
+			err := cache.Do(context.Background(), i, func(v int) error {
 				assert.Equal(t, i, v)
 				return nil
 			})
@@ -66,7 +68,7 @@ func TestLRUCache(t *testing.T) {
 		}).Build()
 
 		for i := 0; i < 20; i++ {
-			err := cache.Do(i, func(v int) error {
+			err := cache.Do(context.Background(), i, func(v int) error {
 				assert.Equal(t, i, v)
 				return nil
 			})
@@ -78,7 +80,7 @@ func TestLRUCache(t *testing.T) {
 	t.Run("test do negative", func(t *testing.T) {
 		cache := cacheBuilder.Build()
 		theErr := errors.New("error")
-		err := cache.Do(-1, func(v int) error {
+		err := cache.Do(context.Background(), -1, func(v int) error {
 			return theErr
 		})
 		assert.Equal(t, theErr, err)
@@ -95,31 +97,31 @@ func TestLRUCache(t *testing.T) {
 		}).Build()
 
 		for i := 0; i < 20; i++ {
-			err := cache.Do(i, func(v int) error {
+			err := cache.Do(context.Background(), i, func(v int) error {
 				assert.Equal(t, i, v)
 				return nil
 			})
 			assert.NoError(t, err)
 		}
 		assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18}, finalizeSeq)
-		err := cache.Do(100, func(v int) error {
+		err := cache.Do(context.Background(), 100, func(v int) error {
 			return nil
 		})
 		assert.Equal(t, ErrNotEnoughSpace, err)
 	})
 
 	t.Run("test load negative", func(t *testing.T) {
-		cache := NewCacheBuilder[int, int]().WithLoader(func(key int) (int, bool) {
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
 			if key < 0 {
 				return 0, false
 			}
 			return key, true
 		}).Build()
-		err := cache.Do(0, func(v int) error {
+		err := cache.Do(context.Background(), 0, func(v int) error {
 			return nil
 		})
 		assert.NoError(t, err)
-		err = cache.Do(-1, func(v int) error {
+		err = cache.Do(context.Background(), -1, func(v int) error {
 			return nil
 		})
 		assert.Equal(t, ErrNoSuchItem, err)
@@ -129,7 +131,7 @@ func TestLRUCache(t *testing.T) {
 func TestLRUCacheConcurrency(t *testing.T) {
 	t.Run("test race condition", func(t *testing.T) {
 		numEvict := new(atomic.Int32)
-		cache := NewCacheBuilder[int, int]().WithLoader(func(key int) (int, bool) {
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
 			return key, true
 		}).WithCapacity(10).WithFinalizer(func(key, value int) error {
 			numEvict.Add(1)
@@ -142,7 +144,7 @@ func TestLRUCacheConcurrency(t *testing.T) {
 			go func(i int) {
 				defer wg.Done()
 				for j := 0; j < 100; j++ {
-					err := cache.Do(j, func(v int) error {
+					err := cache.Do(context.Background(), j, func(v int) error {
 						return nil
 					})
 					assert.NoError(t, err)
@@ -153,7 +155,7 @@ func TestLRUCacheConcurrency(t *testing.T) {
 	})
 
 	t.Run("test not enough space", func(t *testing.T) {
-		cache := NewCacheBuilder[int, int]().WithLoader(func(key int) (int, bool) {
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
 			return key, true
 		}).WithCapacity(1).WithFinalizer(func(key, value int) error {
 			return nil
@@ -163,16 +165,420 @@ func TestLRUCacheConcurrency(t *testing.T) {
 		var wg1 sync.WaitGroup // Make sure goroutine is started
 		wg.Add(1)
 		wg1.Add(1)
-		go cache.Do(1000, func(v int) error {
+		go cache.Do(context.Background(), 1000, func(v int) error {
 			wg1.Done()
 			wg.Wait()
 			return nil
 		})
 		wg1.Wait()
-		err := cache.Do(1001, func(v int) error {
+		err := cache.Do(context.Background(), 1001, func(v int) error {
 			return nil
 		})
 		wg.Done()
 		assert.Equal(t, ErrNotEnoughSpace, err)
 	})
+
+	t.Run("test skip not evictable", func(t *testing.T) {
+		var evicted []int
+		inUse := map[int]bool{1: true}
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+			return key, true
+		}).WithCapacity(2).WithEvictable(func(key, value int) bool {
+			return !inUse[key]
+		}).WithFinalizer(func(key, value int) error {
+			evicted = append(evicted, key)
+			return nil
+		}).Build()
+
+		noop := func(v int) error { return nil }
+		assert.NoError(t, cache.Do(context.Background(), 1, noop))
+		assert.NoError(t, cache.Do(context.Background(), 2, noop))
+		// key 1 is the LRU candidate but still in use, key 2 is evicted instead
+		assert.NoError(t, cache.Do(context.Background(), 3, noop))
+		assert.Equal(t, []int{2}, evicted)
+
+		// nothing evictable
+		inUse[3] = true
+		err := cache.Do(context.Background(), 4, noop)
+		assert.Equal(t, ErrNotEnoughSpace, err)
+		assert.Equal(t, []int{2}, evicted)
+	})
+}
+
+func TestLRUCacheCancelLoading(t *testing.T) {
+	t.Run("test cancel sole requester", func(t *testing.T) {
+		started := make(chan struct{})
+		loadCancelled := make(chan struct{})
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+			close(started)
+			<-ctx.Done()
+			close(loadCancelled)
+			return 0, false
+		}).Build()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-started
+			cancel()
+		}()
+		err := cache.Do(ctx, 1, func(v int) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		select {
+		case <-loadCancelled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("load not cancelled")
+		}
+	})
+
+	t.Run("test cancel one of requesters", func(t *testing.T) {
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		loadCtxErr := make(chan error, 1)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+			close(started)
+			<-finish
+			loadCtxErr <- ctx.Err()
+			return key, true
+		}).Build()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := cache.Do(context.Background(), 1, func(v int) error {
+				assert.Equal(t, 1, v)
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+		<-started
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := cache.Do(ctx, 1, func(v int) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		close(finish)
+		wg.Wait()
+		assert.NoError(t, <-loadCtxErr)
+	})
+}
+
+func TestLRUCacheLoadingPins(t *testing.T) {
+	pinCountOf := func(c Cache[int, int], key int) int32 {
+		lru := c.(*lruCache[int, int])
+		lru.rwlock.RLock()
+		defer lru.rwlock.RUnlock()
+		e, ok := lru.items[key]
+		if !ok {
+			return -1
+		}
+		return e.Value.(*cacheItem[int, int]).pinCount.Load()
+	}
+
+	t.Run("test cancel while load completes", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			ctx, cancel := context.WithCancel(context.Background())
+			cache := NewCacheBuilder[int, int]().WithLoader(func(_ context.Context, key int) (int, bool) {
+				// the requester gives up as the load completes
+				cancel()
+				return key, true
+			}).Build()
+
+			_ = cache.Do(ctx, 1, func(v int) error {
+				assert.EqualValues(t, 1, pinCountOf(cache, 1))
+				return nil
+			})
+
+			// the load completes after the requester returned or gave up, no pin is left either way
+			assert.Eventually(t, func() bool {
+				return pinCountOf(cache, 1) == 0
+			}, 5*time.Second, time.Millisecond)
+		}
+	})
+
+	t.Run("test requesters sharing a load", func(t *testing.T) {
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+			close(started)
+			<-finish
+			return key, true
+		}).Build()
+
+		var wg sync.WaitGroup
+		pinned := make(chan struct{})
+		release := make(chan struct{})
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := cache.Do(context.Background(), 1, func(v int) error {
+					pinned <- struct{}{}
+					<-release
+					return nil
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		<-started
+		assert.Eventually(t, func() bool {
+			lru := cache.(*lruCache[int, int])
+			lru.loadingMu.Lock()
+			defer lru.loadingMu.Unlock()
+			return lru.loadings[1].waiters == 3
+		}, 5*time.Second, time.Millisecond)
+		close(finish)
+		for i := 0; i < 3; i++ {
+			<-pinned
+		}
+		assert.EqualValues(t, 3, pinCountOf(cache, 1))
+		close(release)
+		wg.Wait()
+		assert.EqualValues(t, 0, pinCountOf(cache, 1))
+	})
+}
+
+func TestLRUCachePauseEviction(t *testing.T) {
+	cacheBuilder := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+		return key, true
+	})
+	noop := func(int) error { return nil }
+
+	t.Run("test pause and resume", func(t *testing.T) {
+		finalizeSeq := make([]int, 0)
+		cache := cacheBuilder.WithCapacity(2).WithFinalizer(func(key, value int) error {
+			finalizeSeq = append(finalizeSeq, key)
+			return nil
+		}).Build()
+
+		cache.PauseEviction(time.Minute, 4)
+		for i := 0; i < 4; i++ {
+			assert.NoError(t, cache.Do(context.Background(), i, noop))
+		}
+		assert.Empty(t, finalizeSeq)
+
+		// evicts down to the capacity once resumed
+		cache.ResumeEviction()
+		assert.NoError(t, cache.Do(context.Background(), 4, noop))
+		assert.Equal(t, []int{0, 1, 2}, finalizeSeq)
+	})
+
+	t.Run("test resume beyond hard limit", func(t *testing.T) {
+		finalizeSeq := make([]int, 0)
+		cache := cacheBuilder.WithCapacity(2).WithFinalizer(func(key, value int) error {
+			finalizeSeq = append(finalizeSeq, key)
+			return nil
+		}).Build()
+
+		cache.PauseEviction(time.Minute, 3)
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, cache.Do(context.Background(), i, noop))
+		}
+		assert.Empty(t, finalizeSeq)
+
+		assert.NoError(t, cache.Do(context.Background(), 3, noop))
+		assert.Equal(t, []int{0, 1}, finalizeSeq)
+	})
+
+	t.Run("test resume after timeout", func(t *testing.T) {
+		finalizeSeq := make([]int, 0)
+		cache := cacheBuilder.WithCapacity(1).WithFinalizer(func(key, value int) error {
+			finalizeSeq = append(finalizeSeq, key)
+			return nil
+		}).Build()
+
+		cache.PauseEviction(50*time.Millisecond, 10)
+		assert.NoError(t, cache.Do(context.Background(), 0, noop))
+		assert.NoError(t, cache.Do(context.Background(), 1, noop))
+		assert.Empty(t, finalizeSeq)
+
+		time.Sleep(100 * time.Millisecond)
+		assert.NoError(t, cache.Do(context.Background(), 2, noop))
+		assert.Equal(t, []int{0, 1}, finalizeSeq)
+	})
+}
+
+func TestLRUCacheCountLimit(t *testing.T) {
+	noop := func(int) error { return nil }
+
+	t.Run("test evict by count", func(t *testing.T) {
+		finalizeSeq := make([]int, 0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+			return key, true
+		}).WithLazyScavenger(func(key int) int64 {
+			return 10
+		}, 1000).WithCountLimit(2).WithFinalizer(func(key, value int) error {
+			finalizeSeq = append(finalizeSeq, key)
+			return nil
+		}).Build()
+
+		for i := 0; i < 4; i++ {
+			assert.NoError(t, cache.Do(context.Background(), i, noop))
+		}
+		// far under the weight capacity, but evicted by count
+		assert.Equal(t, []int{0, 1}, finalizeSeq)
+	})
+
+	t.Run("test evict by weight", func(t *testing.T) {
+		finalizeSeq := make([]int, 0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+			return key, true
+		}).WithLazyScavenger(func(key int) int64 {
+			return int64(key)
+		}, 5).WithCountLimit(10).WithFinalizer(func(key, value int) error {
+			finalizeSeq = append(finalizeSeq, key)
+			return nil
+		}).Build()
+
+		for i := 1; i <= 3; i++ {
+			assert.NoError(t, cache.Do(context.Background(), i, noop))
+		}
+		assert.Equal(t, []int{1}, finalizeSeq)
+	})
+
+	t.Run("test count limit while paused", func(t *testing.T) {
+		finalizeSeq := make([]int, 0)
+		cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+			return key, true
+		}).WithCapacity(1).WithCountLimit(2).WithFinalizer(func(key, value int) error {
+			finalizeSeq = append(finalizeSeq, key)
+			return nil
+		}).Build()
+
+		cache.PauseEviction(time.Minute, 10)
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, cache.Do(context.Background(), i, noop))
+		}
+		// the count limit is never overcommitted, the eviction resumes beyond it
+		assert.Equal(t, []int{0, 1}, finalizeSeq)
+	})
+}
+
+func TestLRUCacheTouch(t *testing.T) {
+	loaded := make([]int, 0)
+	finalizeSeq := make([]int, 0)
+	cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+		loaded = append(loaded, key)
+		return key, true
+	}).WithCapacity(2).WithFinalizer(func(key, value int) error {
+		finalizeSeq = append(finalizeSeq, key)
+		return nil
+	}).Build()
+	noop := func(int) error { return nil }
+
+	assert.NoError(t, cache.Do(context.Background(), 0, noop))
+	assert.NoError(t, cache.Do(context.Background(), 1, noop))
+
+	// the least recently used one survives the eviction once touched
+	assert.True(t, cache.Touch(0))
+	assert.NoError(t, cache.Do(context.Background(), 2, noop))
+	assert.Equal(t, []int{1}, finalizeSeq)
+
+	// never loads the absent one
+	assert.False(t, cache.Touch(1))
+	assert.Equal(t, []int{0, 1, 2}, loaded)
+}
+
+func TestLRUCacheShrink(t *testing.T) {
+	finalizeSeq := make([]int, 0)
+	cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+		return key, true
+	}).WithLazyScavenger(func(key int) int64 {
+		return 10
+	}, 100).WithFinalizer(func(key, value int) error {
+		finalizeSeq = append(finalizeSeq, key)
+		return nil
+	}).Build()
+	noop := func(int) error { return nil }
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, cache.Do(context.Background(), i, noop))
+	}
+	assert.True(t, cache.Shrink(60))
+	assert.Empty(t, finalizeSeq)
+
+	assert.True(t, cache.Shrink(25))
+	assert.Equal(t, []int{0, 1, 2}, finalizeSeq)
+
+	// the pinned one is never evicted
+	pinned := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.Do(context.Background(), 4, func(int) error {
+			pinned <- struct{}{}
+			<-pinned
+			return nil
+		})
+	}()
+	<-pinned
+	assert.False(t, cache.Shrink(5))
+	assert.Equal(t, []int{0, 1, 2, 3}, finalizeSeq)
+
+	pinned <- struct{}{}
+	<-done
+	assert.True(t, cache.Shrink(5))
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, finalizeSeq)
+}
+
+func TestLRUCacheEvictionPolicy(t *testing.T) {
+	weight := func(key int) int64 {
+		if key == 1 {
+			return 5
+		}
+		return 1
+	}
+	cases := []struct {
+		name    string
+		policy  EvictionPolicy[int]
+		evicted []int
+	}{
+		{"default", nil, []int{0}},
+		{"lru", LRUPolicy[int]{}, []int{0}},
+		// the large item 1 is evicted instead of the small item 0 used less recently
+		{"size weighted lru", NewSizeWeightedLRUPolicy(weight), []int{1}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			finalizeSeq := make([]int, 0)
+			cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+				return key, true
+			}).WithLazyScavenger(weight, 9).WithEvictionPolicy(c.policy).WithFinalizer(func(key, value int) error {
+				finalizeSeq = append(finalizeSeq, key)
+				return nil
+			}).Build()
+			noop := func(int) error { return nil }
+
+			// the same access pattern for all the policies
+			for i := 0; i < 5; i++ {
+				assert.NoError(t, cache.Do(context.Background(), i, noop))
+			}
+			assert.Empty(t, finalizeSeq)
+			assert.NoError(t, cache.Do(context.Background(), 5, noop))
+			assert.Equal(t, c.evicted, finalizeSeq)
+		})
+	}
+}
+
+func TestLRUCacheKeys(t *testing.T) {
+	cache := NewCacheBuilder[int, int]().WithLoader(func(ctx context.Context, key int) (int, bool) {
+		return key, key >= 0
+	}).WithCapacity(2).Build()
+	noop := func(int) error { return nil }
+
+	assert.Empty(t, cache.Keys())
+	assert.NoError(t, cache.Do(context.Background(), 0, noop))
+	assert.NoError(t, cache.Do(context.Background(), 1, noop))
+	assert.ErrorIs(t, cache.Do(context.Background(), -1, noop), ErrNoSuchItem)
+	assert.ElementsMatch(t, []int{0, 1}, cache.Keys())
+
+	// the evicted one is gone
+	assert.NoError(t, cache.Do(context.Background(), 2, noop))
+	assert.ElementsMatch(t, []int{1, 2}, cache.Keys())
 }
@@ -113,6 +113,7 @@ var (
 	ErrMqTopicNotEmpty = newMilvusError("topic not empty", 1301, false)
 	ErrMqInternal      = newMilvusError("message queue internal error", 1302, false)
 	ErrDenyProduceMsg  = newMilvusError("deny to write the message to mq", 1303, false)
+	ErrMqMsgCorrupted  = newMilvusError("message corrupted", 1304, false)
 
 	// Privilege related
 	// this operation is denied because the user not authorized, user need to login in first
@@ -835,6 +835,14 @@ func WrapErrMqTopicNotEmpty(name string, msg ...string) error {
 	return err
 }
 
+func WrapErrMqMsgCorrupted(topic string, msg ...string) error {
+	err := wrapFields(ErrMqMsgCorrupted, value("topic", topic))
+	if len(msg) > 0 {
+		err = errors.Wrap(err, strings.Join(msg, "->"))
+	}
+	return err
+}
+
 func WrapErrMqInternal(err error, msg ...string) error {
 	err = wrapFieldsWithDesc(ErrMqInternal, err.Error())
 	if len(msg) > 0 {
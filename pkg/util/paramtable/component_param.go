@@ -1995,6 +1995,13 @@ type queryNodeConfig struct {
 
 	MemoryIndexLoadPredictMemoryUsageFactor ParamItem `refreshable:"true"`
 	EnableSegmentPrune                      ParamItem `refreshable:"false"`
+	EnableSegmentFilterCache                ParamItem `refreshable:"false"`
+	SegmentFilterCacheSize                  ParamItem `refreshable:"false"`
+	SegmentAuditTrailSize                   ParamItem `refreshable:"false"`
+	DiskCacheEvictionPauseTimeout           ParamItem `refreshable:"true"`
+	DiskCacheOvercommitRatio                ParamItem `refreshable:"true"`
+	DiskCacheSegmentNumLimit                ParamItem `refreshable:"false"`
+	MaxGrowingSegmentNumPerChannel          ParamItem `refreshable:"true"`
 }
 
 func (p *queryNodeConfig) init(base *BaseTable) {
@@ -2475,6 +2482,62 @@ Max read concurrency must greater than or equal to 1, and less than or equal to
 		Doc:          "use partition prune function on shard delegator",
 	}
 	p.EnableSegmentPrune.Init(base.mgr)
+
+	p.EnableSegmentFilterCache = ParamItem{
+		Key:          "queryNode.enableSegmentFilterCache",
+		Version:      "2.4.0",
+		DefaultValue: "false",
+		Doc:          "cache the segments matching the same filters until the segments change",
+	}
+	p.EnableSegmentFilterCache.Init(base.mgr)
+
+	p.SegmentFilterCacheSize = ParamItem{
+		Key:          "queryNode.segmentFilterCacheSize",
+		Version:      "2.4.0",
+		DefaultValue: "1024",
+		Doc:          "the max number of the filters whose matched segments are cached, the least recently used ones are evicted",
+	}
+	p.SegmentFilterCacheSize.Init(base.mgr)
+
+	p.SegmentAuditTrailSize = ParamItem{
+		Key:          "queryNode.segmentAuditTrailSize",
+		Version:      "2.4.0",
+		DefaultValue: "0",
+		Doc:          "the number of the latest segment manager operations kept for the post-incident analysis, disabled if not positive",
+	}
+	p.SegmentAuditTrailSize.Init(base.mgr)
+
+	p.DiskCacheEvictionPauseTimeout = ParamItem{
+		Key:          "queryNode.diskCache.evictionPauseTimeout",
+		Version:      "2.4.0",
+		DefaultValue: "300",
+		Doc:          "the eviction of the disk cache resumes automatically after paused for this long, in seconds",
+	}
+	p.DiskCacheEvictionPauseTimeout.Init(base.mgr)
+
+	p.DiskCacheOvercommitRatio = ParamItem{
+		Key:          "queryNode.diskCache.overcommitRatio",
+		Version:      "2.4.0",
+		DefaultValue: "1.2",
+		Doc:          "the disk cache could grow up to this ratio of the disk capacity while the eviction paused, the eviction resumes beyond it",
+	}
+	p.DiskCacheOvercommitRatio.Init(base.mgr)
+
+	p.DiskCacheSegmentNumLimit = ParamItem{
+		Key:          "queryNode.diskCache.segmentNumLimit",
+		Version:      "2.4.0",
+		DefaultValue: "0",
+		Doc:          "the max number of sealed segments cached alongside the disk capacity, no limit if not positive",
+	}
+	p.DiskCacheSegmentNumLimit.Init(base.mgr)
+
+	p.MaxGrowingSegmentNumPerChannel = ParamItem{
+		Key:          "queryNode.maxGrowingSegmentNumPerChannel",
+		Version:      "2.4.0",
+		DefaultValue: "0",
+		Doc:          "the max number of growing segments on a channel, the ingestion is throttled beyond it, no limit if not positive",
+	}
+	p.MaxGrowingSegmentNumPerChannel.Init(base.mgr)
 }
 
 // /////////////////////////////////////////////////////////////////////////////
@@ -365,6 +365,14 @@ func TestComponentParam(t *testing.T) {
 		assert.Equal(t, 2.5, Params.MemoryIndexLoadPredictMemoryUsageFactor.GetAsFloat())
 		params.Save("queryNode.memoryIndexLoadPredictMemoryUsageFactor", "2.0")
 		assert.Equal(t, 2.0, Params.MemoryIndexLoadPredictMemoryUsageFactor.GetAsFloat())
+
+		assert.Equal(t, 300*time.Second, Params.DiskCacheEvictionPauseTimeout.GetAsDuration(time.Second))
+		assert.Equal(t, 1.2, Params.DiskCacheOvercommitRatio.GetAsFloat())
+		assert.Equal(t, int64(0), Params.DiskCacheSegmentNumLimit.GetAsInt64())
+		assert.False(t, Params.EnableSegmentFilterCache.GetAsBool())
+		assert.Equal(t, 1024, Params.SegmentFilterCacheSize.GetAsInt())
+		assert.Equal(t, 0, Params.SegmentAuditTrailSize.GetAsInt())
+		assert.Equal(t, 0, Params.MaxGrowingSegmentNumPerChannel.GetAsInt())
 	})
 
 	t.Run("test dataCoordConfig", func(t *testing.T) {
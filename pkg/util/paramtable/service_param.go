@@ -473,6 +473,12 @@ type MQConfig struct {
 
 	MQBufSize      ParamItem `refreshable:"false"`
 	ReceiveBufSize ParamItem `refreshable:"false"`
+	EnableChecksum ParamItem `refreshable:"true"`
+
+	StrictInsertValidation ParamItem `refreshable:"true"`
+
+	InsertCompression          ParamItem `refreshable:"false"`
+	InsertCompressionThreshold ParamItem `refreshable:"false"`
 }
 
 // Init initializes the MQConfig object with a BaseTable.
@@ -530,6 +536,44 @@ Valid values: [default, pulsar, kafka, rocksmq, natsmq]`,
 		Doc:          "MQ consumer chan buffer length",
 	}
 	p.ReceiveBufSize.Init(base.mgr)
+
+	p.EnableChecksum = ParamItem{
+		Key:          "mq.enableChecksum",
+		Version:      "2.4.0",
+		DefaultValue: "false",
+		Doc:          "attach a CRC32 checksum to the produced messages, the consumers verify it if present",
+		Export:       true,
+	}
+	p.EnableChecksum.Init(base.mgr)
+
+	p.StrictInsertValidation = ParamItem{
+		Key:          "mq.strictInsertValidation",
+		Version:      "2.4.0",
+		DefaultValue: "false",
+		Doc:          "reject the consumed insert messages whose columns are misaligned with the timestamps",
+		Export:       true,
+	}
+	p.StrictInsertValidation.Init(base.mgr)
+
+	p.InsertCompression = ParamItem{
+		Key:          "mq.insertCompression",
+		Version:      "2.4.0",
+		DefaultValue: "none",
+		Doc: `the codec compressing the large insert messages produced, the consumers detect it from the payload,
+upgrade all the consumers before enabling it
+Valid values: [none, zstd, snappy]`,
+		Export: true,
+	}
+	p.InsertCompression.Init(base.mgr)
+
+	p.InsertCompressionThreshold = ParamItem{
+		Key:          "mq.insertCompressionThreshold",
+		Version:      "2.4.0",
+		DefaultValue: "1048576",
+		Doc:          "the insert messages smaller than the threshold in bytes are produced uncompressed",
+		Export:       true,
+	}
+	p.InsertCompressionThreshold.Init(base.mgr)
 }
 
 // /////////////////////////////////////////////////////////////////////////////
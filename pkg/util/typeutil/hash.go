@@ -77,7 +77,11 @@ func HashString2Uint32(v string) uint32 {
 
 // HashPK2Channels hash primary keys to channels
 func HashPK2Channels(primaryKeys *schemapb.IDs, shardNames []string) []uint32 {
-	numShard := uint32(len(shardNames))
+	return HashPK2Shards(primaryKeys, uint32(len(shardNames)))
+}
+
+// HashPK2Shards hash primary keys to shard indexes in [0, numShard)
+func HashPK2Shards(primaryKeys *schemapb.IDs, numShard uint32) []uint32 {
 	var hashValues []uint32
 	switch primaryKeys.IdField.(type) {
 	case *schemapb.IDs_IntId: